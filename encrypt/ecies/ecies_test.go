@@ -1,11 +1,14 @@
 package ecies
 
 import (
+	"bytes"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/drand/kyber/group/edwards25519"
 	"github.com/drand/kyber/util/random"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 func TestECIES(t *testing.T) {
@@ -44,3 +47,92 @@ func TestECIESFailCiphertext(t *testing.T) {
 	_, err = Decrypt(suite, private, ciphertext, nil)
 	require.NotNil(t, err)
 }
+
+func TestECIESSharedKey(t *testing.T) {
+	message := []byte("Hello ECIES")
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	private := suite.Scalar().Pick(random.New())
+	public := suite.Point().Mul(private, nil)
+	ciphertext, err := Encrypt(suite, public, message, nil)
+	require.Nil(t, err)
+	R, err := EphemeralKey(suite, ciphertext)
+	require.Nil(t, err)
+	dh := suite.Point().Mul(private, R)
+	plaintext, err := DecryptWithSharedKey(suite, dh, ciphertext, nil)
+	require.Nil(t, err)
+	require.Equal(t, message, plaintext)
+
+	_, err = DecryptWithSharedKey(suite, R, ciphertext, nil)
+	require.NotNil(t, err)
+	_, err = EphemeralKey(suite, ciphertext[:suite.PointLen()-1])
+	require.NotNil(t, err)
+}
+
+func TestECIESOptions(t *testing.T) {
+	message := []byte("Hello ECIES")
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	private := suite.Scalar().Pick(random.New())
+	public := suite.Point().Mul(private, nil)
+
+	// the default options are interoperable with Encrypt and Decrypt
+	ciphertext, err := EncryptWithOptions(suite, public, message, nil)
+	require.Nil(t, err)
+	plaintext, err := Decrypt(suite, private, ciphertext, nil)
+	require.Nil(t, err)
+	require.Equal(t, message, plaintext)
+	ciphertext, err = Encrypt(suite, public, message, suite.Hash)
+	require.Nil(t, err)
+	plaintext, err = DecryptWithOptions(suite, private, ciphertext, &Options{Hash: suite.Hash})
+	require.Nil(t, err)
+	require.Equal(t, message, plaintext)
+
+	opts := &Options{AEAD: chacha20poly1305.New, AdditionalData: []byte("context")}
+	ciphertext, err = EncryptWithOptions(suite, public, message, opts)
+	require.Nil(t, err)
+	plaintext, err = DecryptWithOptions(suite, private, ciphertext, opts)
+	require.Nil(t, err)
+	require.Equal(t, message, plaintext)
+
+	_, err = DecryptWithOptions(suite, private, ciphertext, &Options{AEAD: chacha20poly1305.New})
+	require.NotNil(t, err)
+	_, err = DecryptWithOptions(suite, private, ciphertext, &Options{AdditionalData: opts.AdditionalData})
+	require.NotNil(t, err)
+	_, err = EncryptWithOptions(suite, public, message, &Options{KeySize: 7})
+	require.NotNil(t, err)
+}
+
+func TestECIESStream(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	private := suite.Scalar().Pick(random.New())
+	public := suite.Point().Mul(private, nil)
+	message := random.Bits(8*(3*64*1024+100), false, random.New())
+	opts := &Options{AEAD: chacha20poly1305.New, AdditionalData: []byte("context")}
+
+	var ciphertext bytes.Buffer
+	w, err := EncryptStream(suite, public, &ciphertext, opts)
+	require.Nil(t, err)
+	_, err = w.Write(message)
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	r, err := DecryptStream(suite, private, bytes.NewReader(ciphertext.Bytes()), opts)
+	require.Nil(t, err)
+	plaintext, err := io.ReadAll(r)
+	require.Nil(t, err)
+	require.Equal(t, message, plaintext)
+
+	// a truncated stream is detected even at a chunk boundary
+	l := suite.PointLen() + 64*1024 + chacha20poly1305.Overhead
+	r, err = DecryptStream(suite, private, bytes.NewReader(ciphertext.Bytes()[:l]), opts)
+	require.Nil(t, err)
+	_, err = io.ReadAll(r)
+	require.NotNil(t, err)
+
+	r, err = DecryptStream(suite, private, bytes.NewReader(ciphertext.Bytes()), &Options{AEAD: chacha20poly1305.New})
+	require.Nil(t, err)
+	_, err = io.ReadAll(r)
+	require.NotNil(t, err)
+
+	_, err = DecryptStream(suite, private, bytes.NewReader(ciphertext.Bytes()[:3]), opts)
+	require.NotNil(t, err)
+}
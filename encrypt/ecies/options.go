@@ -0,0 +1,175 @@
+package ecies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/encrypt/internal/stream"
+	"github.com/drand/kyber/util/random"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Options configures the encryption of EncryptWithOptions, DecryptWithOptions,
+// EncryptStream and DecryptStream. The zero value, like a nil *Options, gives
+// the format of Encrypt and Decrypt with SHA256.
+type Options struct {
+	// Hash is the hash function of HKDF, SHA256 if nil.
+	Hash func() hash.Hash
+	// AEAD returns the AEAD encrypting the message with a key of KeySize
+	// bytes, such as chacha20poly1305.New. It is AES-GCM if nil.
+	AEAD func(key []byte) (cipher.AEAD, error)
+	// KeySize is the size of the key of the AEAD, 32 bytes if zero.
+	KeySize int
+	// AdditionalData is authenticated, but neither encrypted nor included in
+	// the ciphertext. The same additional data must be given to decrypt.
+	AdditionalData []byte
+}
+
+// streamInfo is the HKDF info deriving the key of the streaming mode, which
+// differs from the key of the other mode for the same shared DH key.
+const streamInfo = "ecies-stream"
+
+func (o *Options) hash() func() hash.Hash {
+	if o == nil || o.Hash == nil {
+		return sha256.New
+	}
+	return o.Hash
+}
+
+func (o *Options) additionalData() []byte {
+	if o == nil {
+		return nil
+	}
+	return o.AdditionalData
+}
+
+// aead derives from the shared DH key with the HKDF info the key of the AEAD,
+// and returns the AEAD and the reader of the rest of the HKDF output.
+func (o *Options) aead(dh kyber.Point, info []byte) (cipher.AEAD, io.Reader, error) {
+	dhb, err := dh.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	newAEAD := newAESGCM
+	keySize := 32
+	if o != nil && o.AEAD != nil {
+		newAEAD = o.AEAD
+	}
+	if o != nil && o.KeySize != 0 {
+		keySize = o.KeySize
+	}
+	if keySize < 0 {
+		return nil, nil, errors.New("ecies: invalid key size")
+	}
+	kdf := hkdf.New(o.hash(), dhb, nil, info)
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(kdf, key); err != nil {
+		return nil, nil, errors.New("ecies: hkdf-derived key too short")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, kdf, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	aes, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(aes)
+}
+
+// EncryptWithOptions is Encrypt with the hash function, the AEAD and the
+// additional data of the options. The nonce of the AEAD is derived with HKDF
+// right after its key, and the format is the one of Encrypt.
+func EncryptWithOptions(group kyber.Group, public kyber.Point, message []byte, opts *Options) ([]byte, error) {
+	r := group.Scalar().Pick(random.New())
+	R := group.Point().Mul(r, nil)
+	dh := group.Point().Mul(r, public)
+
+	aead, kdf, err := opts.aead(dh, nil)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(kdf, nonce); err != nil {
+		return nil, errors.New("ecies: hkdf-derived nonce too short")
+	}
+	ctx, err := R.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(ctx, nonce, message, opts.additionalData()), nil
+}
+
+// DecryptWithOptions decrypts a ciphertext of EncryptWithOptions with the
+// same options.
+func DecryptWithOptions(group kyber.Group, private kyber.Scalar, ctx []byte, opts *Options) ([]byte, error) {
+	R, err := EphemeralKey(group, ctx)
+	if err != nil {
+		return nil, err
+	}
+	dh := group.Point().Mul(private, R)
+	aead, kdf, err := opts.aead(dh, nil)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(kdf, nonce); err != nil {
+		return nil, errors.New("ecies: hkdf-derived nonce too short")
+	}
+	return aead.Open(nil, nonce, ctx[group.PointLen():], opts.additionalData())
+}
+
+// EncryptStream encrypts to the public key the message written to the
+// returned writer, for messages too large to be held in memory, and writes the
+// ciphertext to dst. The writer must be closed to finish the encryption,
+// which does not close dst.
+//
+// The ciphertext is the ephemeral point followed by the message in chunks of
+// 64KiB, each one encrypted with the AEAD and the additional data of the
+// options under a counter nonce, whose last byte flags the last chunk so that
+// truncated streams are detected. The AEAD key is derived with HKDF from the
+// shared DH key, with another info than EncryptWithOptions.
+func EncryptStream(group kyber.Group, public kyber.Point, dst io.Writer, opts *Options) (io.WriteCloser, error) {
+	r := group.Scalar().Pick(random.New())
+	R := group.Point().Mul(r, nil)
+	dh := group.Point().Mul(r, public)
+
+	aead, _, err := opts.aead(dh, []byte(streamInfo))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := R.MarshalTo(dst); err != nil {
+		return nil, err
+	}
+	w := stream.NewWriter(aead, dst)
+	w.AdditionalData = opts.additionalData()
+	return w, nil
+}
+
+// DecryptStream decrypts a ciphertext of EncryptStream read from src with
+// the same options. The returned reader authenticates the message chunk by
+// chunk as it is read, and returns io.EOF once the whole message is
+// authenticated. Any other error means that the ciphertext is invalid.
+func DecryptStream(group kyber.Group, private kyber.Scalar, src io.Reader, opts *Options) (io.Reader, error) {
+	R := group.Point()
+	if _, err := R.UnmarshalFrom(src); err != nil {
+		return nil, err
+	}
+	dh := group.Point().Mul(private, R)
+	aead, _, err := opts.aead(dh, []byte(streamInfo))
+	if err != nil {
+		return nil, err
+	}
+	r := stream.NewReader(aead, src)
+	r.AdditionalData = opts.additionalData()
+	return r, nil
+}
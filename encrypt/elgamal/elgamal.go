@@ -0,0 +1,131 @@
+// Package elgamal implements the ElGamal encryption scheme over any
+// kyber.Group. A message point M is encrypted to the public key X = xG as the
+// pair (K, C) = (kG, M + kX) for a random k, and decrypted as M = C - xK.
+//
+// Messages are either data embedded in a point with Point.Embed, or small
+// integers m encoded as mG by the exponential variant, whose decryption solves
+// a discrete logarithm. Ciphertexts are additively homomorphic: the sum of the
+// encryptions of M1 and M2 is an encryption of M1 + M2, hence of m1 + m2 in the
+// exponential variant. They can be re-randomized without the private key, and
+// their lists split into the X and Y inputs of shuffle.Shuffle. The private
+// key can also be shared among several holders, a threshold of which decrypt
+// together with PartialDecrypt and Recover.
+package elgamal
+
+import (
+	"errors"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/internal/subgroup"
+	"github.com/drand/kyber/util/random"
+)
+
+// Ciphertext is an ElGamal ciphertext.
+type Ciphertext struct {
+	// K is the ephemeral key kG.
+	K kyber.Point
+	// C is the blinded message M + kX.
+	C kyber.Point
+}
+
+// EncryptPoint encrypts the point M to the public key.
+func EncryptPoint(group kyber.Group, public, M kyber.Point) *Ciphertext {
+	k := group.Scalar().Pick(random.New())
+	K := group.Point().Mul(k, nil)
+	C := group.Point().Mul(k, public)
+	return &Ciphertext{K: K, C: C.Add(C, M)}
+}
+
+// DecryptPoint decrypts the point encrypted in the ciphertext.
+func DecryptPoint(group kyber.Group, private kyber.Scalar, c *Ciphertext) kyber.Point {
+	S := group.Point().Mul(private, c.K)
+	return S.Sub(c.C, S)
+}
+
+// Encrypt encrypts the message embedded in a point. The message must be at
+// most group.Point().EmbedLen() bytes long.
+func Encrypt(group kyber.Group, public kyber.Point, message []byte) (*Ciphertext, error) {
+	if len(message) > group.Point().EmbedLen() {
+		return nil, errors.New("elgamal: message too long to be embedded")
+	}
+	M := group.Point().Embed(message, random.New())
+	return EncryptPoint(group, public, M), nil
+}
+
+// Decrypt decrypts the message embedded in the point of the ciphertext.
+func Decrypt(group kyber.Group, private kyber.Scalar, c *Ciphertext) ([]byte, error) {
+	return DecryptPoint(group, private, c).Data()
+}
+
+// Add returns the sum of the ciphertexts, which encrypts the sum of their
+// points.
+func Add(group kyber.Group, a, b *Ciphertext) *Ciphertext {
+	return &Ciphertext{
+		K: group.Point().Add(a.K, b.K),
+		C: group.Point().Add(a.C, b.C),
+	}
+}
+
+// Rerandomize returns a new ciphertext of the point of c, unlinkable to c
+// without the private key.
+func Rerandomize(group kyber.Group, public kyber.Point, c *Ciphertext) *Ciphertext {
+	return Add(group, c, EncryptPoint(group, public, group.Point().Null()))
+}
+
+// Split returns the ephemeral keys and the blinded messages of the
+// ciphertexts, which are the X and Y inputs of shuffle.Shuffle with the base
+// point as g and the public key as h.
+func Split(cs []*Ciphertext) (X, Y []kyber.Point) {
+	X = make([]kyber.Point, len(cs))
+	Y = make([]kyber.Point, len(cs))
+	for i, c := range cs {
+		X[i], Y[i] = c.K, c.C
+	}
+	return X, Y
+}
+
+// Join returns the ciphertexts of the ephemeral keys and blinded messages,
+// such as the outputs of shuffle.Shuffle.
+func Join(X, Y []kyber.Point) ([]*Ciphertext, error) {
+	if len(X) != len(Y) {
+		return nil, errors.New("elgamal: X and Y of different lengths")
+	}
+	cs := make([]*Ciphertext, len(X))
+	for i := range X {
+		cs[i] = &Ciphertext{K: X[i], C: Y[i]}
+	}
+	return cs, nil
+}
+
+// MarshalBinary encodes the ciphertext as K followed by C.
+func (c *Ciphertext) MarshalBinary() ([]byte, error) {
+	K, err := c.K.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	C, err := c.C.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(K, C...), nil
+}
+
+// Unmarshal decodes a ciphertext encoded by MarshalBinary. Both points must
+// be in the subgroup generated by the base point.
+func Unmarshal(group kyber.Group, buff []byte) (*Ciphertext, error) {
+	pointLen := group.PointLen()
+	if len(buff) != 2*pointLen {
+		return nil, errors.New("elgamal: invalid ciphertext length")
+	}
+	c := &Ciphertext{K: group.Point(), C: group.Point()}
+	if err := c.K.UnmarshalBinary(buff[:pointLen]); err != nil {
+		return nil, err
+	}
+	if err := c.C.UnmarshalBinary(buff[pointLen:]); err != nil {
+		return nil, err
+	}
+	if !subgroup.Contains(group, c.K) || !subgroup.Contains(group, c.C) {
+		return nil, errors.New("elgamal: point not in the expected group")
+	}
+	return c, nil
+}
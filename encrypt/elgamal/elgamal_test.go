@@ -0,0 +1,120 @@
+package elgamal
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/group/nist"
+	"github.com/drand/kyber/proof"
+	"github.com/drand/kyber/shuffle"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func keyPair(group kyber.Group) (kyber.Scalar, kyber.Point) {
+	x := group.Scalar().Pick(random.New())
+	return x, group.Point().Mul(x, nil)
+}
+
+func TestElGamal(t *testing.T) {
+	for _, group := range []kyber.Group{edwards25519.NewBlakeSHA256Ed25519(), nist.NewBlakeSHA256P256()} {
+		x, X := keyPair(group)
+		msg := []byte("The quick brown fox")
+		c, err := Encrypt(group, X, msg)
+		require.NoError(t, err)
+		decrypted, err := Decrypt(group, x, c)
+		require.NoError(t, err)
+		require.Equal(t, msg, decrypted)
+
+		r := Rerandomize(group, X, c)
+		require.False(t, r.K.Equal(c.K))
+		require.False(t, r.C.Equal(c.C))
+		decrypted, err = Decrypt(group, x, r)
+		require.NoError(t, err)
+		require.Equal(t, msg, decrypted)
+
+		_, err = Encrypt(group, X, make([]byte, group.Point().EmbedLen()+1))
+		require.Error(t, err)
+	}
+}
+
+func TestExponentialElGamal(t *testing.T) {
+	group := edwards25519.NewBlakeSHA256Ed25519()
+	x, X := keyPair(group)
+	max := uint64(1000)
+	table := NewTable(group, max)
+	for _, m := range []uint64{0, 1, 2, 31, 32, 33, 999, 1000} {
+		m2, err := table.DecryptInt(x, EncryptInt(group, X, m))
+		require.NoError(t, err)
+		require.Equal(t, m, m2)
+	}
+	_, err := table.DecryptInt(x, EncryptInt(group, X, max+1))
+	require.Error(t, err)
+	_, err = table.DecryptInt(x, EncryptInt(group, X, 1<<40))
+	require.Error(t, err)
+
+	// the sum of the ciphertexts encrypts the sum of the integers
+	sum := EncryptInt(group, X, 0)
+	for _, m := range []uint64{17, 400, 3} {
+		sum = Add(group, sum, EncryptInt(group, X, m))
+	}
+	m, err := DecryptInt(group, x, sum, max)
+	require.NoError(t, err)
+	require.Equal(t, uint64(420), m)
+	m, err = DecryptInt(group, x, Rerandomize(group, X, sum), 420)
+	require.NoError(t, err)
+	require.Equal(t, uint64(420), m)
+
+	m, err = DecryptInt(group, x, EncryptInt(group, X, 0), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), m)
+}
+
+func TestEncoding(t *testing.T) {
+	group := edwards25519.NewBlakeSHA256Ed25519()
+	_, X := keyPair(group)
+	c := EncryptInt(group, X, 42)
+	buff, err := c.MarshalBinary()
+	require.NoError(t, err)
+	decoded, err := Unmarshal(group, buff)
+	require.NoError(t, err)
+	require.True(t, c.K.Equal(decoded.K))
+	require.True(t, c.C.Equal(decoded.C))
+
+	_, err = Unmarshal(group, buff[1:])
+	require.Error(t, err)
+	_, err = Unmarshal(group, append(buff, 0))
+	require.Error(t, err)
+}
+
+func TestShuffle(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	x, X := keyPair(suite)
+	var cs []*Ciphertext
+	for m := uint64(0); m < 5; m++ {
+		cs = append(cs, EncryptInt(suite, X, m))
+	}
+	Xs, Ys := Split(cs)
+	Xbar, Ybar, prover := shuffle.Shuffle(suite, nil, X, Xs, Ys, suite.RandomStream())
+	prf, err := proof.HashProve(suite, "PairShuffle", prover)
+	require.NoError(t, err)
+	verifier := shuffle.Verifier(suite, nil, X, Xs, Ys, Xbar, Ybar)
+	require.NoError(t, proof.HashVerify(suite, "PairShuffle", verifier, prf))
+
+	shuffled, err := Join(Xbar, Ybar)
+	require.NoError(t, err)
+	table := NewTable(suite, 10)
+	var ms []int
+	for _, c := range shuffled {
+		m, err := table.DecryptInt(x, c)
+		require.NoError(t, err)
+		ms = append(ms, int(m))
+	}
+	sort.Ints(ms)
+	require.Equal(t, []int{0, 1, 2, 3, 4}, ms)
+
+	_, err = Join(Xbar, Ybar[1:])
+	require.Error(t, err)
+}
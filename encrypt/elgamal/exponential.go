@@ -0,0 +1,96 @@
+package elgamal
+
+import (
+	"errors"
+
+	"github.com/drand/kyber"
+)
+
+// EncryptInt encrypts the integer m as the point mG, so that the sum of
+// ciphertexts encrypts the sum of their integers.
+func EncryptInt(group kyber.Group, public kyber.Point, m uint64) *Ciphertext {
+	return EncryptPoint(group, public, intPoint(group, m))
+}
+
+// DecryptInt decrypts an integer encrypted with EncryptInt, which must be at
+// most max. It takes O(sqrt(max)) time and memory, see Table to decrypt many
+// ciphertexts.
+func DecryptInt(group kyber.Group, private kyber.Scalar, c *Ciphertext, max uint64) (uint64, error) {
+	return NewTable(group, max).DecryptInt(private, c)
+}
+
+// Table solves discrete logarithms up to a maximum with the baby-step
+// giant-step algorithm, with a table of the baby steps computed once.
+type Table struct {
+	group kyber.Group
+	max   uint64
+	// steps is the number of baby steps, and the size of the giant steps
+	steps uint64
+	// baby maps the encoding of jG to j for j < steps
+	baby  map[string]uint64
+	giant kyber.Point
+}
+
+// NewTable returns a table for the integers from 0 to max.
+func NewTable(group kyber.Group, max uint64) *Table {
+	steps := isqrt(max) + 1
+	t := &Table{
+		group: group,
+		max:   max,
+		steps: steps,
+		baby:  make(map[string]uint64, steps),
+	}
+	P := group.Point().Null()
+	G := group.Point().Base()
+	for j := uint64(0); j < steps; j++ {
+		t.baby[pointKey(P)] = j
+		P.Add(P, G)
+	}
+	// P is now steps·G
+	t.giant = P.Neg(P)
+	return t
+}
+
+// DecryptInt decrypts an integer encrypted with EncryptInt.
+func (t *Table) DecryptInt(private kyber.Scalar, c *Ciphertext) (uint64, error) {
+	return t.Log(DecryptPoint(t.group, private, c))
+}
+
+// Log returns m such that M = mG, if m is at most the maximum of the table.
+func (t *Table) Log(M kyber.Point) (uint64, error) {
+	P := M.Clone()
+	// M = (i·steps + j)G with i <= max/steps
+	for i := uint64(0); i <= t.max/t.steps; i++ {
+		if j, ok := t.baby[pointKey(P)]; ok {
+			if m := i*t.steps + j; m <= t.max {
+				return m, nil
+			}
+			break
+		}
+		P.Add(P, t.giant)
+	}
+	return 0, errors.New("elgamal: integer out of range")
+}
+
+func intPoint(group kyber.Group, m uint64) kyber.Point {
+	s := group.Scalar().SetInt64(int64(m >> 1))
+	s.Add(s, s)
+	s.Add(s, group.Scalar().SetInt64(int64(m&1)))
+	return group.Point().Mul(s, nil)
+}
+
+func pointKey(P kyber.Point) string {
+	buff, _ := P.MarshalBinary()
+	return string(buff)
+}
+
+// isqrt returns the floor of the square root of n.
+func isqrt(n uint64) uint64 {
+	r := uint64(0)
+	for bit := uint64(1) << 31; bit > 0; bit >>= 1 {
+		if c := r | bit; c*c <= n {
+			r = c
+		}
+	}
+	return r
+}
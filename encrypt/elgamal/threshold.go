@@ -0,0 +1,111 @@
+package elgamal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+)
+
+// Suite wraps the functionalities needed by the threshold decryption.
+type Suite dleq.Suite
+
+// The private key x of a ciphertext can be shared among n holders, such as
+// the DistKeyShares of a share/dkg run whose public key is the ElGamal public
+// key, so that t of them are needed to decrypt. Each holder computes with its
+// share x_i the partial decryption x_i·K of a ciphertext, with a proof that it
+// has the same discrete logarithm as its public share x_i·G of the public
+// polynomial, and a combiner verifies the partial decryptions, interpolates
+// xK and recovers the message point C - xK.
+
+// PartialDecryption is a share of the decryption of a ciphertext.
+type PartialDecryption struct {
+	// Index is the index of the share of the private key. It is not
+	// authenticated, see Recover.
+	Index int
+	// D is the partial decryption x_i·K.
+	D kyber.Point
+	// Proof proves that log_G(x_i·G) == log_K(D).
+	Proof *dleq.Proof
+}
+
+// PartialDecrypt returns the partial decryption of the ciphertext for the
+// share of the private key, such as DistKeyShare.PriShare(). The proof is
+// made with the standard base point, the base of the ElGamal keys, which the
+// public polynomial of the shares must have too.
+func PartialDecrypt(suite Suite, private *share.PriShare, c *Ciphertext) (*PartialDecryption, error) {
+	if c == nil || c.K == nil {
+		return nil, errors.New("elgamal: incomplete ciphertext")
+	}
+	proof, _, D, err := dleq.NewDLEQProof(suite, suite.Point().Base(), c.K, private.V)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialDecryption{Index: private.I, D: D, Proof: proof}, nil
+}
+
+// VerifyPartial checks the partial decryption of the ciphertext against the
+// public share of its index in the public polynomial of the private key,
+// whose base must be the standard base point as in PartialDecrypt.
+func VerifyPartial(suite Suite, public *share.PubPoly, c *Ciphertext, p *PartialDecryption) error {
+	if c == nil || c.K == nil {
+		return errors.New("elgamal: incomplete ciphertext")
+	}
+	if public == nil {
+		return errors.New("elgamal: missing public polynomial")
+	}
+	if p == nil || p.D == nil || p.Proof == nil || p.Index < 0 {
+		return errors.New("elgamal: incomplete partial decryption")
+	}
+	if pr := p.Proof; pr.C == nil || pr.R == nil || pr.VG == nil || pr.VH == nil {
+		return errors.New("elgamal: incomplete partial decryption proof")
+	}
+	base := suite.Point().Base()
+	if b, _ := public.Info(); b != nil && !b.Equal(base) {
+		return errors.New("elgamal: public polynomial with another base point")
+	}
+	if err := p.Proof.Verify(suite, base, c.K, public.Eval(p.Index).V, p.D); err != nil {
+		return fmt.Errorf("elgamal: partial decryption %d: %v", p.Index, err)
+	}
+	return nil
+}
+
+// Recover verifies the partial decryptions of the ciphertext and recovers
+// its message point from t valid ones out of n shares. Invalid partial
+// decryptions are skipped, and the indexes they claim are returned. Nothing
+// authenticates these indexes, so anyone can send an invalid partial
+// decryption in the name of an honest holder: the returned indexes identify
+// the holders that cheated only if each partial decryption was received from
+// the holder of its index over an authenticated channel.
+func Recover(suite Suite, public *share.PubPoly, c *Ciphertext, partials []*PartialDecryption, t, n int) (kyber.Point, []int, error) {
+	if c == nil || c.K == nil || c.C == nil {
+		return nil, nil, errors.New("elgamal: incomplete ciphertext")
+	}
+	var shares []*share.PubShare
+	var invalid []int
+	seen := make(map[int]bool)
+	for _, p := range partials {
+		if p == nil || seen[p.Index] {
+			continue
+		}
+		if err := VerifyPartial(suite, public, c, p); err != nil || p.Index >= n {
+			invalid = append(invalid, p.Index)
+			continue
+		}
+		seen[p.Index] = true
+		shares = append(shares, &share.PubShare{I: p.Index, V: p.D})
+		if len(shares) >= t {
+			break
+		}
+	}
+	if len(shares) < t {
+		return nil, invalid, fmt.Errorf("elgamal: only %d/%d valid partial decryptions", len(shares), t)
+	}
+	xK, err := share.RecoverCommit(suite, shares, t, n)
+	if err != nil {
+		return nil, invalid, err
+	}
+	return suite.Point().Sub(c.C, xK), invalid, nil
+}
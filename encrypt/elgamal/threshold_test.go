@@ -0,0 +1,81 @@
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdDecryption(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	n, thr := 7, 4
+	x := suite.Scalar().Pick(random.New())
+	priPoly := share.NewPriPoly(suite, thr, x, random.New())
+	pubPoly := priPoly.Commit(nil)
+	msg := []byte("The quick brown fox")
+	c, err := Encrypt(suite, pubPoly.Commit(), msg)
+	require.NoError(t, err)
+
+	var partials []*PartialDecryption
+	for _, sh := range priPoly.Shares(n) {
+		p, err := PartialDecrypt(suite, sh, c)
+		require.NoError(t, err)
+		require.NoError(t, VerifyPartial(suite, pubPoly, c, p))
+		partials = append(partials, p)
+	}
+	// the partial decryption of another ciphertext is invalid
+	other, err := PartialDecrypt(suite, priPoly.Shares(n)[1], Rerandomize(suite, pubPoly.Commit(), c))
+	require.NoError(t, err)
+	require.Error(t, VerifyPartial(suite, pubPoly, c, other))
+
+	// malformed partial decryptions are rejected without panicking
+	pr := partials[0].Proof
+	malformed := []*PartialDecryption{
+		{Index: 0, D: partials[0].D, Proof: &dleq.Proof{}},
+		{Index: 0, D: partials[0].D, Proof: &dleq.Proof{C: pr.C, R: pr.R, VG: pr.VG}},
+	}
+	for _, p := range malformed {
+		require.Error(t, VerifyPartial(suite, pubPoly, c, p))
+	}
+	_, blamed, err := Recover(suite, pubPoly, c, malformed, thr, n)
+	require.Error(t, err)
+	require.Equal(t, []int{0, 0}, blamed)
+
+	// incomplete ciphertexts are rejected without panicking
+	for _, bad := range []*Ciphertext{nil, {C: c.C}, {K: c.K}} {
+		if bad == nil || bad.K == nil {
+			_, err = PartialDecrypt(suite, priPoly.Shares(n)[0], bad)
+			require.Error(t, err)
+			require.Error(t, VerifyPartial(suite, pubPoly, bad, partials[0]))
+		}
+		_, _, err = Recover(suite, pubPoly, bad, partials, thr, n)
+		require.Error(t, err)
+	}
+	// the partial decryptions are proven with the standard base point
+	require.NoError(t, VerifyPartial(suite, priPoly.Commit(suite.Point().Base()), c, partials[0]))
+	require.Error(t, VerifyPartial(suite, priPoly.Commit(suite.Point().Pick(random.New())), c, partials[0]))
+
+	// cheating holders are identified and skipped
+	cheaters := []*PartialDecryption{
+		other,
+		{Index: 2, D: partials[1].D, Proof: partials[1].Proof},
+		{Index: 3, D: suite.Point().Pick(random.New()), Proof: partials[3].Proof},
+	}
+	M, invalid, err := Recover(suite, pubPoly, c, append(cheaters, partials[1:]...), thr, n)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, invalid)
+	decrypted, err := M.Data()
+	require.NoError(t, err)
+	require.Equal(t, msg, decrypted)
+
+	_, invalid, err = Recover(suite, pubPoly, c, append(cheaters, partials[4:]...), thr, n)
+	require.Error(t, err)
+	require.Equal(t, []int{1, 2, 3}, invalid)
+	// duplicates do not count twice
+	_, _, err = Recover(suite, pubPoly, c, []*PartialDecryption{partials[0], partials[0], partials[1], partials[2]}, thr, n)
+	require.Error(t, err)
+}
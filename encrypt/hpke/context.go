@@ -0,0 +1,77 @@
+package hpke
+
+import (
+	"crypto/cipher"
+	"errors"
+	"math"
+)
+
+// context is the encryption context shared by a sender and a receiver.
+type context struct {
+	kdf            *kdf
+	aead           cipher.AEAD
+	baseNonce      []byte
+	exporterSecret []byte
+	// seq is the sequence number of the next message
+	seq uint64
+}
+
+// nonce returns the nonce of the next message, the base nonce XOR the
+// sequence number.
+func (c *context) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, errors.New("hpke: export-only context")
+	}
+	if c.seq == math.MaxUint64 {
+		return nil, errors.New("hpke: message limit reached")
+	}
+	nonce := make([]byte, len(c.baseNonce))
+	copy(nonce, c.baseNonce)
+	for i, s := len(nonce)-1, c.seq; s > 0; i, s = i-1, s>>8 {
+		nonce[i] ^= byte(s)
+	}
+	return nonce, nil
+}
+
+// Export returns a secret of the given length for the exporter context,
+// which the sender and the receiver derive alike.
+func (c *context) Export(exporterContext []byte, length int) ([]byte, error) {
+	return c.kdf.labeledExpand(c.exporterSecret, "sec", exporterContext, length)
+}
+
+// Sender is the context of a sender, which encrypts messages to the receiver.
+type Sender struct {
+	*context
+}
+
+// Seal encrypts and authenticates the next message with the additional data.
+func (s *Sender) Seal(aad, pt []byte) ([]byte, error) {
+	nonce, err := s.nonce()
+	if err != nil {
+		return nil, err
+	}
+	ct := s.aead.Seal(nil, nonce, pt, aad)
+	s.seq++
+	return ct, nil
+}
+
+// Receiver is the context of a receiver, which decrypts the messages of the
+// sender in the order they were encrypted.
+type Receiver struct {
+	*context
+}
+
+// Open decrypts and authenticates the next message with the additional data.
+// A message that fails to decrypt does not advance the sequence number.
+func (r *Receiver) Open(aad, ct []byte) ([]byte, error) {
+	nonce, err := r.nonce()
+	if err != nil {
+		return nil, err
+	}
+	pt, err := r.aead.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, err
+	}
+	r.seq++
+	return pt, nil
+}
@@ -0,0 +1,264 @@
+// Package hpke implements the Hybrid Public Key Encryption of RFC 9180, in
+// its base, PSK, auth and auth-PSK modes, with DHKEMs built on kyber groups.
+//
+// A sender sets up with the public key of a receiver an encryption context,
+// whose encapsulated key enc lets the receiver set up the matching decryption
+// context with its private key. The sender then encrypts any number of
+// messages in order, which the receiver decrypts in the same order, and both
+// can export secrets from their contexts. In the PSK modes, both also share a
+// pre-shared key, and in the auth modes, the receiver authenticates the key
+// pair of the sender.
+package hpke
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/drand/kyber"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// KDFID identifies a key derivation function.
+type KDFID uint16
+
+// The KDFs of RFC 9180.
+const (
+	HKDFSHA256 KDFID = 0x0001
+	HKDFSHA384 KDFID = 0x0002
+	HKDFSHA512 KDFID = 0x0003
+)
+
+// AEADID identifies an authenticated encryption scheme.
+type AEADID uint16
+
+// The AEADs of RFC 9180. With ExportOnly, the contexts only export secrets.
+const (
+	AES128GCM        AEADID = 0x0001
+	AES256GCM        AEADID = 0x0002
+	ChaCha20Poly1305 AEADID = 0x0003
+	ExportOnly       AEADID = 0xffff
+)
+
+// Mode is the mode of HPKE.
+type Mode byte
+
+// The modes of HPKE.
+const (
+	ModeBase    Mode = 0x00
+	ModePSK     Mode = 0x01
+	ModeAuth    Mode = 0x02
+	ModeAuthPSK Mode = 0x03
+)
+
+// Suite is an HPKE ciphersuite, made of a KEM, a KDF and an AEAD.
+type Suite struct {
+	kem    *KEM
+	kdf    *kdf
+	aeadID AEADID
+	// nK is the size of the keys of the AEAD
+	nK int
+}
+
+// NewSuite returns the ciphersuite of the identifiers.
+func NewSuite(kemID KEMID, kdfID KDFID, aeadID AEADID) (*Suite, error) {
+	kem, err := NewKEM(kemID)
+	if err != nil {
+		return nil, err
+	}
+	var h func() hash.Hash
+	switch kdfID {
+	case HKDFSHA256:
+		h = sha256.New
+	case HKDFSHA384:
+		h = sha512.New384
+	case HKDFSHA512:
+		h = sha512.New
+	default:
+		return nil, errors.New("hpke: unsupported KDF")
+	}
+	var nK int
+	switch aeadID {
+	case AES128GCM:
+		nK = 16
+	case AES256GCM, ChaCha20Poly1305:
+		nK = 32
+	case ExportOnly:
+	default:
+		return nil, errors.New("hpke: unsupported AEAD")
+	}
+	suiteID := []byte("HPKE")
+	suiteID = appendUint16(suiteID, uint16(kemID))
+	suiteID = appendUint16(suiteID, uint16(kdfID))
+	suiteID = appendUint16(suiteID, uint16(aeadID))
+	return &Suite{
+		kem:    kem,
+		kdf:    &kdf{hash: h, suiteID: suiteID},
+		aeadID: aeadID,
+		nK:     nK,
+	}, nil
+}
+
+// KEM returns the KEM of the suite, which generates and encodes its keys.
+func (s *Suite) KEM() *KEM {
+	return s.kem
+}
+
+// SetupBaseS sets up the context of a sender for the public key of the
+// receiver, and returns it with its encapsulated key.
+func (s *Suite) SetupBaseS(pkR kyber.Point, info []byte) ([]byte, *Sender, error) {
+	return s.setupS(ModeBase, pkR, info, nil, nil, nil, nil)
+}
+
+// SetupBaseR sets up the context of a receiver for the encapsulated key of
+// SetupBaseS.
+func (s *Suite) SetupBaseR(enc []byte, skR kyber.Scalar, info []byte) (*Receiver, error) {
+	return s.setupR(ModeBase, enc, skR, info, nil, nil, nil)
+}
+
+// SetupPSKS is SetupBaseS with the pre-shared key psk of identifier pskID.
+func (s *Suite) SetupPSKS(pkR kyber.Point, info, psk, pskID []byte) ([]byte, *Sender, error) {
+	return s.setupS(ModePSK, pkR, info, psk, pskID, nil, nil)
+}
+
+// SetupPSKR sets up the context of a receiver for the encapsulated key of
+// SetupPSKS.
+func (s *Suite) SetupPSKR(enc []byte, skR kyber.Scalar, info, psk, pskID []byte) (*Receiver, error) {
+	return s.setupR(ModePSK, enc, skR, info, psk, pskID, nil)
+}
+
+// SetupAuthS is SetupBaseS authenticated by the private key of the sender.
+func (s *Suite) SetupAuthS(pkR kyber.Point, info []byte, skS kyber.Scalar) ([]byte, *Sender, error) {
+	return s.setupS(ModeAuth, pkR, info, nil, nil, skS, nil)
+}
+
+// SetupAuthR sets up the context of a receiver for the encapsulated key of
+// SetupAuthS, with the public key of the sender.
+func (s *Suite) SetupAuthR(enc []byte, skR kyber.Scalar, info []byte, pkS kyber.Point) (*Receiver, error) {
+	return s.setupR(ModeAuth, enc, skR, info, nil, nil, pkS)
+}
+
+// SetupAuthPSKS is SetupPSKS authenticated by the private key of the sender.
+func (s *Suite) SetupAuthPSKS(pkR kyber.Point, info, psk, pskID []byte, skS kyber.Scalar) ([]byte, *Sender, error) {
+	return s.setupS(ModeAuthPSK, pkR, info, psk, pskID, skS, nil)
+}
+
+// SetupAuthPSKR sets up the context of a receiver for the encapsulated key of
+// SetupAuthPSKS, with the public key of the sender.
+func (s *Suite) SetupAuthPSKR(enc []byte, skR kyber.Scalar, info, psk, pskID []byte, pkS kyber.Point) (*Receiver, error) {
+	return s.setupR(ModeAuthPSK, enc, skR, info, psk, pskID, pkS)
+}
+
+// setupS encapsulates a key with the ephemeral private key skE, random if
+// nil, and sets up the context of the sender.
+func (s *Suite) setupS(mode Mode, pkR kyber.Point, info, psk, pskID []byte, skS, skE kyber.Scalar) ([]byte, *Sender, error) {
+	if skE == nil {
+		skE, _ = s.kem.GenerateKeyPair()
+	}
+	secret, enc, err := s.kem.encap(pkR, skE, skS)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := s.keySchedule(mode, secret, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &Sender{c}, nil
+}
+
+func (s *Suite) setupR(mode Mode, enc []byte, skR kyber.Scalar, info, psk, pskID []byte, pkS kyber.Point) (*Receiver, error) {
+	secret, err := s.kem.decap(enc, skR, pkS)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.keySchedule(mode, secret, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{c}, nil
+}
+
+func (s *Suite) keySchedule(mode Mode, secret, info, psk, pskID []byte) (*context, error) {
+	withPSK := mode == ModePSK || mode == ModeAuthPSK
+	if (len(psk) == 0) != (len(pskID) == 0) {
+		return nil, errors.New("hpke: inconsistent PSK inputs")
+	}
+	if withPSK != (len(psk) != 0) {
+		return nil, errors.New("hpke: PSK input provided when not needed or missing")
+	}
+
+	pskIDHash := s.kdf.labeledExtract(nil, "psk_id_hash", pskID)
+	infoHash := s.kdf.labeledExtract(nil, "info_hash", info)
+	ksContext := append([]byte{byte(mode)}, pskIDHash...)
+	ksContext = append(ksContext, infoHash...)
+
+	secret = s.kdf.labeledExtract(secret, "secret", psk)
+	exporterSecret, err := s.kdf.labeledExpand(secret, "exp", ksContext, s.kdf.hash().Size())
+	if err != nil {
+		return nil, err
+	}
+	c := &context{kdf: s.kdf, exporterSecret: exporterSecret}
+	if s.aeadID == ExportOnly {
+		return c, nil
+	}
+
+	key, err := s.kdf.labeledExpand(secret, "key", ksContext, s.nK)
+	if err != nil {
+		return nil, err
+	}
+	switch s.aeadID {
+	case ChaCha20Poly1305:
+		c.aead, err = chacha20poly1305.New(key)
+	default:
+		var block cipher.Block
+		if block, err = aes.NewCipher(key); err == nil {
+			c.aead, err = cipher.NewGCM(block)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.baseNonce, err = s.kdf.labeledExpand(secret, "base_nonce", ksContext, c.aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// kdf is the labeled HKDF of RFC 9180 for a suite identifier.
+type kdf struct {
+	hash    func() hash.Hash
+	suiteID []byte
+}
+
+func (k *kdf) labeledExtract(salt []byte, label string, ikm []byte) []byte {
+	labeled := append([]byte("HPKE-v1"), k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(k.hash, labeled, salt)
+}
+
+func (k *kdf) labeledExpand(prk []byte, label string, info []byte, length int) ([]byte, error) {
+	if length > 255*k.hash().Size() || length > 0xffff {
+		return nil, errors.New("hpke: expanded length too large")
+	}
+	labeled := appendUint16(nil, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(k.hash, prk, labeled), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
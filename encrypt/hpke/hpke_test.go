@@ -0,0 +1,273 @@
+package hpke
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := hex.DecodeString(s)
+	*h = d
+	return err
+}
+
+// vector is a test vector of RFC 9180, from testdata/rfc9180.json which only
+// keeps the first encryptions of each vector of the RFC.
+type vector struct {
+	Mode           Mode     `json:"mode"`
+	KEMID          KEMID    `json:"kem_id"`
+	KDFID          KDFID    `json:"kdf_id"`
+	AEADID         AEADID   `json:"aead_id"`
+	Info           hexBytes `json:"info"`
+	IkmR           hexBytes `json:"ikmR"`
+	IkmS           hexBytes `json:"ikmS"`
+	IkmE           hexBytes `json:"ikmE"`
+	SkRm           hexBytes `json:"skRm"`
+	PkRm           hexBytes `json:"pkRm"`
+	PkSm           hexBytes `json:"pkSm"`
+	PkEm           hexBytes `json:"pkEm"`
+	PSK            hexBytes `json:"psk"`
+	PSKID          hexBytes `json:"psk_id"`
+	Enc            hexBytes `json:"enc"`
+	Key            hexBytes `json:"key"`
+	BaseNonce      hexBytes `json:"base_nonce"`
+	ExporterSecret hexBytes `json:"exporter_secret"`
+	Encryptions    []struct {
+		AAD   hexBytes `json:"aad"`
+		CT    hexBytes `json:"ct"`
+		Nonce hexBytes `json:"nonce"`
+		PT    hexBytes `json:"pt"`
+	} `json:"encryptions"`
+	Exports []struct {
+		Context hexBytes `json:"exporter_context"`
+		L       int      `json:"L"`
+		Value   hexBytes `json:"exported_value"`
+	} `json:"exports"`
+}
+
+func marshal(t *testing.T, k *KEM, pk kyber.Point) []byte {
+	b, err := k.MarshalPublicKey(pk)
+	require.NoError(t, err)
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	buff, err := os.ReadFile("testdata/rfc9180.json")
+	require.NoError(t, err)
+	var vectors []vector
+	require.NoError(t, json.Unmarshal(buff, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for i, v := range vectors {
+		t.Run(fmt.Sprintf("%d-%d-%x-%d-%d", i, v.Mode, v.KEMID, v.KDFID, v.AEADID), func(t *testing.T) {
+			s, err := NewSuite(v.KEMID, v.KDFID, v.AEADID)
+			require.NoError(t, err)
+			k := s.KEM()
+
+			skR, pkR, err := k.DeriveKeyPair(v.IkmR)
+			require.NoError(t, err)
+			require.Equal(t, []byte(v.PkRm), marshal(t, k, pkR))
+			sk, err := k.UnmarshalPrivateKey(v.SkRm)
+			require.NoError(t, err)
+			require.True(t, sk.Equal(skR))
+			skE, pkE, err := k.DeriveKeyPair(v.IkmE)
+			require.NoError(t, err)
+			require.Equal(t, []byte(v.PkEm), marshal(t, k, pkE))
+			var skS kyber.Scalar
+			var pkS kyber.Point
+			if v.Mode == ModeAuth || v.Mode == ModeAuthPSK {
+				skS, pkS, err = k.DeriveKeyPair(v.IkmS)
+				require.NoError(t, err)
+				require.Equal(t, []byte(v.PkSm), marshal(t, k, pkS))
+			}
+
+			enc, sender, err := s.setupS(v.Mode, pkR, v.Info, v.PSK, v.PSKID, skS, skE)
+			require.NoError(t, err)
+			require.Equal(t, []byte(v.Enc), enc)
+			require.Equal(t, []byte(v.ExporterSecret), sender.exporterSecret)
+			receiver, err := s.setupR(v.Mode, enc, skR, v.Info, v.PSK, v.PSKID, pkS)
+			require.NoError(t, err)
+
+			if v.AEADID == ExportOnly {
+				_, err = sender.Seal(nil, nil)
+				require.Error(t, err)
+				_, err = receiver.Open(nil, nil)
+				require.Error(t, err)
+			} else {
+				require.Equal(t, []byte(v.BaseNonce), sender.baseNonce)
+			}
+			for _, e := range v.Encryptions {
+				nonce, err := sender.nonce()
+				require.NoError(t, err)
+				require.Equal(t, []byte(e.Nonce), nonce)
+				ct, err := sender.Seal(e.AAD, e.PT)
+				require.NoError(t, err)
+				require.Equal(t, []byte(e.CT), ct)
+				pt, err := receiver.Open(e.AAD, ct)
+				require.NoError(t, err)
+				require.Equal(t, []byte(e.PT), pt)
+			}
+			for _, e := range v.Exports {
+				for _, c := range []*context{sender.context, receiver.context} {
+					exported, err := c.Export(e.Context, e.L)
+					require.NoError(t, err)
+					require.Equal(t, []byte(e.Value), exported)
+				}
+			}
+		})
+	}
+}
+
+// TestP384Vectors decrypts the vectors of testdata/p384.json, as RFC 9180
+// has no vectors of DHKEM(P-384). They were encrypted in base mode by the
+// crypto/hpke package of the Go standard library, to the key derived from
+// ikmR.
+func TestP384Vectors(t *testing.T) {
+	buff, err := os.ReadFile("testdata/p384.json")
+	require.NoError(t, err)
+	var vectors []vector
+	require.NoError(t, json.Unmarshal(buff, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for i, v := range vectors {
+		t.Run(fmt.Sprintf("%d-%d-%d", i, v.KDFID, v.AEADID), func(t *testing.T) {
+			require.Equal(t, DHKEMP384, v.KEMID)
+			s, err := NewSuite(v.KEMID, v.KDFID, v.AEADID)
+			require.NoError(t, err)
+			k := s.KEM()
+
+			skR, pkR, err := k.DeriveKeyPair(v.IkmR)
+			require.NoError(t, err)
+			require.Equal(t, []byte(v.PkRm), marshal(t, k, pkR))
+			sk, err := k.UnmarshalPrivateKey(v.SkRm)
+			require.NoError(t, err)
+			require.True(t, sk.Equal(skR))
+
+			receiver, err := s.SetupBaseR(v.Enc, skR, v.Info)
+			require.NoError(t, err)
+			for _, e := range v.Encryptions {
+				pt, err := receiver.Open(e.AAD, e.CT)
+				require.NoError(t, err)
+				require.Equal(t, string(e.PT), string(pt))
+			}
+			for _, e := range v.Exports {
+				exported, err := receiver.Export(e.Context, e.L)
+				require.NoError(t, err)
+				require.Equal(t, []byte(e.Value), exported)
+			}
+		})
+	}
+}
+
+func TestModes(t *testing.T) {
+	info := []byte("info")
+	psk := []byte("a pre-shared key of at least 32 bytes")
+	pskID := []byte("psk id")
+	for _, kemID := range []KEMID{DHKEMP256, DHKEMP384, DHKEMX25519} {
+		s, err := NewSuite(kemID, HKDFSHA384, ChaCha20Poly1305)
+		require.NoError(t, err)
+		k := s.KEM()
+		skR, pkR := k.GenerateKeyPair()
+		skS, pkS := k.GenerateKeyPair()
+		_, pkO := k.GenerateKeyPair()
+
+		check := func(enc []byte, sender *Sender, receiver *Receiver, err error) {
+			require.NoError(t, err)
+			for _, msg := range []string{"first", "second", ""} {
+				ct, err := sender.Seal(info, []byte(msg))
+				require.NoError(t, err)
+				_, err = receiver.Open(nil, ct)
+				require.Error(t, err)
+				pt, err := receiver.Open(info, ct)
+				require.NoError(t, err)
+				require.Equal(t, msg, string(pt))
+			}
+			exported, err := sender.Export(info, 42)
+			require.NoError(t, err)
+			exported2, err := receiver.Export(info, 42)
+			require.NoError(t, err)
+			require.Equal(t, exported, exported2)
+		}
+
+		enc, sender, err := s.SetupBaseS(pkR, info)
+		require.NoError(t, err)
+		receiver, err := s.SetupBaseR(enc, skR, info)
+		check(enc, sender, receiver, err)
+
+		enc, sender, err = s.SetupPSKS(pkR, info, psk, pskID)
+		require.NoError(t, err)
+		receiver, err = s.SetupPSKR(enc, skR, info, psk, pskID)
+		check(enc, sender, receiver, err)
+
+		enc, sender, err = s.SetupAuthS(pkR, info, skS)
+		require.NoError(t, err)
+		receiver, err = s.SetupAuthR(enc, skR, info, pkS)
+		check(enc, sender, receiver, err)
+		// a receiver expecting another sender derives another key
+		receiver, err = s.SetupAuthR(enc, skR, info, pkO)
+		require.NoError(t, err)
+		ct, err := sender.Seal(nil, []byte("msg"))
+		require.NoError(t, err)
+		_, err = receiver.Open(nil, ct)
+		require.Error(t, err)
+
+		enc, sender, err = s.SetupAuthPSKS(pkR, info, psk, pskID, skS)
+		require.NoError(t, err)
+		receiver, err = s.SetupAuthPSKR(enc, skR, info, psk, pskID, pkS)
+		check(enc, sender, receiver, err)
+
+		_, _, err = s.SetupPSKS(pkR, info, nil, nil)
+		require.Error(t, err)
+		_, _, err = s.SetupPSKS(pkR, info, psk, nil)
+		require.Error(t, err)
+		_, _, err = s.setupS(ModeBase, pkR, info, psk, pskID, nil, nil)
+		require.Error(t, err)
+
+		_, err = s.SetupBaseR(enc[1:], skR, info)
+		require.Error(t, err)
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	_, err := NewSuite(0x0012, HKDFSHA256, AES128GCM)
+	require.Error(t, err)
+	_, err = NewSuite(DHKEMX25519, 0x0004, AES128GCM)
+	require.Error(t, err)
+	_, err = NewSuite(DHKEMX25519, HKDFSHA256, 0x0004)
+	require.Error(t, err)
+
+	k, err := NewKEM(DHKEMP256)
+	require.NoError(t, err)
+	_, err = k.UnmarshalPrivateKey(make([]byte, 32))
+	require.Error(t, err)
+	_, err = k.UnmarshalPrivateKey(make([]byte, 31))
+	require.Error(t, err)
+	// the neutral element
+	_, err = k.UnmarshalPublicKey(make([]byte, 65))
+	require.Error(t, err)
+	_, err = k.UnmarshalPublicKey(make([]byte, 1))
+	require.Error(t, err)
+
+	k, err = NewKEM(DHKEMX25519)
+	require.NoError(t, err)
+	sk, _ := k.GenerateKeyPair()
+	// u = 0 is the point of order 2, u = 1 a point of order 4
+	for _, u := range []byte{0, 1} {
+		pk, err := k.UnmarshalPublicKey(append([]byte{u}, make([]byte, 31)...))
+		require.NoError(t, err)
+		_, err = k.dh(sk, pk)
+		require.Error(t, err)
+	}
+}
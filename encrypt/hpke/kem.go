@@ -0,0 +1,256 @@
+package hpke
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/group/nist"
+	"github.com/drand/kyber/util/random"
+)
+
+// KEMID identifies a key encapsulation mechanism.
+type KEMID uint16
+
+// The DHKEMs of RFC 9180, each one with the HKDF of its hash function.
+const (
+	DHKEMP256   KEMID = 0x0010
+	DHKEMP384   KEMID = 0x0011
+	DHKEMX25519 KEMID = 0x0020
+)
+
+// KEM is a DHKEM, the key encapsulation mechanism of RFC 9180 built on the
+// Diffie-Hellman function of a kyber group. The keys of X25519 are scalars and
+// points of edwards25519, encoded on Curve25519, and those of P-256 and P-384
+// are scalars and points of group/nist.
+type KEM struct {
+	id    KEMID
+	group kyber.Group
+	kdf   *kdf
+	// nSecret is the size of the shared secret, nPK of the encoded public
+	// keys and nSK of the encoded private keys.
+	nSecret, nPK, nSK int
+	x25519            bool
+}
+
+// NewKEM returns the KEM of the identifier.
+func NewKEM(id KEMID) (*KEM, error) {
+	suiteID := appendUint16([]byte("KEM"), uint16(id))
+	switch id {
+	case DHKEMP256:
+		return &KEM{
+			id:      id,
+			group:   nist.NewBlakeSHA256P256(),
+			kdf:     &kdf{hash: sha256.New, suiteID: suiteID},
+			nSecret: 32, nPK: 65, nSK: 32,
+		}, nil
+	case DHKEMP384:
+		return &KEM{
+			id:      id,
+			group:   nist.NewBlakeSHA384P384(),
+			kdf:     &kdf{hash: sha512.New384, suiteID: suiteID},
+			nSecret: 48, nPK: 97, nSK: 48,
+		}, nil
+	case DHKEMX25519:
+		return &KEM{
+			id:      id,
+			group:   edwards25519.NewBlakeSHA256Ed25519(),
+			kdf:     &kdf{hash: sha256.New, suiteID: suiteID},
+			nSecret: 32, nPK: 32, nSK: 32,
+			x25519: true,
+		}, nil
+	}
+	return nil, errors.New("hpke: unsupported KEM")
+}
+
+// ID returns the identifier of the KEM.
+func (k *KEM) ID() KEMID {
+	return k.id
+}
+
+// Group returns the group of the keys of the KEM.
+func (k *KEM) Group() kyber.Group {
+	return k.group
+}
+
+// GenerateKeyPair returns a random key pair.
+func (k *KEM) GenerateKeyPair() (kyber.Scalar, kyber.Point) {
+	sk := k.group.Scalar().Pick(random.New())
+	return sk, k.group.Point().Mul(sk, nil)
+}
+
+// DeriveKeyPair derives a key pair from the input keying material, which
+// must have at least as much entropy as a private key.
+func (k *KEM) DeriveKeyPair(ikm []byte) (kyber.Scalar, kyber.Point, error) {
+	prk := k.kdf.labeledExtract(nil, "dkp_prk", ikm)
+	if k.x25519 {
+		b, err := k.kdf.labeledExpand(prk, "sk", nil, k.nSK)
+		if err != nil {
+			return nil, nil, err
+		}
+		sk, err := k.UnmarshalPrivateKey(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sk, k.group.Point().Mul(sk, nil), nil
+	}
+	for counter := 0; counter < 256; counter++ {
+		b, err := k.kdf.labeledExpand(prk, "candidate", []byte{byte(counter)}, k.nSK)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the bitmask of P-256 and P-384 is 0xff
+		if sk, err := k.UnmarshalPrivateKey(b); err == nil {
+			return sk, k.group.Point().Mul(sk, nil), nil
+		}
+	}
+	return nil, nil, errors.New("hpke: key pair derivation failed")
+}
+
+// UnmarshalPrivateKey decodes a private key: the clamped little-endian
+// integer of X25519, reduced modulo the order of edwards25519, or the
+// big-endian integer of P-256 and P-384, between 1 and the order of the group.
+func (k *KEM) UnmarshalPrivateKey(b []byte) (kyber.Scalar, error) {
+	if len(b) != k.nSK {
+		return nil, errors.New("hpke: invalid private key length")
+	}
+	if k.x25519 {
+		c := make([]byte, len(b))
+		copy(c, b)
+		c[0] &= 248
+		c[31] &= 127
+		c[31] |= 64
+		return k.group.Scalar().SetBytes(c), nil
+	}
+	n := new(big.Int).SetBytes(b)
+	if n.Sign() == 0 || n.Cmp(k.order()) >= 0 {
+		return nil, errors.New("hpke: invalid private key")
+	}
+	return k.group.Scalar().SetBytes(b), nil
+}
+
+// MarshalPublicKey encodes a public key: the u-coordinate of X25519, or the
+// uncompressed point of P-256 and P-384.
+func (k *KEM) MarshalPublicKey(pk kyber.Point) ([]byte, error) {
+	if k.x25519 {
+		return edwards25519.ToMontgomery(pk), nil
+	}
+	return pk.MarshalBinary()
+}
+
+// UnmarshalPublicKey decodes a public key encoded by MarshalPublicKey. The
+// u-coordinates of X25519 on the twist of Curve25519 are rejected, as well as
+// the neutral element of P-256 and P-384.
+func (k *KEM) UnmarshalPublicKey(b []byte) (kyber.Point, error) {
+	if len(b) != k.nPK {
+		return nil, errors.New("hpke: invalid public key length")
+	}
+	if k.x25519 {
+		return edwards25519.FromMontgomery(b)
+	}
+	pk := k.group.Point()
+	if err := pk.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if pk.Equal(k.group.Point().Null()) {
+		return nil, errors.New("hpke: invalid public key")
+	}
+	return pk, nil
+}
+
+func (k *KEM) order() *big.Int {
+	return k.group.(interface{ Order() *big.Int }).Order()
+}
+
+// dh returns the encoded shared Diffie-Hellman key of sk and pk, which is
+// the x-coordinate of sk·pk for P-256 and P-384. For X25519, it is the
+// u-coordinate of sk·pk without its component of small order, as X25519
+// clamps its private keys to multiples of the cofactor.
+func (k *KEM) dh(sk kyber.Scalar, pk kyber.Point) ([]byte, error) {
+	if k.x25519 {
+		cofactor := k.group.Scalar().SetInt64(8)
+		Q := k.group.Point().Mul(cofactor, pk)
+		if Q.Equal(k.group.Point().Null()) {
+			return nil, errors.New("hpke: public key of small order")
+		}
+		s := k.group.Scalar().Div(sk, cofactor)
+		return edwards25519.ToMontgomery(Q.Mul(s, Q)), nil
+	}
+	b, err := k.group.Point().Mul(sk, pk).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return b[1 : 1+k.nSK], nil
+}
+
+// encap returns the shared secret and the encapsulated key enc of the
+// ephemeral key pair for the public key pkR, authenticated by the private key
+// skS if it is not nil.
+func (k *KEM) encap(pkR kyber.Point, skE kyber.Scalar, skS kyber.Scalar) (secret, enc []byte, err error) {
+	enc, err = k.MarshalPublicKey(k.group.Point().Mul(skE, nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	pkRm, err := k.MarshalPublicKey(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, err := k.dh(skE, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	kemContext := append(enc[:len(enc):len(enc)], pkRm...)
+	if skS != nil {
+		dhS, err := k.dh(skS, pkR)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkSm, err := k.MarshalPublicKey(k.group.Point().Mul(skS, nil))
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkSm...)
+	}
+	secret, err = k.extractAndExpand(dh, kemContext)
+	return secret, enc, err
+}
+
+// decap returns the shared secret of the encapsulated key enc for the
+// private key skR, authenticated by the public key pkS if it is not nil.
+func (k *KEM) decap(enc []byte, skR kyber.Scalar, pkS kyber.Point) ([]byte, error) {
+	pkE, err := k.UnmarshalPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	pkRm, err := k.MarshalPublicKey(k.group.Point().Mul(skR, nil))
+	if err != nil {
+		return nil, err
+	}
+	dh, err := k.dh(skR, pkE)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(enc[:len(enc):len(enc)], pkRm...)
+	if pkS != nil {
+		dhS, err := k.dh(skR, pkS)
+		if err != nil {
+			return nil, err
+		}
+		pkSm, err := k.MarshalPublicKey(pkS)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkSm...)
+	}
+	return k.extractAndExpand(dh, kemContext)
+}
+
+func (k *KEM) extractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	prk := k.kdf.labeledExtract(nil, "eae_prk", dh)
+	return k.kdf.labeledExpand(prk, "shared_secret", kemContext, k.nSecret)
+}
@@ -0,0 +1,136 @@
+package ibe
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+)
+
+// EncodingVersion is the version of the binary encoding of the ciphertexts.
+const EncodingVersion byte = 1
+
+// groupIDSize is the size of the identifier of the group of the commitment
+// point in the encodings.
+const groupIDSize = 4
+
+// The encoding of a ciphertext is the version of the encoding, the identifier
+// of the group of the commitment point U or RP, the point, and the 2 bytes
+// big-endian length of the message followed, for a Ciphertext, by V and W
+// which have that length, or, for a CiphertextCPA, by C.
+
+// MarshalBinary encodes the ciphertext.
+func (c *Ciphertext) MarshalBinary() ([]byte, error) {
+	if len(c.V) != len(c.W) {
+		return nil, errors.New("V and W of different lengths")
+	}
+	buff, err := marshalCommitment(c.U, len(c.W))
+	if err != nil {
+		return nil, err
+	}
+	buff = append(buff, c.V...)
+	return append(buff, c.W...), nil
+}
+
+// UnmarshalBinary decodes a ciphertext encoded by MarshalBinary. U must be
+// set beforehand to a point of the expected group, such as
+// suite.G1().Point() for a ciphertext of EncryptCCAonG1. The point must be in
+// the prime order subgroup.
+func (c *Ciphertext) UnmarshalBinary(buff []byte) error {
+	rest, err := unmarshalCommitment(c.U, buff)
+	if err != nil {
+		return err
+	}
+	n := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) != 2*n {
+		return errors.New("invalid ciphertext length")
+	}
+	c.V = append([]byte(nil), rest[:n]...)
+	c.W = append([]byte(nil), rest[n:]...)
+	return nil
+}
+
+// MarshalBinary encodes the ciphertext.
+func (c *CiphertextCPA) MarshalBinary() ([]byte, error) {
+	buff, err := marshalCommitment(c.RP, len(c.C))
+	if err != nil {
+		return nil, err
+	}
+	return append(buff, c.C...), nil
+}
+
+// UnmarshalBinary decodes a ciphertext encoded by MarshalBinary. RP must be
+// set beforehand to a point of the expected group, such as
+// suite.G1().Point() for a ciphertext of EncryptCPAonG1. The point must be in
+// the prime order subgroup.
+func (c *CiphertextCPA) UnmarshalBinary(buff []byte) error {
+	rest, err := unmarshalCommitment(c.RP, buff)
+	if err != nil {
+		return err
+	}
+	n := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) != n {
+		return errors.New("invalid ciphertext length")
+	}
+	c.C = append([]byte(nil), rest...)
+	return nil
+}
+
+// marshalCommitment returns the encoding of the ciphertext up to the length
+// of the message.
+func marshalCommitment(p kyber.Point, msgLen int) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("missing commitment point")
+	}
+	if msgLen>>16 > 0 {
+		return nil, errors.New("message too long")
+	}
+	buff := []byte{EncodingVersion}
+	buff = append(buff, groupID(p)...)
+	point, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buff = append(buff, point...)
+	return append(buff, byte(msgLen>>8), byte(msgLen)), nil
+}
+
+// unmarshalCommitment decodes into p the commitment point of the encoding of
+// a ciphertext, and returns the rest of the encoding from the length of the
+// message.
+func unmarshalCommitment(p kyber.Point, buff []byte) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("the commitment point must be set to a point of the expected group")
+	}
+	pointLen := p.MarshalSize()
+	if len(buff) < 1+groupIDSize+pointLen+2 {
+		return nil, errors.New("ciphertext too short")
+	}
+	if buff[0] != EncodingVersion {
+		return nil, fmt.Errorf("unsupported encoding version %d", buff[0])
+	}
+	buff = buff[1:]
+	if string(buff[:groupIDSize]) != string(groupID(p)) {
+		return nil, errors.New("ciphertext for another group")
+	}
+	buff = buff[groupIDSize:]
+	if err := p.UnmarshalBinary(buff[:pointLen]); err != nil {
+		return nil, err
+	}
+	if sub, ok := p.(kyber.SubGroupElement); ok && !sub.IsInCorrectGroup() {
+		return nil, errors.New("point not in the expected group")
+	}
+	return buff[pointLen:], nil
+}
+
+// groupID identifies the group of the point by the hash of the encoding of
+// its base point, which differs for G1 and G2 and across curves.
+func groupID(p kyber.Point) []byte {
+	base, _ := p.Clone().Base().MarshalBinary()
+	h := sha256.Sum256(base)
+	return h[:groupIDSize]
+}
@@ -0,0 +1,105 @@
+package ibe
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiphertextEncoding(t *testing.T) {
+	msg := []byte("Hello World\n")
+	suite := bls.NewBLS12381Suite()
+	for _, g := range []KeyGroup{KeyOnG1, KeyOnG2} {
+		for _, scheme := range []Scheme{NewCCAScheme(suite, g), NewCPAScheme(suite, g)} {
+			master := scheme.KeyGroup().Point().Pick(random.New())
+			c, err := scheme.Encrypt(master, []byte("passtherand"), msg)
+			require.NoError(t, err)
+			buff, err := c.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, EncodingVersion, buff[0])
+
+			decoded, err := scheme.Unmarshal(buff)
+			require.NoError(t, err)
+			reencoded, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, buff, reencoded)
+
+			for i := 0; i < len(buff); i++ {
+				_, err = scheme.Unmarshal(buff[:i])
+				require.Error(t, err)
+			}
+			_, err = scheme.Unmarshal(append(buff, 0))
+			require.Error(t, err)
+			invalid := append([]byte(nil), buff...)
+			invalid[0]++
+			_, err = scheme.Unmarshal(invalid)
+			require.Error(t, err)
+		}
+	}
+
+	// a ciphertext with U on G1 is not decoded on G2
+	c, err := EncryptCCAonG1(suite, suite.G1().Point().Pick(random.New()), []byte("passtherand"), msg)
+	require.NoError(t, err)
+	buff, err := c.MarshalBinary()
+	require.NoError(t, err)
+	err = (&Ciphertext{U: suite.G2().Point()}).UnmarshalBinary(buff)
+	require.Error(t, err)
+	err = (&Ciphertext{}).UnmarshalBinary(buff)
+	require.Error(t, err)
+	decoded := &Ciphertext{U: suite.G1().Point()}
+	require.NoError(t, decoded.UnmarshalBinary(buff))
+	require.True(t, c.U.Equal(decoded.U))
+	require.Equal(t, c.V, decoded.V)
+	require.Equal(t, c.W, decoded.W)
+
+	c.W = c.W[1:]
+	_, err = c.MarshalBinary()
+	require.Error(t, err)
+}
+
+// outsidePoint is a point whose group check always fails.
+type outsidePoint struct {
+	kyber.Point
+}
+
+func (p *outsidePoint) IsInCorrectGroup() bool {
+	return false
+}
+
+func TestCiphertextEncodingSubGroup(t *testing.T) {
+	suite := bls.NewBLS12381Suite()
+	c, err := EncryptCPAonG1(suite, suite.G1().Point().Base(), suite.G1().Point().Pick(random.New()), []byte("passtherand"), []byte("Hello World\n"))
+	require.NoError(t, err)
+	buff, err := c.MarshalBinary()
+	require.NoError(t, err)
+
+	err = (&CiphertextCPA{RP: &outsidePoint{suite.G1().Point()}}).UnmarshalBinary(buff)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "point not in the expected group")
+
+	// replace RP by a point of the curve outside of the prime order subgroup
+	copy(buff[1+groupIDSize:], nonSubGroupG1())
+	err = (&CiphertextCPA{RP: suite.G1().Point()}).UnmarshalBinary(buff)
+	require.Error(t, err)
+}
+
+// nonSubGroupG1 returns the compressed encoding of a point of BLS12-381 G1,
+// y^2 = x^3 + 4, which is almost surely outside of the prime order subgroup.
+func nonSubGroupG1() []byte {
+	p, _ := new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	for x := int64(1); ; x++ {
+		X := big.NewInt(x)
+		y2 := new(big.Int).Exp(X, big.NewInt(3), p)
+		y2.Add(y2, big.NewInt(4)).Mod(y2, p)
+		if new(big.Int).ModSqrt(y2, p) == nil {
+			continue
+		}
+		buff := X.FillBytes(make([]byte, 48))
+		buff[0] |= 0x80
+		return buff
+	}
+}
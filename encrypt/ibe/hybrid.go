@@ -0,0 +1,117 @@
+package ibe
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/encrypt/internal/stream"
+	"github.com/drand/kyber/pairing"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The hybrid mode encrypts messages of any length: a random file key is
+// encrypted with the CCA scheme, and the message with ChaCha20-Poly1305 under
+// a key derived from the file key, in chunks with the STREAM construction.
+// The ciphertext is the header U || V || W followed by the encrypted chunks.
+
+// fileKeySize is the size of the key encrypted with the CCA scheme.
+const fileKeySize = 32
+
+// hybridInfo is the HKDF info deriving the payload key from the file key.
+const hybridInfo = "IBE-HYBRID-PAYLOAD-v1"
+
+// EncryptStreamOnG1 encrypts towards ID with the master key on G1 the
+// message written to the returned writer, and writes the ciphertext to dst.
+// The writer must be closed to finish the encryption, which does not close
+// dst.
+func EncryptStreamOnG1(s pairing.Suite, master kyber.Point, ID []byte, dst io.Writer) (io.WriteCloser, error) {
+	return encryptStream(s, EncryptCCAonG1, master, ID, dst)
+}
+
+// DecryptStreamOnG1 decrypts a ciphertext encrypted with EncryptStreamOnG1
+// given the G2 "private" point. The header is decrypted before returning, but
+// the message is only authenticated chunk by chunk as it is read: the reader
+// returns an error on any invalid chunk, and io.EOF once the whole message is
+// authenticated.
+func DecryptStreamOnG1(s pairing.Suite, private kyber.Point, src io.Reader) (io.Reader, error) {
+	return decryptStream(s, s.G1(), DecryptCCAonG1, private, src)
+}
+
+// EncryptStreamOnG2 is EncryptStreamOnG1 with the master key on G2.
+func EncryptStreamOnG2(s pairing.Suite, master kyber.Point, ID []byte, dst io.Writer) (io.WriteCloser, error) {
+	return encryptStream(s, EncryptCCAonG2, master, ID, dst)
+}
+
+// DecryptStreamOnG2 decrypts a ciphertext encrypted with EncryptStreamOnG2
+// given the G1 "private" point, as DecryptStreamOnG1.
+func DecryptStreamOnG2(s pairing.Suite, private kyber.Point, src io.Reader) (io.Reader, error) {
+	return decryptStream(s, s.G2(), DecryptCCAonG2, private, src)
+}
+
+type ccaEncrypt func(s pairing.Suite, master kyber.Point, ID, msg []byte) (*Ciphertext, error)
+
+type ccaDecrypt func(s pairing.Suite, private kyber.Point, c *Ciphertext) ([]byte, error)
+
+func encryptStream(s pairing.Suite, encrypt ccaEncrypt, master kyber.Point, ID []byte, dst io.Writer) (io.WriteCloser, error) {
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, fmt.Errorf("err reading rand file key: %v", err)
+	}
+	c, err := encrypt(s, master, ID, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	header, err := c.U.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	header = append(header, c.V...)
+	header = append(header, c.W...)
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewWriter(aead, dst), nil
+}
+
+func decryptStream(s pairing.Suite, g kyber.Group, decrypt ccaDecrypt, private kyber.Point, src io.Reader) (io.Reader, error) {
+	header := make([]byte, g.PointLen()+2*fileKeySize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, fmt.Errorf("err reading header: %v", err)
+	}
+	U := g.Point()
+	if err := U.UnmarshalBinary(header[:g.PointLen()]); err != nil {
+		return nil, fmt.Errorf("invalid header point: %v", err)
+	}
+	c := &Ciphertext{
+		U: U,
+		V: header[g.PointLen() : g.PointLen()+fileKeySize],
+		W: header[g.PointLen()+fileKeySize:],
+	}
+	fileKey, err := decrypt(s, private, c)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewReader(aead, src), nil
+}
+
+func payloadAEAD(fileKey []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nil, []byte(hybridInfo)), key); err != nil {
+		return nil, errors.New("err deriving the payload key")
+	}
+	return chacha20poly1305.New(key)
+}
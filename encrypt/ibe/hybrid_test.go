@@ -0,0 +1,86 @@
+package ibe
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamSetting(i uint) (
+	pairing.Suite, kyber.Point, []byte, kyber.Point,
+	func(s pairing.Suite, master kyber.Point, ID []byte, dst io.Writer) (io.WriteCloser, error),
+	func(s pairing.Suite, private kyber.Point, src io.Reader) (io.Reader, error),
+) {
+	suite, Ppub, ID, sQid, _, _ := newSetting(i)
+	if i == 1 {
+		return suite, Ppub, ID, sQid, EncryptStreamOnG1, DecryptStreamOnG1
+	}
+	return suite, Ppub, ID, sQid, EncryptStreamOnG2, DecryptStreamOnG2
+}
+
+func TestStreamEncryption(t *testing.T) {
+	for _, i := range []uint{1, 2} {
+		suite, Ppub, ID, sQid, encrypt, decrypt := newStreamSetting(i)
+		for _, size := range []int{0, 1, 1 << 20} {
+			msg := random.Bits(uint(size*8), false, random.New())
+			var b bytes.Buffer
+			w, err := encrypt(suite, Ppub, ID, &b)
+			require.NoError(t, err)
+			_, err = w.Write(msg)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			r, err := decrypt(suite, sQid, bytes.NewReader(b.Bytes()))
+			require.NoError(t, err)
+			decrypted, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, msg, decrypted)
+		}
+	}
+}
+
+func TestStreamInvalidDecryption(t *testing.T) {
+	for _, i := range []uint{1, 2} {
+		suite, Ppub, ID, sQid, encrypt, decrypt := newStreamSetting(i)
+		var b bytes.Buffer
+		w, err := encrypt(suite, Ppub, ID, &b)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("Hello World\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		ciphertext := b.Bytes()
+		headerLen := len(ciphertext) - len("Hello World\n") - 16
+
+		// wrong private key
+		wrong := sQid.Clone().Mul(suite.G1().Scalar().Pick(random.New()), sQid)
+		_, err = decrypt(suite, wrong, bytes.NewReader(ciphertext))
+		require.Error(t, err)
+
+		// tampered header
+		for _, pos := range []int{headerLen - 1, headerLen - 1 - fileKeySize} {
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[pos] ^= 1
+			_, err = decrypt(suite, sQid, bytes.NewReader(tampered))
+			require.Error(t, err)
+		}
+
+		// truncated header
+		_, err = decrypt(suite, sQid, bytes.NewReader(ciphertext[:headerLen-1]))
+		require.Error(t, err)
+
+		// tampered and truncated payload
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[headerLen] ^= 1
+		for _, c := range [][]byte{tampered, ciphertext[:headerLen], ciphertext[:len(ciphertext)-1]} {
+			r, err := decrypt(suite, sQid, bytes.NewReader(c))
+			require.NoError(t, err)
+			_, err = io.ReadAll(r)
+			require.Error(t, err)
+		}
+	}
+}
@@ -394,3 +394,51 @@ func DecryptCPAonG1(s pairing.Suite, private kyber.Point, c *CiphertextCPA) ([]b
 	}
 	return xor(c.C, hGidT), nil
 }
+
+// EncryptCPAonG2 is EncryptCPAonG1 with the groups swapped:
+// KeyGroup = G2 (master public keys)
+// SigGroup = G1 (secret identities)
+// - U = rP \in G2, with P the base point of G2
+// - Qid = H1(ID) \in G1
+func EncryptCPAonG2(s pairing.Suite, basePoint, public kyber.Point, ID, msg []byte) (*CiphertextCPA, error) {
+	if len(msg)>>16 > 0 {
+		// we're using blake2 as XOF which only outputs 2^16-1 length
+		return nil, errors.New("ciphertext too long")
+	}
+	hashable, ok := s.G1().Point().(kyber.HashablePoint)
+	if !ok {
+		return nil, errors.New("point needs to implement hashablePoint")
+	}
+	Qid := hashable.Hash(ID)
+	r := s.G1().Scalar().Pick(random.New())
+	rP := s.G2().Point().Mul(r, basePoint)
+
+	// e(Qid, Ppub) = e( H(round), s*P) where s is dist secret key
+	Ppub := public
+	rQid := s.G1().Point().Mul(r, Qid)
+	GidT := s.Pair(rQid, Ppub)
+	// H(gid)
+	hGidT, err := gtToHash(s, GidT, len(msg))
+	if err != nil {
+		return nil, err
+	}
+	xored := xor(msg, hGidT)
+
+	return &CiphertextCPA{
+		RP: rP,
+		C:  xored,
+	}, nil
+}
+
+// DecryptCPAonG2 decrypts ciphertexts encrypted using EncryptCPAonG2 given a
+// G1 "private" point:
+// - V XOR H2(e(did, U)) = V XOR H2(e(s*Qid, rP)) = M
+func DecryptCPAonG2(s pairing.Suite, private kyber.Point, c *CiphertextCPA) ([]byte, error) {
+	GidT := s.Pair(private, c.RP)
+	hGidT, err := gtToHash(s, GidT, len(c.C))
+
+	if err != nil {
+		return nil, err
+	}
+	return xor(c.C, hGidT), nil
+}
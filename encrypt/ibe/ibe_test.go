@@ -259,3 +259,25 @@ func TestCPAEncryptOnG1(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, msg, msg2)
 }
+
+func TestCPAEncryptOnG2(t *testing.T) {
+	suite := bls.NewBLS12381Suite()
+	P := suite.G2().Point().Pick(random.New())
+	s := suite.G2().Scalar().Pick(random.New())
+	Ppub := suite.G2().Point().Mul(s, P)
+	ID := []byte("passtherand")
+	IDP := suite.G1().Point().(kyber.HashablePoint)
+	Qid := IDP.Hash(ID)
+	sQid := Qid.Mul(s, Qid)
+	msg := []byte("Hello World\n")
+	c, err := EncryptCPAonG2(suite, P, Ppub, ID, msg)
+	require.NoError(t, err)
+	msg2, err := DecryptCPAonG2(suite, sQid, c)
+	require.NoError(t, err)
+	require.Equal(t, msg, msg2)
+
+	wrong := Qid.Mul(suite.G1().Scalar().Pick(random.New()), Qid)
+	msg2, err = DecryptCPAonG2(suite, wrong, c)
+	require.NoError(t, err)
+	require.NotEqual(t, msg, msg2)
+}
@@ -0,0 +1,179 @@
+package ibe
+
+import (
+	"encoding"
+	"errors"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// KeyGroup selects the group of the master public key of a Scheme. The
+// identities and their private keys are on the other group.
+type KeyGroup int
+
+const (
+	// KeyOnG1 puts the master public key on G1 and the private keys on G2, as
+	// EncryptCCAonG1 and EncryptCPAonG1.
+	KeyOnG1 KeyGroup = iota
+	// KeyOnG2 puts the master public key on G2 and the private keys on G1, as
+	// EncryptCCAonG2 and EncryptCPAonG2.
+	KeyOnG2
+)
+
+// EncryptedMessage is a ciphertext of a Scheme: a *Ciphertext for the CCA
+// schemes and a *CiphertextCPA for the CPA ones.
+type EncryptedMessage interface {
+	encoding.BinaryMarshaler
+	commitment() kyber.Point
+}
+
+func (c *Ciphertext) commitment() kyber.Point { return c.U }
+
+func (c *CiphertextCPA) commitment() kyber.Point { return c.RP }
+
+// Scheme is an identity-based encryption scheme, so that callers can switch
+// between the variants of the package without code changes.
+type Scheme interface {
+	// KeyGroup returns the group of the master public key.
+	KeyGroup() kyber.Group
+	// IdentityGroup returns the group of the identities and private keys.
+	IdentityGroup() kyber.Group
+	// Extract returns the private key of the identity ID given the master
+	// secret.
+	Extract(master kyber.Scalar, ID []byte) (kyber.Point, error)
+	// Encrypt encrypts msg towards ID with the master public key.
+	Encrypt(master kyber.Point, ID, msg []byte) (EncryptedMessage, error)
+	// Decrypt decrypts c with the private key of its identity.
+	Decrypt(private kyber.Point, c EncryptedMessage) ([]byte, error)
+	// Marshal encodes a ciphertext of the scheme.
+	Marshal(c EncryptedMessage) ([]byte, error)
+	// Unmarshal decodes a ciphertext encoded by Marshal, checking that its
+	// commitment point is on the key group.
+	Unmarshal(buff []byte) (EncryptedMessage, error)
+}
+
+type scheme struct {
+	suite    pairing.Suite
+	keyGroup kyber.Group
+	idGroup  kyber.Group
+	cca      bool
+	onG1     bool
+}
+
+// NewCCAScheme returns the CCA secure scheme of EncryptCCAonG1 and
+// EncryptCCAonG2, whose messages are at most s.Hash().Size() bytes long.
+func NewCCAScheme(s pairing.Suite, g KeyGroup) Scheme {
+	return newScheme(s, g, true)
+}
+
+// NewCPAScheme returns the CPA secure scheme of EncryptCPAonG1 and
+// EncryptCPAonG2, using the base point of the key group.
+func NewCPAScheme(s pairing.Suite, g KeyGroup) Scheme {
+	return newScheme(s, g, false)
+}
+
+func newScheme(s pairing.Suite, g KeyGroup, cca bool) *scheme {
+	if g == KeyOnG2 {
+		return &scheme{suite: s, keyGroup: s.G2(), idGroup: s.G1(), cca: cca}
+	}
+	return &scheme{suite: s, keyGroup: s.G1(), idGroup: s.G2(), cca: cca, onG1: true}
+}
+
+func (s *scheme) KeyGroup() kyber.Group {
+	return s.keyGroup
+}
+
+func (s *scheme) IdentityGroup() kyber.Group {
+	return s.idGroup
+}
+
+func (s *scheme) Extract(master kyber.Scalar, ID []byte) (kyber.Point, error) {
+	Qid, err := s.hashID(ID)
+	if err != nil {
+		return nil, err
+	}
+	return Qid.Mul(master, Qid), nil
+}
+
+func (s *scheme) Encrypt(master kyber.Point, ID, msg []byte) (EncryptedMessage, error) {
+	base := s.keyGroup.Point().Base()
+	switch {
+	case s.cca && s.onG1:
+		return EncryptCCAonG1(s.suite, master, ID, msg)
+	case s.cca:
+		return EncryptCCAonG2(s.suite, master, ID, msg)
+	case s.onG1:
+		return EncryptCPAonG1(s.suite, base, master, ID, msg)
+	default:
+		return EncryptCPAonG2(s.suite, base, master, ID, msg)
+	}
+}
+
+func (s *scheme) Decrypt(private kyber.Point, c EncryptedMessage) ([]byte, error) {
+	if err := s.checkGroup(c); err != nil {
+		return nil, err
+	}
+	if s.cca {
+		cca, ok := c.(*Ciphertext)
+		if !ok {
+			return nil, errors.New("expected a CCA ciphertext")
+		}
+		if s.onG1 {
+			return DecryptCCAonG1(s.suite, private, cca)
+		}
+		return DecryptCCAonG2(s.suite, private, cca)
+	}
+	cpa, ok := c.(*CiphertextCPA)
+	if !ok {
+		return nil, errors.New("expected a CPA ciphertext")
+	}
+	if s.onG1 {
+		return DecryptCPAonG1(s.suite, private, cpa)
+	}
+	return DecryptCPAonG2(s.suite, private, cpa)
+}
+
+// Marshal encodes the ciphertext with its MarshalBinary method.
+func (s *scheme) Marshal(c EncryptedMessage) ([]byte, error) {
+	if err := s.checkGroup(c); err != nil {
+		return nil, err
+	}
+	switch c.(type) {
+	case *Ciphertext:
+		if !s.cca {
+			return nil, errors.New("expected a CPA ciphertext")
+		}
+	case *CiphertextCPA:
+		if s.cca {
+			return nil, errors.New("expected a CCA ciphertext")
+		}
+	}
+	return c.MarshalBinary()
+}
+
+func (s *scheme) Unmarshal(buff []byte) (EncryptedMessage, error) {
+	if s.cca {
+		c := &Ciphertext{U: s.keyGroup.Point()}
+		if err := c.UnmarshalBinary(buff); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	c := &CiphertextCPA{RP: s.keyGroup.Point()}
+	if err := c.UnmarshalBinary(buff); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkGroup checks that the commitment of c is on the key group.
+func (s *scheme) checkGroup(c EncryptedMessage) error {
+	if c == nil || c.commitment() == nil {
+		return errors.New("missing ciphertext commitment")
+	}
+	if !onGroup(s.keyGroup, c.commitment()) {
+		return errors.New("ciphertext commitment not on the key group")
+	}
+	return nil
+}
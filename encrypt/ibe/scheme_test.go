@@ -0,0 +1,81 @@
+package ibe
+
+import (
+	"testing"
+
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheme(t *testing.T) {
+	suite := bls.NewBLS12381Suite()
+	schemes := []Scheme{
+		NewCCAScheme(suite, KeyOnG1),
+		NewCCAScheme(suite, KeyOnG2),
+		NewCPAScheme(suite, KeyOnG1),
+		NewCPAScheme(suite, KeyOnG2),
+	}
+	msg := []byte("Hello World\n")
+	ID := []byte("passtherand")
+	for i, scheme := range schemes {
+		secret := scheme.KeyGroup().Scalar().Pick(random.New())
+		master := scheme.KeyGroup().Point().Mul(secret, nil)
+		private, err := scheme.Extract(secret, ID)
+		require.NoError(t, err)
+
+		c, err := scheme.Encrypt(master, ID, msg)
+		require.NoError(t, err)
+		buff, err := scheme.Marshal(c)
+		require.NoError(t, err)
+		decoded, err := scheme.Unmarshal(buff)
+		require.NoError(t, err)
+		reencoded, err := scheme.Marshal(decoded)
+		require.NoError(t, err)
+		require.Equal(t, buff, reencoded)
+		decrypted, err := scheme.Decrypt(private, decoded)
+		require.NoError(t, err)
+		require.Equal(t, msg, decrypted)
+
+		other, err := scheme.Extract(secret, []byte("other"))
+		require.NoError(t, err)
+		decrypted, err = scheme.Decrypt(other, c)
+		if err == nil {
+			require.NotEqual(t, msg, decrypted)
+		}
+
+		// the ciphertexts of the other variants are rejected
+		for j, otherScheme := range schemes {
+			if i == j {
+				continue
+			}
+			otherMaster := otherScheme.KeyGroup().Point().Mul(secret, nil)
+			otherC, err := otherScheme.Encrypt(otherMaster, ID, msg)
+			require.NoError(t, err)
+			_, err = scheme.Marshal(otherC)
+			require.Error(t, err)
+			_, err = scheme.Decrypt(private, otherC)
+			require.Error(t, err)
+		}
+	}
+
+	_, err := schemes[0].Unmarshal(nil)
+	require.Error(t, err)
+	c, err := schemes[0].Encrypt(schemes[0].KeyGroup().Point().Pick(random.New()), ID, msg)
+	require.NoError(t, err)
+	buff, err := schemes[0].Marshal(c)
+	require.NoError(t, err)
+	_, err = schemes[0].Unmarshal(buff[:len(buff)-1])
+	require.Error(t, err)
+}
+
+func TestSchemeCompatibility(t *testing.T) {
+	suite, Ppub, ID, sQid, _, _ := newSetting(1)
+	scheme := NewCCAScheme(suite, KeyOnG1)
+	msg := []byte("Hello World\n")
+	c, err := EncryptCCAonG1(suite, Ppub, ID, msg)
+	require.NoError(t, err)
+	decrypted, err := scheme.Decrypt(sQid, c)
+	require.NoError(t, err)
+	require.Equal(t, msg, decrypted)
+}
@@ -0,0 +1,200 @@
+package ibe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/internal/subgroup"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/share"
+)
+
+// The private key s·H(ID) of an identity can be extracted by a threshold of
+// the holders of shares of the master secret s, such as the DistKeyShares of
+// a share/dkg run whose public key is the master public key: each holder
+// computes with its share x_i the partial key x_i·H(ID), with a proof that it
+// has the same discrete logarithm as its public share x_i·P of the public
+// polynomial, and a combiner verifies the partial keys and interpolates the
+// private key.
+
+// PartialKey is a share of the private key of an identity.
+type PartialKey struct {
+	// Index is the index of the share of the master secret. It is not
+	// authenticated, see RecoverKey.
+	Index int
+	// Key is the partial key x_i·H(ID), on the identity group.
+	Key kyber.Point
+	// Proof proves that Key is computed with the share of index Index.
+	Proof *PartialKeyProof
+}
+
+// PartialKeyProof is a proof of equality of the discrete logarithms of the
+// public share x_i·P, on the key group, and of the partial key x_i·H(ID), on
+// the identity group. Both groups have the same order, so the Chaum-Pedersen
+// proof applies across them.
+type PartialKeyProof struct {
+	C  kyber.Scalar // challenge
+	R  kyber.Scalar // response
+	VP kyber.Point  // commitment v·P on the key group
+	VH kyber.Point  // commitment v·H(ID) on the identity group
+}
+
+// ExtractPartial returns the partial key of ID for the share of the master
+// secret, such as DistKeyShare.PriShare(), of a scheme whose master public key
+// is on the key group g.
+func ExtractPartial(s pairing.Suite, g KeyGroup, private *share.PriShare, ID []byte) (*PartialKey, error) {
+	sch := newScheme(s, g, true)
+	H, err := sch.hashID(ID)
+	if err != nil {
+		return nil, err
+	}
+	xP := sch.keyGroup.Point().Mul(private.V, nil)
+	xH := sch.idGroup.Point().Mul(private.V, H)
+
+	v := sch.keyGroup.Scalar().Pick(s.RandomStream())
+	vP := sch.keyGroup.Point().Mul(v, nil)
+	vH := sch.idGroup.Point().Mul(v, H)
+	c, err := sch.challenge(private.I, H, xP, xH, vP, vH)
+	if err != nil {
+		return nil, err
+	}
+	r := sch.keyGroup.Scalar().Mul(private.V, c)
+	r.Sub(v, r)
+	return &PartialKey{
+		Index: private.I,
+		Key:   xH,
+		Proof: &PartialKeyProof{C: c, R: r, VP: vP, VH: vH},
+	}, nil
+}
+
+// VerifyPartial checks the partial key of ID against the public share of its
+// index in the public polynomial of the master key.
+func VerifyPartial(s pairing.Suite, g KeyGroup, public *share.PubPoly, ID []byte, p *PartialKey) error {
+	sch := newScheme(s, g, true)
+	H, err := sch.hashID(ID)
+	if err != nil {
+		return err
+	}
+	if p == nil || p.Key == nil || p.Proof == nil || p.Index < 0 {
+		return errors.New("incomplete partial key")
+	}
+	pr := p.Proof
+	if pr.C == nil || pr.R == nil || pr.VP == nil || pr.VH == nil {
+		return errors.New("incomplete partial key proof")
+	}
+	if !onGroup(sch.idGroup, p.Key) || !onGroup(sch.idGroup, pr.VH) || !onGroup(sch.keyGroup, pr.VP) {
+		return errors.New("partial key not on the expected groups")
+	}
+	xP := public.Eval(p.Index).V
+	c, err := sch.challenge(p.Index, H, xP, p.Key, pr.VP, pr.VH)
+	if err != nil {
+		return err
+	}
+	if !c.Equal(pr.C) {
+		return errors.New("invalid partial key proof")
+	}
+	// vP == rP + c·xP and vH == rH + c·xH
+	a := sch.keyGroup.Point().Mul(pr.R, nil)
+	a.Add(a, sch.keyGroup.Point().Mul(pr.C, xP))
+	b := sch.idGroup.Point().Mul(pr.R, H)
+	b.Add(b, sch.idGroup.Point().Mul(pr.C, p.Key))
+	if !a.Equal(pr.VP) || !b.Equal(pr.VH) {
+		return errors.New("invalid partial key proof")
+	}
+	return nil
+}
+
+// RecoverKey verifies the partial keys of ID and interpolates the private key
+// of ID from t valid ones out of n shares. Invalid partial keys are skipped,
+// and the indexes they claim are returned. Nothing authenticates these
+// indexes, so anyone can send an invalid partial key in the name of an honest
+// holder: the returned indexes identify the holders that cheated only if each
+// partial key was received from the holder of its index over an authenticated
+// channel. The recovered key is checked with VerifyKey before it is returned,
+// ready to be used with the Decrypt functions.
+func RecoverKey(s pairing.Suite, g KeyGroup, public *share.PubPoly, ID []byte, partials []*PartialKey, t, n int) (kyber.Point, []int, error) {
+	sch := newScheme(s, g, true)
+	var shares []*share.PubShare
+	var invalid []int
+	seen := make(map[int]bool)
+	for _, p := range partials {
+		if p == nil || seen[p.Index] {
+			continue
+		}
+		if err := VerifyPartial(s, g, public, ID, p); err != nil || p.Index >= n {
+			invalid = append(invalid, p.Index)
+			continue
+		}
+		seen[p.Index] = true
+		shares = append(shares, &share.PubShare{I: p.Index, V: p.Key})
+		if len(shares) >= t {
+			break
+		}
+	}
+	if len(shares) < t {
+		return nil, invalid, fmt.Errorf("only %d/%d valid partial keys", len(shares), t)
+	}
+	key, err := share.RecoverCommit(sch.idGroup, shares, t, n)
+	if err != nil {
+		return nil, invalid, err
+	}
+	if err := VerifyKey(s, g, public.Commit(), ID, key); err != nil {
+		return nil, invalid, err
+	}
+	return key, invalid, nil
+}
+
+// VerifyKey checks with a pairing that private is the private key of ID for
+// the master public key on the key group g.
+func VerifyKey(s pairing.Suite, g KeyGroup, master kyber.Point, ID []byte, private kyber.Point) error {
+	sch := newScheme(s, g, true)
+	H, err := sch.hashID(ID)
+	if err != nil {
+		return err
+	}
+	var valid bool
+	if sch.onG1 {
+		// e(sP, H(ID)) == e(P, sH(ID))
+		valid = s.ValidatePairing(master, H, s.G1().Point().Base(), private)
+	} else {
+		valid = s.ValidatePairing(H, master, private, s.G2().Point().Base())
+	}
+	if !valid {
+		return errors.New("invalid identity key")
+	}
+	return nil
+}
+
+// onGroup checks that p is a point of g: its encoding has the size of the
+// points of g, it has the base point of g, as in groupID, and it is in the
+// subgroup of g.
+func onGroup(g kyber.Group, p kyber.Point) bool {
+	if p.MarshalSize() != g.PointLen() || string(groupID(p)) != string(groupID(g.Point())) {
+		return false
+	}
+	return subgroup.Contains(g, p)
+}
+
+func (s *scheme) hashID(ID []byte) (kyber.Point, error) {
+	hashable, ok := s.idGroup.Point().(kyber.HashablePoint)
+	if !ok {
+		return nil, errors.New("point needs to implement `kyber.HashablePoint`")
+	}
+	return hashable.Hash(ID), nil
+}
+
+// challenge hashes the statement and the commitments of a partial key proof.
+func (s *scheme) challenge(index int, H kyber.Point, points ...kyber.Point) (kyber.Scalar, error) {
+	h := s.suite.Hash()
+	if err := binary.Write(h, binary.BigEndian, uint32(index)); err != nil {
+		return nil, err
+	}
+	for _, p := range append([]kyber.Point{H}, points...) {
+		if _, err := p.MarshalTo(h); err != nil {
+			return nil, err
+		}
+	}
+	return s.keyGroup.Scalar().Pick(s.suite.XOF(h.Sum(nil))), nil
+}
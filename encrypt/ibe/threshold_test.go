@@ -0,0 +1,83 @@
+package ibe
+
+import (
+	"testing"
+
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdExtraction(t *testing.T) {
+	suite := bls.NewBLS12381Suite()
+	n, thr := 7, 4
+	ID := []byte("passtherand")
+	msg := []byte("Hello World\n")
+	for _, g := range []KeyGroup{KeyOnG1, KeyOnG2} {
+		scheme := NewCCAScheme(suite, g)
+		secret := scheme.KeyGroup().Scalar().Pick(random.New())
+		priPoly := share.NewPriPoly(scheme.KeyGroup(), thr, secret, random.New())
+		pubPoly := priPoly.Commit(nil)
+		master := pubPoly.Commit()
+
+		var partials []*PartialKey
+		for _, sh := range priPoly.Shares(n) {
+			p, err := ExtractPartial(suite, g, sh, ID)
+			require.NoError(t, err)
+			require.NoError(t, VerifyPartial(suite, g, pubPoly, ID, p))
+			partials = append(partials, p)
+		}
+		// the partial key of another identity or share is invalid
+		other, err := ExtractPartial(suite, g, priPoly.Shares(n)[1], []byte("other"))
+		require.NoError(t, err)
+		require.Error(t, VerifyPartial(suite, g, pubPoly, ID, other))
+		other.Index = 0
+		require.Error(t, VerifyPartial(suite, g, pubPoly, ID, other))
+		require.Error(t, VerifyPartial(suite, g, pubPoly, ID, &PartialKey{Index: 0, Key: partials[0].Key}))
+
+		// malformed partial keys are rejected without panicking
+		pr := partials[0].Proof
+		malformed := []*PartialKey{
+			{Index: 0, Key: partials[0].Key, Proof: &PartialKeyProof{}},
+			{Index: 0, Key: partials[0].Key, Proof: &PartialKeyProof{C: pr.C, VP: pr.VP, VH: pr.VH}},
+			{Index: 0, Key: partials[0].Key, Proof: &PartialKeyProof{C: pr.C, R: pr.R, VH: pr.VH}},
+			{Index: 0, Key: partials[0].Key, Proof: &PartialKeyProof{C: pr.C, R: pr.R, VP: pr.VH, VH: pr.VP}},
+			{Index: 0, Key: scheme.KeyGroup().Point().Pick(random.New()), Proof: pr},
+		}
+		for _, p := range malformed {
+			require.Error(t, VerifyPartial(suite, g, pubPoly, ID, p))
+		}
+		_, blamed, err := RecoverKey(suite, g, pubPoly, ID, malformed, thr, n)
+		require.Error(t, err)
+		require.Len(t, blamed, len(malformed))
+
+		// cheating holders are identified and skipped
+		cheaters := []*PartialKey{
+			{Index: 2, Key: partials[1].Key, Proof: partials[1].Proof},
+			{Index: 3, Key: scheme.IdentityGroup().Point().Pick(random.New()), Proof: partials[3].Proof},
+		}
+		key, invalid, err := RecoverKey(suite, g, pubPoly, ID, append(cheaters, partials[1:]...), thr, n)
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 3}, invalid)
+		require.NoError(t, VerifyKey(suite, g, master, ID, key))
+		expected, err := scheme.Extract(secret, ID)
+		require.NoError(t, err)
+		require.True(t, expected.Equal(key))
+
+		c, err := scheme.Encrypt(master, ID, msg)
+		require.NoError(t, err)
+		decrypted, err := scheme.Decrypt(key, c)
+		require.NoError(t, err)
+		require.Equal(t, msg, decrypted)
+
+		_, invalid, err = RecoverKey(suite, g, pubPoly, ID, append(cheaters, partials[4:]...), thr, n)
+		require.Error(t, err)
+		require.Equal(t, []int{2, 3}, invalid)
+		// duplicates do not count twice
+		_, _, err = RecoverKey(suite, g, pubPoly, ID, []*PartialKey{partials[0], partials[0], partials[1], partials[2]}, thr, n)
+		require.Error(t, err)
+
+		require.Error(t, VerifyKey(suite, g, master, []byte("other"), key))
+	}
+}
@@ -0,0 +1,215 @@
+// Package stream implements the STREAM construction of Hoang, Reyhanitabar,
+// Rogaway and Vizár, "Online Authenticated-Encryption and its Nonce-Reuse
+// Misuse-Resistance", which encrypts a stream of arbitrary length in chunks
+// with an AEAD, as used by the age file encryption format.
+//
+// The plaintext is split into chunks of ChunkSize bytes, each one sealed with
+// a nonce made of the big-endian counter of the chunk followed by a flag set
+// on the last chunk. The last chunk may be shorter, and is empty only if the
+// whole plaintext is. A reader therefore detects reordered, truncated or
+// extended streams. Each key must only encrypt a single stream.
+package stream
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// ChunkSize is the size of the plaintext of each chunk but the last one.
+const ChunkSize = 64 * 1024
+
+const lastChunkFlag = 0x01
+
+type nonce []byte
+
+func newNonce(a cipher.AEAD) nonce {
+	return make(nonce, a.NonceSize())
+}
+
+// increment increments the counter, i.e. all the bytes but the last one.
+func (n nonce) increment() error {
+	for i := len(n) - 2; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return nil
+		}
+	}
+	return errors.New("stream: chunk counter overflow")
+}
+
+func (n nonce) setLast(last bool) {
+	if last {
+		n[len(n)-1] = lastChunkFlag
+	} else {
+		n[len(n)-1] = 0
+	}
+}
+
+// Writer encrypts a stream with an AEAD. The writer must be closed to write
+// the last chunk.
+type Writer struct {
+	// AdditionalData is authenticated with each chunk. It must be set
+	// before the first write.
+	AdditionalData []byte
+
+	a     cipher.AEAD
+	dst   io.Writer
+	nonce nonce
+	buf   []byte
+	err   error
+}
+
+// NewWriter returns a writer encrypting to dst with the AEAD, whose nonces
+// must be at least 2 bytes long.
+func NewWriter(a cipher.AEAD, dst io.Writer) *Writer {
+	return &Writer{
+		a:     a,
+		dst:   dst,
+		nonce: newNonce(a),
+		buf:   make([]byte, 0, ChunkSize),
+	}
+}
+
+// Write encrypts p. The chunks are written to the underlying writer as soon
+// as they are complete, except the last one which is written by Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	var n int
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize {
+			// more data follows, so this chunk is not the last one
+			if err := w.flush(false); err != nil {
+				w.err = err
+				return n, err
+			}
+		}
+		c := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close writes the last chunk. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	err := w.flush(true)
+	if err == nil {
+		w.err = errors.New("stream: writer closed")
+	}
+	return err
+}
+
+func (w *Writer) flush(last bool) error {
+	w.nonce.setLast(last)
+	out := w.a.Seal(w.buf[:0:0], w.nonce, w.buf, w.AdditionalData)
+	if _, err := w.dst.Write(out); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return w.nonce.increment()
+}
+
+// Reader decrypts a stream encrypted by a Writer with the same AEAD and key.
+type Reader struct {
+	// AdditionalData must be the additional data of the Writer. It must be
+	// set before the first read.
+	AdditionalData []byte
+
+	a     cipher.AEAD
+	src   io.Reader
+	nonce nonce
+	// buf holds an encrypted chunk and the first byte of the next one, which
+	// is kept in next until the next chunk is read
+	buf     []byte
+	next    byte
+	hasNext bool
+	unread  []byte
+	first   bool
+	err     error
+}
+
+// NewReader returns a reader decrypting the stream read from src.
+func NewReader(a cipher.AEAD, src io.Reader) *Reader {
+	return &Reader{
+		a:     a,
+		src:   src,
+		nonce: newNonce(a),
+		buf:   make([]byte, ChunkSize+a.Overhead()+1),
+		first: true,
+	}
+}
+
+// Read decrypts the stream into p. The plaintext of a chunk is only returned
+// once the chunk is authenticated, and Read returns io.EOF after the last
+// chunk. Any other error means the stream is invalid.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.unread) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		last, err := r.readChunk()
+		if err != nil {
+			r.err = err
+		} else if last {
+			r.err = io.EOF
+		}
+	}
+	n := copy(p, r.unread)
+	r.unread = r.unread[n:]
+	return n, nil
+}
+
+// readChunk reads and decrypts the next chunk into unread.
+func (r *Reader) readChunk() (last bool, err error) {
+	encChunkSize := ChunkSize + r.a.Overhead()
+	var n int
+	if r.hasNext {
+		r.buf[0] = r.next
+		n = 1
+	}
+	m, err := io.ReadFull(r.src, r.buf[n:])
+	n += m
+	switch {
+	case err == nil:
+		// there is at least one byte after this chunk
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return false, err
+	}
+	chunk := r.buf[:n]
+	if !last {
+		chunk = chunk[:encChunkSize]
+	}
+	if len(chunk) < r.a.Overhead() {
+		return false, errors.New("stream: truncated chunk")
+	}
+	if last && !r.first && len(chunk) == r.a.Overhead() {
+		return false, errors.New("stream: empty last chunk")
+	}
+
+	r.nonce.setLast(last)
+	out, err := r.a.Open(chunk[:0], r.nonce, chunk, r.AdditionalData)
+	if err != nil {
+		return false, errors.New("stream: failed to authenticate chunk")
+	}
+	if err := r.nonce.increment(); err != nil {
+		return false, err
+	}
+	// the plaintext is decrypted in place, and buf is only reused once it is
+	// entirely read
+	r.unread = out
+	r.first = false
+	r.next, r.hasNext = r.buf[encChunkSize], !last
+	return last, nil
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func newAEAD(t *testing.T) cipher.AEAD {
+	key := make([]byte, chacha20poly1305.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	a, err := chacha20poly1305.New(key)
+	require.NoError(t, err)
+	return a
+}
+
+func seal(t *testing.T, a cipher.AEAD, msg []byte) []byte {
+	var b bytes.Buffer
+	w := NewWriter(a, &b)
+	// write in pieces that do not align with the chunks
+	for len(msg) > 0 {
+		n := 1000
+		if n > len(msg) {
+			n = len(msg)
+		}
+		_, err := w.Write(msg[:n])
+		require.NoError(t, err)
+		msg = msg[n:]
+	}
+	require.NoError(t, w.Close())
+	_, err := w.Write([]byte("late"))
+	require.Error(t, err)
+	return b.Bytes()
+}
+
+func open(a cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	return io.ReadAll(NewReader(a, bytes.NewReader(ciphertext)))
+}
+
+func TestStream(t *testing.T) {
+	a := newAEAD(t)
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 5} {
+		msg := make([]byte, size)
+		_, err := rand.Read(msg)
+		require.NoError(t, err)
+		ciphertext := seal(t, a, msg)
+		chunks := size/ChunkSize + 1
+		if size > 0 && size%ChunkSize == 0 {
+			chunks--
+		}
+		require.Equal(t, size+chunks*a.Overhead(), len(ciphertext))
+
+		decrypted, err := open(a, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, msg, decrypted)
+	}
+}
+
+func TestStreamInvalid(t *testing.T) {
+	a := newAEAD(t)
+	msg := make([]byte, 2*ChunkSize+10)
+	ciphertext := seal(t, a, msg)
+	encChunkSize := ChunkSize + a.Overhead()
+
+	invalid := map[string][]byte{
+		"empty":            nil,
+		"truncated":        ciphertext[:len(ciphertext)-1],
+		"no last chunk":    ciphertext[:2*encChunkSize],
+		"appended":         append(append([]byte(nil), ciphertext...), 0),
+		"reordered chunks": append(append(append([]byte(nil), ciphertext[encChunkSize:2*encChunkSize]...), ciphertext[:encChunkSize]...), ciphertext[2*encChunkSize:]...),
+	}
+	flipped := append([]byte(nil), ciphertext...)
+	flipped[encChunkSize+5] ^= 1
+	invalid["flipped byte"] = flipped
+	// a full chunk must be the last one rather than be followed by an empty one
+	var b bytes.Buffer
+	w := NewWriter(a, &b)
+	_, err := w.Write(make([]byte, ChunkSize))
+	require.NoError(t, err)
+	require.NoError(t, w.flush(false))
+	require.NoError(t, w.flush(true))
+	invalid["empty last chunk"] = b.Bytes()
+
+	for name, c := range invalid {
+		_, err := open(a, c)
+		require.Error(t, err, name)
+	}
+}
+
+func TestStreamAdditionalData(t *testing.T) {
+	a := newAEAD(t)
+	var b bytes.Buffer
+	w := NewWriter(a, &b)
+	w.AdditionalData = []byte("header")
+	_, err := w.Write(make([]byte, ChunkSize+1))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r := NewReader(a, bytes.NewReader(b.Bytes()))
+	r.AdditionalData = []byte("header")
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	_, err = open(a, b.Bytes())
+	require.Error(t, err)
+}
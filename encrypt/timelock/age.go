@@ -0,0 +1,231 @@
+package timelock
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/drand/kyber/encrypt/internal/stream"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The age format is made of a header listing the file key encrypted to each
+// recipient in a stanza, authenticated with an HMAC keyed by the file key,
+// followed by the payload encrypted with the STREAM construction:
+//
+//	age-encryption.org/v1
+//	-> tlock <round> <chain hash>
+//	<base64 body, 64 columns per line, the last one shorter>
+//	--- <base64 HMAC>
+//	<16 bytes nonce><encrypted chunks>
+
+const (
+	ageIntro   = "age-encryption.org/v1"
+	stanzaType = "tlock"
+	// columnsPerLine is the number of columns of the base64 lines.
+	columnsPerLine = 64
+	fileKeySize    = 16
+	nonceSize      = 16
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// stanza is the file key encrypted to a recipient.
+type stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+func (s *stanza) marshal(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "-> %s\n", strings.Join(append([]string{s.Type}, s.Args...), " ")); err != nil {
+		return err
+	}
+	body := b64.EncodeToString(s.Body)
+	for {
+		n := len(body)
+		if n > columnsPerLine {
+			n = columnsPerLine
+		}
+		if _, err := io.WriteString(w, body[:n]+"\n"); err != nil {
+			return err
+		}
+		// a full line is followed by another one, possibly empty
+		if n < columnsPerLine {
+			return nil
+		}
+		body = body[n:]
+	}
+}
+
+// encryptAge writes the header with the stanza of the file key returned by
+// wrap, and returns the writer of the payload.
+func encryptAge(dst io.Writer, wrap func(fileKey []byte) (*stanza, error)) (io.WriteCloser, error) {
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+	s, err := wrap(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	var header bytes.Buffer
+	header.WriteString(ageIntro + "\n")
+	if err := s.marshal(&header); err != nil {
+		return nil, err
+	}
+	header.WriteString("---")
+	mac, err := headerMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header.WriteString(" " + b64.EncodeToString(mac) + "\n")
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header.Write(nonce)
+	if _, err := dst.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewWriter(aead, dst), nil
+}
+
+// decryptAge reads the header and returns the reader of the payload, with the
+// file key returned by unwrap for the first stanza it knows, for which it
+// returns a nil key and no error for the others.
+func decryptAge(r *bufio.Reader, unwrap func(s *stanza) ([]byte, error)) (io.Reader, error) {
+	stanzas, header, mac, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	var fileKey []byte
+	for _, s := range stanzas {
+		fileKey, err = unwrap(s)
+		if err != nil {
+			return nil, err
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, errors.New("timelock: no tlock recipient")
+	}
+	expected, err := headerMAC(fileKey, header)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return nil, errors.New("timelock: invalid header mac")
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, fmt.Errorf("timelock: reading payload nonce: %v", err)
+	}
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewReader(aead, r), nil
+}
+
+// readHeader returns the stanzas, the header up to the mac, and the mac.
+func readHeader(r *bufio.Reader) ([]*stanza, []byte, []byte, error) {
+	var header bytes.Buffer
+	line, err := readLine(r, &header)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if line != ageIntro {
+		return nil, nil, nil, errors.New("timelock: not an age file")
+	}
+	var stanzas []*stanza
+	for {
+		line, err := readLine(r, &header)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if strings.HasPrefix(line, "--- ") {
+			mac, err := b64.DecodeString(line[4:])
+			if err != nil || len(mac) != sha256.Size {
+				return nil, nil, nil, errors.New("timelock: invalid header mac")
+			}
+			// the mac covers the header up to "---"
+			raw := header.Bytes()[:header.Len()-len(line)-1+len("---")]
+			return stanzas, raw, mac, nil
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, nil, nil, errors.New("timelock: invalid header line")
+		}
+		args := strings.Split(line[3:], " ")
+		for _, arg := range args {
+			if arg == "" {
+				return nil, nil, nil, errors.New("timelock: invalid stanza arguments")
+			}
+		}
+		s := &stanza{Type: args[0], Args: args[1:]}
+		for {
+			line, err := readLine(r, &header)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if len(line) > columnsPerLine {
+				return nil, nil, nil, errors.New("timelock: stanza body line too long")
+			}
+			b, err := b64.DecodeString(line)
+			if err != nil {
+				return nil, nil, nil, errors.New("timelock: invalid stanza body")
+			}
+			s.Body = append(s.Body, b...)
+			if len(line) < columnsPerLine {
+				break
+			}
+		}
+		stanzas = append(stanzas, s)
+	}
+}
+
+// readLine reads a line of the header, appends it to header and returns it
+// without the newline.
+func readLine(r *bufio.Reader, header *bytes.Buffer) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return "", fmt.Errorf("timelock: reading header: %v", err)
+	}
+	header.Write(line)
+	return string(line[:len(line)-1]), nil
+}
+
+func headerMAC(fileKey, header []byte) ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nil, []byte("header")), key); err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+func payloadAEAD(fileKey, nonce []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nonce, []byte("payload")), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
@@ -0,0 +1,180 @@
+package timelock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// The armor is the ASCII encoding of age files: the file encoded in base64
+// with padding, in lines of 64 columns but the last one, between a header and
+// a footer line.
+const (
+	armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+	armorFooter = "-----END AGE ENCRYPTED FILE-----"
+)
+
+type armorWriter struct {
+	dst     io.Writer
+	enc     io.WriteCloser
+	lines   *lineWriter
+	started bool
+}
+
+// NewArmorWriter returns a writer armoring the data written to it to dst. The
+// writer must be closed to write the end of the armor, which does not close
+// dst.
+func NewArmorWriter(dst io.Writer) io.WriteCloser {
+	lines := &lineWriter{dst: dst}
+	return &armorWriter{
+		dst:   dst,
+		enc:   base64.NewEncoder(base64.StdEncoding, lines),
+		lines: lines,
+	}
+}
+
+func (a *armorWriter) start() error {
+	if a.started {
+		return nil
+	}
+	a.started = true
+	_, err := io.WriteString(a.dst, armorHeader+"\n")
+	return err
+}
+
+func (a *armorWriter) Write(p []byte) (int, error) {
+	if err := a.start(); err != nil {
+		return 0, err
+	}
+	return a.enc.Write(p)
+}
+
+func (a *armorWriter) Close() error {
+	if err := a.start(); err != nil {
+		return err
+	}
+	if err := a.enc.Close(); err != nil {
+		return err
+	}
+	footer := armorFooter + "\n"
+	if a.lines.column > 0 {
+		footer = "\n" + footer
+	}
+	_, err := io.WriteString(a.dst, footer)
+	return err
+}
+
+// lineWriter breaks the lines after columnsPerLine columns.
+type lineWriter struct {
+	dst    io.Writer
+	column int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if l.column == columnsPerLine {
+			if _, err := io.WriteString(l.dst, "\n"); err != nil {
+				return n, err
+			}
+			l.column = 0
+		}
+		c := columnsPerLine - l.column
+		if c > len(p) {
+			c = len(p)
+		}
+		m, err := l.dst.Write(p[:c])
+		n += m
+		l.column += m
+		if err != nil {
+			return n, err
+		}
+		p = p[c:]
+	}
+	return n, nil
+}
+
+type armorReader struct {
+	r       *bufio.Reader
+	started bool
+	// short is set after the last line of base64, shorter than the others
+	short  bool
+	unread []byte
+	err    error
+}
+
+// NewArmorReader returns a reader of the data armored in src. Only
+// whitespace may follow the armor.
+func NewArmorReader(src io.Reader) io.Reader {
+	return &armorReader{r: bufio.NewReader(src)}
+}
+
+func (a *armorReader) Read(p []byte) (int, error) {
+	for len(a.unread) == 0 {
+		if a.err != nil {
+			return 0, a.err
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		a.err = a.readLine()
+	}
+	n := copy(p, a.unread)
+	a.unread = a.unread[n:]
+	return n, nil
+}
+
+func (a *armorReader) readLine() error {
+	if !a.started {
+		line, err := a.line()
+		if err != nil {
+			return err
+		}
+		if line != armorHeader {
+			return errors.New("timelock: invalid armor header")
+		}
+		a.started = true
+	}
+	line, err := a.line()
+	if err != nil {
+		return err
+	}
+	if line == armorFooter {
+		rest, err := io.ReadAll(a.r)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(rest)) != 0 {
+			return errors.New("timelock: data after the armor")
+		}
+		return io.EOF
+	}
+	if a.short || len(line) > columnsPerLine {
+		return errors.New("timelock: invalid armor line")
+	}
+	if len(line) < columnsPerLine || line[len(line)-1] == '=' {
+		a.short = true
+	}
+	a.unread, err = base64.StdEncoding.Strict().DecodeString(line)
+	if err != nil {
+		return errors.New("timelock: invalid armor line")
+	}
+	return nil
+}
+
+// line returns the next line, without the line ending.
+func (a *armorReader) line() (string, error) {
+	line, err := a.r.ReadSlice('\n')
+	switch {
+	case err == bufio.ErrBufferFull:
+		return "", errors.New("timelock: armor line too long")
+	case err == io.EOF && len(line) == 0:
+		return "", errors.New("timelock: truncated armor")
+	case err != nil && err != io.EOF:
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
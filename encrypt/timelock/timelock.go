@@ -0,0 +1,213 @@
+// Package timelock implements timelock encryption to the future rounds of a
+// drand chain of unchained beacons, whose beacon of a round is the BLS
+// signature of the hash of the round number by the threshold key of the
+// network. Using the round as the identity of the IBE scheme of the ibe
+// package, the beacon of the round is the private key of the identity, so a
+// message encrypted to a round can be decrypted by anyone once the network
+// publishes the beacon, and by no one before.
+//
+// The ciphertexts use the age file format, https://age-encryption.org/v1, with
+// a "tlock" recipient stanza carrying the round and the chain hash, as the
+// tlock tool does, and can optionally be armored.
+package timelock
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/encrypt/ibe"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/sign"
+	"github.com/drand/kyber/sign/bls"
+)
+
+// Network is a drand chain of unchained beacons.
+type Network struct {
+	// Suite is the pairing suite of the chain, whose hash to curve must be
+	// the one the beacons are signed with.
+	Suite pairing.Suite
+	// PublicKey is the distributed public key of the network.
+	PublicKey kyber.Point
+	// SignaturesOnG1 is true if the beacons are on G1 and the public key on
+	// G2, and false if the beacons are on G2 and the public key on G1.
+	SignaturesOnG1 bool
+	// Genesis is the time of the first round.
+	Genesis time.Time
+	// Period is the time between two rounds.
+	Period time.Duration
+	// ChainHash identifies the chain in the ciphertexts.
+	ChainHash []byte
+}
+
+func (n *Network) check() error {
+	switch {
+	case n.Suite == nil || n.PublicKey == nil:
+		return errors.New("timelock: missing suite or public key")
+	case n.Period <= 0:
+		return errors.New("timelock: invalid period")
+	case len(n.ChainHash) == 0:
+		return errors.New("timelock: missing chain hash")
+	}
+	return nil
+}
+
+// RoundAt returns the first round whose beacon is published at or after t.
+func (n *Network) RoundAt(t time.Time) uint64 {
+	if !t.After(n.Genesis) {
+		return 1
+	}
+	d := t.Sub(n.Genesis)
+	r := uint64(d / n.Period)
+	if d%n.Period != 0 {
+		r++
+	}
+	return r + 1
+}
+
+// RoundTime returns the time at which the beacon of the round is published.
+func (n *Network) RoundTime(round uint64) time.Time {
+	if round == 0 {
+		return n.Genesis
+	}
+	return n.Genesis.Add(time.Duration(round-1) * n.Period)
+}
+
+// Encrypt encrypts to the round the message written to the returned writer,
+// and writes the ciphertext to dst. The writer must be closed to finish the
+// encryption, which does not close dst. The ciphertext can be armored by
+// passing a writer returned by NewArmorWriter as dst.
+func (n *Network) Encrypt(dst io.Writer, round uint64) (io.WriteCloser, error) {
+	if err := n.check(); err != nil {
+		return nil, err
+	}
+	if round == 0 {
+		return nil, errors.New("timelock: invalid round 0")
+	}
+	return encryptAge(dst, func(fileKey []byte) (*stanza, error) {
+		var c *ibe.Ciphertext
+		var err error
+		if n.SignaturesOnG1 {
+			c, err = ibe.EncryptCCAonG2(n.Suite, n.PublicKey, roundMessage(round), fileKey)
+		} else {
+			c, err = ibe.EncryptCCAonG1(n.Suite, n.PublicKey, roundMessage(round), fileKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.U.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, c.V...)
+		body = append(body, c.W...)
+		return &stanza{
+			Type: stanzaType,
+			Args: []string{strconv.FormatUint(round, 10), fmt.Sprintf("%x", n.ChainHash)},
+			Body: body,
+		}, nil
+	})
+}
+
+// EncryptAt encrypts to the first round published at or after t, as Encrypt.
+func (n *Network) EncryptAt(dst io.Writer, t time.Time) (io.WriteCloser, error) {
+	return n.Encrypt(dst, n.RoundAt(t))
+}
+
+// Decrypt decrypts a ciphertext, armored or not, given the beacon signature
+// of the round it is encrypted to. The signature is verified against the
+// public key of the network before use. The returned reader authenticates the
+// message chunk by chunk as it is read, and returns io.EOF once the whole
+// message is authenticated.
+func (n *Network) Decrypt(src io.Reader, signature []byte) (io.Reader, error) {
+	if err := n.check(); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(src)
+	if start, _ := r.Peek(len(armorHeader)); string(start) == armorHeader {
+		r = bufio.NewReader(NewArmorReader(r))
+	}
+	return decryptAge(r, func(s *stanza) ([]byte, error) {
+		if s.Type != stanzaType {
+			return nil, nil
+		}
+		round, err := n.parseStanza(s)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.scheme().Verify(n.PublicKey, roundMessage(round), signature); err != nil {
+			return nil, fmt.Errorf("timelock: invalid beacon for round %d: %v", round, err)
+		}
+		return n.decryptStanza(s, signature)
+	})
+}
+
+// parseStanza checks the chain hash of the stanza and returns its round.
+func (n *Network) parseStanza(s *stanza) (uint64, error) {
+	if len(s.Args) != 2 {
+		return 0, errors.New("timelock: invalid tlock stanza")
+	}
+	round, err := strconv.ParseUint(s.Args[0], 10, 64)
+	if err != nil || strconv.FormatUint(round, 10) != s.Args[0] {
+		return 0, errors.New("timelock: invalid round in tlock stanza")
+	}
+	if s.Args[1] != fmt.Sprintf("%x", n.ChainHash) {
+		return 0, fmt.Errorf("timelock: ciphertext for chain %s", s.Args[1])
+	}
+	return round, nil
+}
+
+func (n *Network) decryptStanza(s *stanza, signature []byte) ([]byte, error) {
+	keyGroup, sigGroup := n.Suite.G1(), n.Suite.G2()
+	if n.SignaturesOnG1 {
+		keyGroup, sigGroup = sigGroup, keyGroup
+	}
+	if len(s.Body) != keyGroup.PointLen()+2*fileKeySize {
+		return nil, errors.New("timelock: invalid tlock stanza body")
+	}
+	c := &ibe.Ciphertext{
+		U: keyGroup.Point(),
+		V: s.Body[keyGroup.PointLen() : keyGroup.PointLen()+fileKeySize],
+		W: s.Body[keyGroup.PointLen()+fileKeySize:],
+	}
+	if err := c.U.UnmarshalBinary(s.Body[:keyGroup.PointLen()]); err != nil {
+		return nil, fmt.Errorf("timelock: invalid tlock stanza body: %v", err)
+	}
+	private := sigGroup.Point()
+	if err := private.UnmarshalBinary(signature); err != nil {
+		return nil, err
+	}
+	var fileKey []byte
+	var err error
+	if n.SignaturesOnG1 {
+		fileKey, err = ibe.DecryptCCAonG2(n.Suite, private, c)
+	} else {
+		fileKey, err = ibe.DecryptCCAonG1(n.Suite, private, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("timelock: %v", err)
+	}
+	return fileKey, nil
+}
+
+func (n *Network) scheme() sign.Scheme {
+	if n.SignaturesOnG1 {
+		return bls.NewSchemeOnG1(n.Suite)
+	}
+	return bls.NewSchemeOnG2(n.Suite)
+}
+
+// roundMessage returns the message signed by the beacon of an unchained
+// round, which is the IBE identity of the round.
+func roundMessage(round uint64) []byte {
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], round)
+	h := sha256.Sum256(buff[:])
+	return h[:]
+}
@@ -0,0 +1,244 @@
+package timelock
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	bls12381 "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/sign"
+	"github.com/drand/kyber/sign/bls"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func newNetwork(sigsOnG1 bool) (*Network, func(round uint64) []byte) {
+	suite := bls12381.NewBLS12381Suite()
+	var scheme sign.Scheme = bls.NewSchemeOnG2(suite)
+	if sigsOnG1 {
+		scheme = bls.NewSchemeOnG1(suite)
+	}
+	secret, public := scheme.NewKeyPair(random.New())
+	n := &Network{
+		Suite:          suite,
+		PublicKey:      public,
+		SignaturesOnG1: sigsOnG1,
+		Genesis:        time.Unix(1692803367, 0),
+		Period:         3 * time.Second,
+		ChainHash:      []byte{0x52, 0xdb, 0x9b, 0xa7},
+	}
+	beacon := func(round uint64) []byte {
+		sig, err := scheme.Sign(secret, roundMessage(round))
+		if err != nil {
+			panic(err)
+		}
+		return sig
+	}
+	return n, beacon
+}
+
+func encrypt(t *testing.T, n *Network, round uint64, msg []byte, armor bool) []byte {
+	var b bytes.Buffer
+	var dst io.WriteCloser = nopCloser{&b}
+	if armor {
+		dst = NewArmorWriter(&b)
+	}
+	w, err := n.Encrypt(dst, round)
+	require.NoError(t, err)
+	_, err = w.Write(msg)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, dst.Close())
+	return b.Bytes()
+}
+
+func decrypt(n *Network, ciphertext, signature []byte) ([]byte, error) {
+	r, err := n.Decrypt(bytes.NewReader(ciphertext), signature)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestRounds(t *testing.T) {
+	n, _ := newNetwork(false)
+	require.Equal(t, uint64(1), n.RoundAt(n.Genesis.Add(-time.Hour)))
+	require.Equal(t, uint64(1), n.RoundAt(n.Genesis))
+	require.Equal(t, uint64(2), n.RoundAt(n.Genesis.Add(time.Second)))
+	require.Equal(t, uint64(2), n.RoundAt(n.Genesis.Add(n.Period)))
+	require.Equal(t, uint64(3), n.RoundAt(n.Genesis.Add(n.Period+1)))
+	for round := uint64(1); round < 10; round++ {
+		require.Equal(t, round, n.RoundAt(n.RoundTime(round)))
+		require.False(t, n.RoundTime(n.RoundAt(n.Genesis.Add(time.Duration(round)*time.Second))).Before(n.Genesis.Add(time.Duration(round)*time.Second)))
+	}
+}
+
+func TestTimelock(t *testing.T) {
+	for _, sigsOnG1 := range []bool{false, true} {
+		n, beacon := newNetwork(sigsOnG1)
+		for _, size := range []int{0, 12, 200000} {
+			msg := random.Bits(uint(size*8), false, random.New())
+			for _, armor := range []bool{false, true} {
+				ciphertext := encrypt(t, n, 1000, msg, armor)
+				decrypted, err := decrypt(n, ciphertext, beacon(1000))
+				require.NoError(t, err)
+				require.Equal(t, msg, decrypted)
+			}
+		}
+
+		w, err := n.EncryptAt(io.Discard, n.RoundTime(5).Add(-time.Second))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+}
+
+// TestTimelockDrandBeacon decrypts with the beacon of round 1 of a drand
+// testnet chain of unchained beacons on G1, which hashes the rounds with the
+// domain of G2. The vector is the one of the kyber-bls12381 tests. It checks
+// the identities and keys against a real chain, but not the age format, which
+// would need a ciphertext of the tlock tool.
+func TestTimelockDrandBeacon(t *testing.T) {
+	pk, err := hex.DecodeString("a0b862a7527fee3a731bcb59280ab6abd62d5c0b6ea03dc4ddf6612fdfc9d01f01c31542541771903475eb1ec6615f8d0df0b8b6dce385811d6dcf8cbefb8759e5e616a3dfd054c928940766d9a5b9db91e3b697e5d70a975181e007f87fca5e")
+	require.NoError(t, err)
+	sig, err := hex.DecodeString("9544ddce2fdbe8688d6f5b4f98eed5d63eee3902e7e162050ac0f45905a55657714880adabe3c3096b92767d886567d0")
+	require.NoError(t, err)
+
+	suite := bls12381.NewBLS12381Suite()
+	suite.(*bls12381.Suite).SetDomainG1(bls12381.DefaultDomainG2())
+	public := suite.G2().Point()
+	require.NoError(t, public.UnmarshalBinary(pk))
+	n := &Network{
+		Suite:          suite,
+		PublicKey:      public,
+		SignaturesOnG1: true,
+		Period:         3 * time.Second,
+		// only compared with the chain hash of the ciphertext
+		ChainHash: []byte{1},
+	}
+	msg := []byte("decrypted with a drand beacon")
+	ciphertext := encrypt(t, n, 1, msg, true)
+	decrypted, err := decrypt(n, ciphertext, sig)
+	require.NoError(t, err)
+	require.Equal(t, msg, decrypted)
+
+	// the beacon of round 1 does not decrypt round 2
+	ciphertext = encrypt(t, n, 2, msg, false)
+	_, err = decrypt(n, ciphertext, sig)
+	require.Error(t, err)
+}
+
+func TestTimelockFormat(t *testing.T) {
+	n, beacon := newNetwork(false)
+	ciphertext := encrypt(t, n, 1000, []byte("Hello World\n"), false)
+	lines := strings.SplitN(string(ciphertext), "\n", 6)
+	require.Equal(t, "age-encryption.org/v1", lines[0])
+	require.Equal(t, "-> tlock 1000 52db9ba7", lines[1])
+	// the body is the U point on G1 and the 16 bytes V and W
+	require.Equal(t, 64, len(lines[2]))
+	require.Equal(t, b64.EncodedLen(48+32)-64, len(lines[3]))
+	require.True(t, strings.HasPrefix(lines[4], "--- "))
+	// the nonce and the one chunk of the payload
+	require.Equal(t, 16+12+16, len(lines[5]))
+
+	armored := encrypt(t, n, 1000, []byte("Hello World\n"), true)
+	require.True(t, strings.HasPrefix(string(armored), armorHeader+"\n"))
+	require.True(t, strings.HasSuffix(string(armored), "\n"+armorFooter+"\n"))
+	decrypted, err := decrypt(n, []byte(string(armored)+"\n  \n"), beacon(1000))
+	require.NoError(t, err)
+	require.Equal(t, []byte("Hello World\n"), decrypted)
+}
+
+func TestTimelockInvalid(t *testing.T) {
+	n, beacon := newNetwork(false)
+	msg := []byte("Hello World\n")
+	ciphertext := encrypt(t, n, 1000, msg, false)
+
+	// beacon of another round
+	_, err := decrypt(n, ciphertext, beacon(999))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid beacon for round 1000")
+	// beacon of another network
+	other, otherBeacon := newNetwork(false)
+	_, err = decrypt(n, ciphertext, otherBeacon(1000))
+	require.Error(t, err)
+	// other chain
+	other.PublicKey = n.PublicKey
+	other.ChainHash = []byte{1}
+	_, err = decrypt(other, ciphertext, beacon(1000))
+	require.Error(t, err)
+
+	// tampered header
+	s := string(ciphertext)
+	for _, tampered := range []string{
+		strings.Replace(s, "tlock 1000", "tlock 01000", 1),
+		strings.Replace(s, "tlock 1000", "tlock 1000 extra", 1),
+		strings.Replace(s, "-> tlock 1000 52db9ba7\n", "-> tlock 1000 52db9ba7\n-> X25519 abc\nAAAA\n", 1),
+		strings.Replace(s, "age-encryption.org/v1\n", "age-encryption.org/v1\n-> X25519 abc\nAAAA\n", 1),
+		strings.Replace(s, "age-encryption.org/v1", "age-encryption.org/v2", 1),
+		strings.Replace(s, "tlock", "other", 1),
+		s[:len(s)-len(msg)-16-1],
+	} {
+		_, err = decrypt(n, []byte(tampered), beacon(1000))
+		require.Error(t, err, tampered)
+	}
+
+	// tampered payload
+	for _, tampered := range [][]byte{
+		append(append([]byte(nil), ciphertext...), 0),
+		ciphertext[:len(ciphertext)-1],
+	} {
+		_, err = decrypt(n, tampered, beacon(1000))
+		require.Error(t, err)
+	}
+
+	_, err = (&Network{}).Encrypt(io.Discard, 1)
+	require.Error(t, err)
+	_, err = n.Encrypt(io.Discard, 0)
+	require.Error(t, err)
+}
+
+func TestArmor(t *testing.T) {
+	for _, size := range []int{0, 1, 47, 48, 49, 96, 1000} {
+		msg := random.Bits(uint(size*8), false, random.New())
+		var b bytes.Buffer
+		w := NewArmorWriter(&b)
+		_, err := w.Write(msg)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+			require.LessOrEqual(t, len(line), columnsPerLine, fmt.Sprint(size))
+			require.NotEmpty(t, line)
+		}
+
+		decoded, err := io.ReadAll(NewArmorReader(bytes.NewReader(b.Bytes())))
+		require.NoError(t, err)
+		require.Equal(t, msg, decoded)
+		crlf := strings.ReplaceAll(b.String(), "\n", "\r\n")
+		decoded, err = io.ReadAll(NewArmorReader(strings.NewReader(crlf)))
+		require.NoError(t, err)
+		require.Equal(t, msg, decoded)
+	}
+
+	for _, invalid := range []string{
+		"",
+		armorHeader + "\n",
+		armorHeader + "\nAAAA\n",
+		armorHeader + "\nAAA\n" + armorFooter + "\n",
+		armorHeader + "\nAA==\nAAAA\n" + armorFooter + "\n",
+		armorHeader + "\nAAAA\n" + armorFooter + "\ndata\n",
+		"-----BEGIN AGE FILE-----\nAAAA\n" + armorFooter + "\n",
+	} {
+		_, err := io.ReadAll(NewArmorReader(strings.NewReader(invalid)))
+		require.Error(t, err, invalid)
+	}
+}
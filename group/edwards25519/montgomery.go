@@ -0,0 +1,59 @@
+package edwards25519
+
+import (
+	"errors"
+
+	"github.com/drand/kyber"
+)
+
+// ToMontgomery returns the u-coordinate of the point of Curve25519 that is
+// birationally equivalent to the point P, as the 32-byte little-endian
+// encoding used by X25519 (RFC 7748). It maps (x, y) to u = (1+y)/(1-y), so
+// that P and -P share the same u-coordinate, and the neutral element is
+// mapped to u = 0.
+func ToMontgomery(P kyber.Point) []byte {
+	var recip, y, one, num, den, u fieldElement
+	var b [32]byte
+
+	ge := &P.(*point).ge
+	feInvert(&recip, &ge.Z)
+	feMul(&y, &ge.Y, &recip)
+	feOne(&one)
+	feAdd(&num, &one, &y)
+	feSub(&den, &one, &y)
+	feInvert(&den, &den)
+	feMul(&u, &num, &den)
+	feToBytes(&b, &u)
+	return b[:]
+}
+
+// FromMontgomery returns a point of Ed25519 whose u-coordinate on Curve25519
+// is the 32-byte little-endian u, which is the inverse of ToMontgomery up to
+// the sign of the point. As in X25519, the most significant bit of u is
+// ignored. It returns an error if u is not the u-coordinate of a point of
+// Curve25519, e.g. if it lies on its quadratic twist.
+func FromMontgomery(u []byte) (kyber.Point, error) {
+	var fu, one, num, den, y fieldElement
+	var b [32]byte
+
+	if len(u) != 32 {
+		return nil, errors.New("invalid Curve25519 u-coordinate length")
+	}
+	feFromBytes(&fu, u)
+	feOne(&one)
+	feSub(&num, &fu, &one)
+	feAdd(&den, &fu, &one)
+	if feIsNonZero(&den) == 0 {
+		// u = -1 has no equivalent point
+		return nil, errors.New("invalid Curve25519 u-coordinate")
+	}
+	feInvert(&den, &den)
+	feMul(&y, &num, &den)
+	feToBytes(&b, &y)
+
+	P := new(point)
+	if !P.ge.FromBytes(b[:]) {
+		return nil, errors.New("invalid Curve25519 u-coordinate")
+	}
+	return P, nil
+}
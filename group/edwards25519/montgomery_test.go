@@ -0,0 +1,55 @@
+package edwards25519
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestMontgomery(t *testing.T) {
+	require.Equal(t, curve25519.Basepoint, ToMontgomery(new(point).Base()))
+	require.Equal(t, make([]byte, 32), ToMontgomery(new(point).Null()))
+
+	for i := 0; i < 10; i++ {
+		var k [32]byte
+		_, err := rand.Read(k[:])
+		require.NoError(t, err)
+		k[0] &= 248
+		k[31] &= 127
+		k[31] |= 64
+
+		expected, err := curve25519.X25519(k[:], curve25519.Basepoint)
+		require.NoError(t, err)
+		P := new(point).Mul(new(scalar).SetBytes(k[:]), nil)
+		require.Equal(t, expected, ToMontgomery(P))
+
+		Q, err := FromMontgomery(expected)
+		require.NoError(t, err)
+		require.True(t, Q.Equal(P) || Q.Equal(new(point).Neg(P)))
+	}
+
+	// about half of the u-coordinates lie on the twist
+	invalid := 0
+	for i := 0; i < 32; i++ {
+		var u [32]byte
+		_, err := rand.Read(u[:])
+		require.NoError(t, err)
+		if _, err := FromMontgomery(u[:]); err != nil {
+			invalid++
+		}
+	}
+	require.NotZero(t, invalid)
+
+	minusOne := make([]byte, 32)
+	minusOne[0] = 0xec
+	for i := 1; i < 31; i++ {
+		minusOne[i] = 0xff
+	}
+	minusOne[31] = 0x7f
+	_, err := FromMontgomery(minusOne)
+	require.Error(t, err)
+	_, err = FromMontgomery(minusOne[1:])
+	require.Error(t, err)
+}
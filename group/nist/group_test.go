@@ -14,6 +14,10 @@ var testP256 = NewBlakeSHA256P256()
 
 func TestP256(t *testing.T) { test.SuiteTest(t, testP256) }
 
+var testP384 = NewBlakeSHA384P384()
+
+func TestP384(t *testing.T) { test.SuiteTest(t, testP384) }
+
 func TestSetBytesBE(t *testing.T) {
 	s := testP256.Scalar()
 	s.SetBytes([]byte{0, 1, 2, 3})
@@ -0,0 +1,34 @@
+package nist
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// P384 implements the kyber.Group interface
+// for the NIST P-384 elliptic curve,
+// based on Go's native elliptic curve library.
+type p384 struct {
+	curve
+}
+
+func (curve *p384) String() string {
+	return "P384"
+}
+
+// Modular square root for P-384 curve: since p = 3 mod 4,
+// sqrt(c) = c^((p+1)/4) mod p.
+func (curve *p384) sqrt(c *big.Int) *big.Int {
+	m := curve.p.P
+	e := new(big.Int).Add(m, big.NewInt(1))
+	e.Rsh(e, 2)
+	return new(big.Int).Exp(c, e, m)
+}
+
+// Init initializes standard Curve instances
+func (curve *p384) Init() curve {
+	curve.curve.Curve = elliptic.P384()
+	curve.p = curve.Params()
+	curve.curveOps = curve
+	return curve.curve
+}
@@ -3,6 +3,7 @@ package nist
 import (
 	"crypto/cipher"
 	"crypto/sha256"
+	"crypto/sha512"
 	"hash"
 	"io"
 	"reflect"
@@ -60,3 +61,48 @@ func NewBlakeSHA256P256() *Suite128 {
 	suite.p256.Init()
 	return suite
 }
+
+// Suite192 is the suite for P384 curve
+type Suite192 struct {
+	p384
+}
+
+// Hash returns the instance associated with the suite
+func (s *Suite192) Hash() hash.Hash {
+	return sha512.New384()
+}
+
+// XOF creates the XOF associated with the suite
+func (s *Suite192) XOF(key []byte) kyber.XOF {
+	return blake2xb.New(key)
+}
+
+// RandomStream returns a cipher.Stream that returns a key stream
+// from crypto/rand.
+func (s *Suite192) RandomStream() cipher.Stream {
+	return random.New()
+}
+
+func (s *Suite192) Read(r io.Reader, objs ...interface{}) error {
+	return fixbuf.Read(r, s, objs)
+}
+
+func (s *Suite192) Write(w io.Writer, objs ...interface{}) error {
+	return fixbuf.Write(w, objs)
+}
+
+// New implements the kyber.encoding interface
+func (s *Suite192) New(t reflect.Type) interface{} {
+	return marshalling.GroupNew(s, t)
+}
+
+// NewBlakeSHA384P384 returns a cipher suite based on package
+// github.com/drand/kyber/xof/blake2xb, SHA-384, and the NIST P-384
+// elliptic curve. It returns random streams from Go's crypto/rand.
+//
+// Its scalars are big-endian like those of NewBlakeSHA256P256.
+func NewBlakeSHA384P384() *Suite192 {
+	suite := new(Suite192)
+	suite.p384.Init()
+	return suite
+}
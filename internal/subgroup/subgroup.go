@@ -0,0 +1,18 @@
+// Package subgroup checks that decoded points belong to the prime order
+// subgroup of their group, for the packages that must reject the points with
+// a small order component on curves with a cofactor.
+package subgroup
+
+import "github.com/drand/kyber"
+
+// Contains returns true if the point belongs to the subgroup of g generated
+// by the base point. Groups implementing kyber.SubGroupElement check it
+// themselves, otherwise the point must satisfy (q-1)p + p = 0 where q is the
+// order of the scalars.
+func Contains(g kyber.Group, p kyber.Point) bool {
+	if sub, ok := p.(kyber.SubGroupElement); ok {
+		return sub.IsInCorrectGroup()
+	}
+	q := g.Point().Mul(g.Scalar().SetInt64(-1), p)
+	return q.Add(q, p).Equal(g.Point().Null())
+}
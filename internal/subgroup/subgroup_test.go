@@ -0,0 +1,25 @@
+package subgroup
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContains(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	p := suite.Point().Pick(random.New())
+	require.True(t, Contains(suite, p))
+	require.True(t, Contains(suite, suite.Point().Null()))
+
+	// a point of order 8
+	torsion := suite.Point()
+	b, err := hex.DecodeString("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a")
+	require.NoError(t, err)
+	require.NoError(t, torsion.UnmarshalBinary(b))
+	require.False(t, Contains(suite, torsion))
+	require.False(t, Contains(suite, p.Add(p, torsion)))
+}
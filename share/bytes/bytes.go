@@ -0,0 +1,327 @@
+// Package bytes implements Shamir secret sharing of arbitrary byte strings,
+// such as seed phrases or symmetric keys, on top of share.PriPoly. The secret
+// is cut into chunks small enough to be embedded in scalars of the chosen
+// group, each chunk is shared with its own polynomial, and all the resulting
+// private shares of a participant are packed into a single self-describing
+// blob. A blob carries its index, the threshold, the name of the group, an
+// identifier common to all blobs of the same secret and a checksum, so that
+// Recover can detect blobs that were mismatched or corrupted.
+package bytes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/util/random"
+)
+
+// Version is the version of the blob encoding produced by Split.
+const Version = 1
+
+// idLen is the length of the identifier common to all blobs of a secret.
+const idLen = 16
+
+// Share is the decoded form of a share blob.
+type Share struct {
+	ID        [idLen]byte    // Identifier common to all shares of a secret
+	Index     int            // Index of the share
+	Threshold int            // Number of shares needed to recover the secret
+	Group     string         // Name of the group of the scalars
+	Length    int            // Length of the shared secret in bytes
+	Values    []kyber.Scalar // Private shares of each chunk of the secret
+}
+
+// Split shares the given secret among n participants such that any t of them
+// can recover it. It returns one blob per participant.
+func Split(g kyber.Group, secret []byte, t, n int, rand cipher.Stream) ([][]byte, error) {
+	if t < 1 || n < t {
+		return nil, errors.New("bytes: invalid threshold")
+	}
+	if uint64(n) > math.MaxUint32 || uint64(len(secret)) > math.MaxUint32 || len(g.String()) > math.MaxUint8 {
+		return nil, errors.New("bytes: parameters too large")
+	}
+	size, err := chunkSize(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var id [idLen]byte
+	random.Bytes(id[:], rand)
+
+	nChunks := (len(secret) + size - 1) / size
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{
+			ID:        id,
+			Index:     i,
+			Threshold: t,
+			Group:     g.String(),
+			Length:    len(secret),
+			Values:    make([]kyber.Scalar, nChunks),
+		}
+	}
+	for c := 0; c < nChunks; c++ {
+		end := (c + 1) * size
+		if end > len(secret) {
+			end = len(secret)
+		}
+		s := embed(g, secret[c*size:end])
+		poly := share.NewPriPoly(g, t, s, rand)
+		for i, ps := range poly.Shares(n) {
+			shares[i].Values[c] = ps.V
+		}
+	}
+
+	blobs := make([][]byte, n)
+	for i, s := range shares {
+		if blobs[i], err = s.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+// Recover reconstructs the secret from a list of share blobs created by
+// Split over the group g. It returns an error if fewer blobs than the
+// threshold are given, if a blob is corrupted, or if the blobs do not all
+// belong to the same secret. When more blobs than the threshold are given,
+// all of them must be consistent with the recovered secret.
+func Recover(g kyber.Group, blobs [][]byte) ([]byte, error) {
+	if len(blobs) == 0 {
+		return nil, errors.New("bytes: no share given")
+	}
+	shares := make([]*Share, len(blobs))
+	for i, b := range blobs {
+		s := new(Share)
+		if err := s.unmarshal(g, b); err != nil {
+			return nil, fmt.Errorf("bytes: share %d: %w", i, err)
+		}
+		shares[i] = s
+	}
+
+	first := shares[0]
+	seen := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if subtle.ConstantTimeCompare(s.ID[:], first.ID[:]) != 1 ||
+			s.Threshold != first.Threshold || s.Length != first.Length {
+			return nil, errors.New("bytes: shares belong to different secrets")
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("bytes: duplicate share index %d", s.Index)
+		}
+		seen[s.Index] = true
+	}
+	t := first.Threshold
+	if len(shares) < t {
+		return nil, errors.New("bytes: not enough shares to recover secret")
+	}
+
+	size, err := chunkSize(g)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 0, first.Length)
+	for c := range first.Values {
+		priShares := make([]*share.PriShare, len(shares))
+		for i, s := range shares {
+			priShares[i] = &share.PriShare{I: s.Index, V: s.Values[c]}
+		}
+		poly, err := share.RecoverPriPoly(g, priShares, t, len(shares))
+		if err != nil {
+			return nil, err
+		}
+		for _, ps := range priShares {
+			if !poly.Eval(ps.I).V.Equal(ps.V) {
+				return nil, fmt.Errorf("bytes: share %d is inconsistent", ps.I)
+			}
+		}
+		chunk, err := extract(poly.Secret())
+		if err != nil {
+			return nil, err
+		}
+		n := size
+		if rem := first.Length - len(secret); rem < size {
+			n = rem
+		}
+		secret = append(secret, chunk[:n]...)
+	}
+	return secret, nil
+}
+
+// MarshalBinary encodes the share as a blob.
+func (s *Share) MarshalBinary() ([]byte, error) {
+	buf := []byte{Version}
+	buf = append(buf, s.ID[:]...)
+	buf = appendUint32(buf, uint32(s.Index))
+	buf = appendUint32(buf, uint32(s.Threshold))
+	buf = appendUint32(buf, uint32(s.Length))
+	buf = append(buf, byte(len(s.Group)))
+	buf = append(buf, s.Group...)
+	buf = appendUint32(buf, uint32(len(s.Values)))
+	for _, v := range s.Values {
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b...)
+	}
+	return appendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// Unmarshal decodes a blob created by Split. The scalars are decoded in the
+// group g, which must match the group of the blob.
+func Unmarshal(g kyber.Group, buf []byte) (*Share, error) {
+	s := new(Share)
+	if err := s.unmarshal(g, buf); err != nil {
+		return nil, fmt.Errorf("bytes: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Share) unmarshal(g kyber.Group, buf []byte) error {
+	if len(buf) < 4 {
+		return errors.New("blob too short")
+	}
+	body, sum := buf[:len(buf)-4], binary.BigEndian.Uint32(buf[len(buf)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return errors.New("invalid checksum")
+	}
+	r := reader(body)
+	version, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if version != Version {
+		return fmt.Errorf("unsupported version %d", version)
+	}
+	id, err := r.next(idLen)
+	if err != nil {
+		return err
+	}
+	copy(s.ID[:], id)
+	var header [3]uint32
+	for i := range header {
+		if header[i], err = r.uint32(); err != nil {
+			return err
+		}
+	}
+	if header[0] > math.MaxInt32 || header[1] > math.MaxInt32 || header[2] > math.MaxInt32 {
+		return errors.New("invalid header")
+	}
+	s.Index, s.Threshold, s.Length = int(header[0]), int(header[1]), int(header[2])
+	if s.Threshold < 1 {
+		return errors.New("invalid threshold")
+	}
+	nameLen, err := r.byte()
+	if err != nil {
+		return err
+	}
+	name, err := r.next(int(nameLen))
+	if err != nil {
+		return err
+	}
+	s.Group = string(name)
+	if s.Group != g.String() {
+		return fmt.Errorf("share over group %q, expected %q", s.Group, g.String())
+	}
+	count, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	size, err := chunkSize(g)
+	if err != nil {
+		return err
+	}
+	if int64(count) != (int64(s.Length)+int64(size)-1)/int64(size) {
+		return errors.New("invalid number of chunks")
+	}
+	scalarLen := g.Scalar().MarshalSize()
+	if len(r) != int(count)*scalarLen {
+		return errors.New("invalid length")
+	}
+	s.Values = make([]kyber.Scalar, count)
+	for i := range s.Values {
+		b, _ := r.next(scalarLen)
+		s.Values[i] = g.Scalar()
+		if err := s.Values[i].UnmarshalBinary(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkSize returns the number of secret bytes embedded in each scalar. The
+// chunk is surrounded by a zero byte on each side so that it stays below the
+// group order whatever the endianness of the scalar encoding.
+func chunkSize(g kyber.Group) (int, error) {
+	l := g.Scalar().MarshalSize()
+	if l < 3 {
+		return 0, errors.New("bytes: scalars too small")
+	}
+	probe := make([]byte, l)
+	for i := 1; i < l-1; i++ {
+		probe[i] = byte(i)
+	}
+	b, err := g.Scalar().SetBytes(probe).MarshalBinary()
+	if err != nil || subtle.ConstantTimeCompare(b, probe) != 1 {
+		return 0, errors.New("bytes: group does not support embedding bytes in scalars")
+	}
+	return l - 2, nil
+}
+
+func embed(g kyber.Group, chunk []byte) kyber.Scalar {
+	buf := make([]byte, g.Scalar().MarshalSize())
+	copy(buf[1:], chunk)
+	return g.Scalar().SetBytes(buf)
+}
+
+func extract(s kyber.Scalar) ([]byte, error) {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if b[0] != 0 || b[len(b)-1] != 0 {
+		return nil, errors.New("bytes: recovered an invalid chunk")
+	}
+	return b[1 : len(b)-1], nil
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+type reader []byte
+
+func (r *reader) next(n int) ([]byte, error) {
+	if len(*r) < n {
+		return nil, errors.New("blob too short")
+	}
+	b := (*r)[:n]
+	*r = (*r)[n:]
+	return b, nil
+}
+
+func (r *reader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *reader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
@@ -0,0 +1,78 @@
+package bytes
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing/circl_bls12381"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRecover(t *testing.T) {
+	groups := []kyber.Group{
+		edwards25519.NewBlakeSHA256Ed25519(),
+		bn256.NewSuiteG1(),
+		circl_bls12381.NewSuiteBLS12381().G1(),
+	}
+	for _, g := range groups {
+		for _, l := range []int{0, 1, 29, 30, 31, 100} {
+			secret := make([]byte, l)
+			random.Bytes(secret, random.New())
+			blobs, err := Split(g, secret, 3, 5, random.New())
+			require.NoError(t, err, g.String())
+			require.Len(t, blobs, 5)
+
+			recovered, err := Recover(g, blobs[2:])
+			require.NoError(t, err, g.String())
+			require.Equal(t, secret, recovered)
+
+			recovered, err = Recover(g, blobs)
+			require.NoError(t, err, g.String())
+			require.Equal(t, secret, recovered)
+
+			_, err = Recover(g, blobs[:2])
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestRecoverInvalid(t *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	secret := []byte("correct horse battery staple")
+	blobs, err := Split(g, secret, 2, 4, random.New())
+	require.NoError(t, err)
+	other, err := Split(g, secret, 2, 4, random.New())
+	require.NoError(t, err)
+
+	// corrupted blob
+	corrupted := append([]byte{}, blobs[1]...)
+	corrupted[len(corrupted)/2] ^= 1
+	_, err = Recover(g, [][]byte{blobs[0], corrupted})
+	require.Error(t, err)
+
+	// blobs of different splits
+	_, err = Recover(g, [][]byte{blobs[0], other[1]})
+	require.Error(t, err)
+
+	// duplicated blob
+	_, err = Recover(g, [][]byte{blobs[0], blobs[0]})
+	require.Error(t, err)
+
+	// wrong group
+	_, err = Recover(bn256.NewSuiteG1(), blobs[:2])
+	require.Error(t, err)
+
+	// altered value with a valid checksum is detected with extra shares
+	_, err = Unmarshal(g, blobs[3][1:])
+	require.Error(t, err)
+	s, err := Unmarshal(g, blobs[3])
+	require.NoError(t, err)
+	s.Values[0] = g.Scalar().Pick(random.New())
+	altered, err := s.MarshalBinary()
+	require.NoError(t, err)
+	_, err = Recover(g, [][]byte{blobs[0], blobs[1], altered})
+	require.Error(t, err)
+}
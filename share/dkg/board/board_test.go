@@ -0,0 +1,169 @@
+package board
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/share/dkg"
+	"github.com/drand/kyber/sign/schnorr"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+var suite = edwards25519.NewBlakeSHA256Ed25519()
+
+type testNode struct {
+	priv  kyber.Scalar
+	node  dkg.Node
+	board func() dkg.Board
+	delay time.Duration
+}
+
+func newTestNodes(n int) []*testNode {
+	nodes := make([]*testNode, n)
+	for i := range nodes {
+		priv := suite.Scalar().Pick(random.New())
+		nodes[i] = &testNode{
+			priv: priv,
+			node: dkg.Node{Index: uint32(i), Public: suite.Point().Mul(priv, nil)},
+		}
+	}
+	return nodes
+}
+
+// runProtocol runs the DKG over the boards of the nodes, each node starting
+// after its delay, and checks that all the nodes end up with the same
+// distributed key.
+func runProtocol(t *testing.T, nodes []*testNode, mode dkg.Mode, period time.Duration) {
+	var list []dkg.Node
+	for _, n := range nodes {
+		list = append(list, n.node)
+	}
+	nonce := dkg.GetNonce()
+	results := make(chan dkg.OptionResult, len(nodes))
+	for _, n := range nodes {
+		go func(n *testNode) {
+			time.Sleep(n.delay)
+			conf := &dkg.Config{
+				Suite:     suite,
+				Longterm:  n.priv,
+				NewNodes:  list,
+				Threshold: len(nodes)/2 + 1,
+				Auth:      schnorr.NewScheme(suite),
+				Nonce:     nonce,
+				Mode:      mode,
+			}
+			phaser := dkg.NewTimePhaser(period)
+			proto, err := dkg.NewProtocol(conf, n.board(), phaser, false)
+			if err != nil {
+				results <- dkg.OptionResult{Error: err}
+				return
+			}
+			go phaser.StartPhases(conf.Phases()...)
+			results <- <-proto.WaitEnd()
+		}(n)
+	}
+	var key kyber.Point
+	for range nodes {
+		select {
+		case res := <-results:
+			require.NoError(t, res.Error)
+			require.Len(t, res.Result.QUAL, len(nodes))
+			if key == nil {
+				key = res.Result.Key.Public()
+			}
+			require.True(t, key.Equal(res.Result.Key.Public()))
+		case <-time.After(30 * period):
+			t.Fatal("dkg did not finish")
+		}
+	}
+}
+
+func TestMemoryBoard(t *testing.T) {
+	for _, mode := range []dkg.Mode{dkg.JointFeldman, dkg.GJKR} {
+		t.Run(mode.String(), func(t *testing.T) {
+			nodes := newTestNodes(5)
+			bus := NewBus()
+			for _, n := range nodes {
+				b := bus.NewBoard()
+				defer b.Close()
+				n.board = func() dkg.Board { return b }
+			}
+			runProtocol(t, nodes, mode, 500*time.Millisecond)
+		})
+	}
+}
+
+func TestTCPBoard(t *testing.T) {
+	n := 4
+	nodes := newTestNodes(n)
+	listeners := make([]net.Listener, n)
+	peers := make([]Peer, n)
+	for i := range nodes {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listeners[i] = l
+		peers[i] = Peer{Public: nodes[i].node.Public, Address: l.Addr().String()}
+	}
+	// the last node is not listening when the others send their deals, so
+	// they have to retry until it comes up
+	require.NoError(t, listeners[n-1].Close())
+	nodes[n-1].delay = 300 * time.Millisecond
+
+	boards := make(chan *TCPBoard, n)
+	defer func() {
+		close(boards)
+		for b := range boards {
+			b.Close()
+		}
+	}()
+	for i := range nodes {
+		i := i
+		nodes[i].board = func() dkg.Board {
+			conf := &TCPConfig{
+				Suite:       suite,
+				Auth:        schnorr.NewScheme(suite),
+				Longterm:    nodes[i].priv,
+				Peers:       peers,
+				RetryPeriod: 20 * time.Millisecond,
+			}
+			if i == n-1 {
+				conf.ListenAddr = peers[i].Address
+			} else {
+				conf.Listener = listeners[i]
+			}
+			b, err := NewTCPBoard(conf)
+			if err != nil {
+				panic(err)
+			}
+			boards <- b
+			return b
+		}
+	}
+	runProtocol(t, nodes, dkg.JointFeldman, time.Second)
+}
+
+func TestTCPBoardUnknownPeer(t *testing.T) {
+	nodes := newTestNodes(2)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b, err := NewTCPBoard(&TCPConfig{
+		Suite:    suite,
+		Auth:     schnorr.NewScheme(suite),
+		Longterm: nodes[0].priv,
+		Peers:    []Peer{{Public: nodes[0].node.Public, Address: l.Addr().String()}},
+		Listener: l,
+	})
+	require.NoError(t, err)
+	defer b.Close()
+
+	// node 1 is not a peer of node 0, so its handshake is rejected
+	intruder := &TCPBoard{c: TCPConfig{Suite: suite, Auth: schnorr.NewScheme(suite), Longterm: nodes[1].priv, Timeout: time.Second, MaxFrameSize: DefaultMaxFrameSize}}
+	intruder.pubBuff, _ = nodes[1].node.Public.MarshalBinary()
+	peerBuff, _ := nodes[0].node.Public.MarshalBinary()
+	_, err = intruder.dial(&tcpPeer{Peer: Peer{Public: nodes[0].node.Public, Address: l.Addr().String()}, pubBuff: peerBuff})
+	require.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package board
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errShortBuffer = errors.New("board: message too short")
+
+// writer writes the length-prefixed fields of the handshake messages.
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) bytes(b []byte) {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(b)))
+	w.buf.Write(l[:])
+	w.buf.Write(b)
+}
+
+// reader reads the fields written by writer. The first error is kept and all
+// the subsequent reads return nil.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < 4 {
+		r.err = errShortBuffer
+		return nil
+	}
+	n := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	if uint64(len(r.buf)) < uint64(n) {
+		r.err = errShortBuffer
+		return nil
+	}
+	b := append([]byte(nil), r.buf[:n]...)
+	r.buf = r.buf[n:]
+	return b
+}
@@ -0,0 +1,141 @@
+package board
+
+import (
+	"sync"
+
+	"github.com/drand/kyber/share/dkg"
+)
+
+// inbox buffers the packets received by a board and dispatches them on the
+// channels read by the dkg.Protocol. Packets delivered locally never block,
+// while packets received from the network wait when more than max packets are
+// pending, so that a slow consumer pushes back on the network.
+type inbox struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []dkg.Packet
+	max    int
+	closed bool
+	done   chan struct{}
+
+	deals    chan dkg.DealBundle
+	resps    chan dkg.ResponseBundle
+	justifs  chan dkg.JustificationBundle
+	extracts chan dkg.ExtractionBundle
+	recons   chan dkg.ReconstructionBundle
+}
+
+func newInbox(max int) *inbox {
+	i := &inbox{
+		max:      max,
+		done:     make(chan struct{}),
+		deals:    make(chan dkg.DealBundle),
+		resps:    make(chan dkg.ResponseBundle),
+		justifs:  make(chan dkg.JustificationBundle),
+		extracts: make(chan dkg.ExtractionBundle),
+		recons:   make(chan dkg.ReconstructionBundle),
+	}
+	i.cond = sync.NewCond(&i.mu)
+	go i.run()
+	return i
+}
+
+// push queues the packet. If wait is true, it waits until there is room in
+// the queue. It returns false if the inbox is closed.
+func (i *inbox) push(p dkg.Packet, wait bool) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for wait && !i.closed && len(i.queue) >= i.max {
+		i.cond.Wait()
+	}
+	if i.closed {
+		return false
+	}
+	i.queue = append(i.queue, p)
+	i.cond.Broadcast()
+	return true
+}
+
+func (i *inbox) close() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.closed {
+		return
+	}
+	i.closed = true
+	close(i.done)
+	i.cond.Broadcast()
+}
+
+func (i *inbox) run() {
+	for {
+		i.mu.Lock()
+		for !i.closed && len(i.queue) == 0 {
+			i.cond.Wait()
+		}
+		if i.closed {
+			i.mu.Unlock()
+			return
+		}
+		p := i.queue[0]
+		i.queue[0] = nil
+		i.queue = i.queue[1:]
+		i.cond.Broadcast()
+		i.mu.Unlock()
+
+		switch b := p.(type) {
+		case *dkg.DealBundle:
+			select {
+			case i.deals <- *b:
+			case <-i.done:
+			}
+		case *dkg.ResponseBundle:
+			select {
+			case i.resps <- *b:
+			case <-i.done:
+			}
+		case *dkg.JustificationBundle:
+			select {
+			case i.justifs <- *b:
+			case <-i.done:
+			}
+		case *dkg.ExtractionBundle:
+			select {
+			case i.extracts <- *b:
+			case <-i.done:
+			}
+		case *dkg.ReconstructionBundle:
+			select {
+			case i.recons <- *b:
+			case <-i.done:
+			}
+		}
+	}
+}
+
+// clonePacket returns a copy of the packet that does not share its slices
+// with p, since the dkg package sorts some of them in place when hashing.
+func clonePacket(p dkg.Packet) dkg.Packet {
+	switch b := p.(type) {
+	case *dkg.DealBundle:
+		c := *b
+		c.Deals = append([]dkg.Deal(nil), b.Deals...)
+		return &c
+	case *dkg.ResponseBundle:
+		c := *b
+		c.Responses = append([]dkg.Response(nil), b.Responses...)
+		return &c
+	case *dkg.JustificationBundle:
+		c := *b
+		c.Justifications = append([]dkg.Justification(nil), b.Justifications...)
+		return &c
+	case *dkg.ExtractionBundle:
+		c := *b
+		return &c
+	case *dkg.ReconstructionBundle:
+		c := *b
+		c.Shares = append([]dkg.ReconstructionShare(nil), b.Shares...)
+		return &c
+	}
+	return p
+}
@@ -0,0 +1,111 @@
+// Package board provides implementations of the dkg.Board interface, which
+// carries the packets of the DKG protocol between the nodes: an in-memory
+// broadcast bus, for embedding the protocol in a single process and for
+// testing, and a TCP transport authenticating the nodes with their longterm
+// keys. Both implement dkg.GJKRBoard and can be used directly with
+// dkg.Protocol.
+package board
+
+import (
+	"sync"
+
+	"github.com/drand/kyber/share/dkg"
+)
+
+// Bus is an in-memory broadcast channel: every packet pushed on a board of
+// the bus is delivered to all the boards of the bus, including the sender.
+type Bus struct {
+	mu     sync.RWMutex
+	boards []*MemoryBoard
+}
+
+// NewBus returns an empty bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// NewBoard returns a new board connected to the bus.
+func (b *Bus) NewBoard() *MemoryBoard {
+	m := &MemoryBoard{
+		bus: b,
+		in:  newInbox(0),
+	}
+	b.mu.Lock()
+	b.boards = append(b.boards, m)
+	b.mu.Unlock()
+	return m
+}
+
+func (b *Bus) broadcast(p dkg.Packet) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, m := range b.boards {
+		m.in.push(clonePacket(p), false)
+	}
+}
+
+func (b *Bus) remove(m *MemoryBoard) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, o := range b.boards {
+		if o == m {
+			b.boards = append(b.boards[:i], b.boards[i+1:]...)
+			return
+		}
+	}
+}
+
+var _ dkg.GJKRBoard = (*MemoryBoard)(nil)
+
+// MemoryBoard is a board connected to a Bus.
+type MemoryBoard struct {
+	bus *Bus
+	in  *inbox
+}
+
+// Close disconnects the board from the bus.
+func (m *MemoryBoard) Close() error {
+	m.bus.remove(m)
+	m.in.close()
+	return nil
+}
+
+func (m *MemoryBoard) PushDeals(d *dkg.DealBundle) {
+	m.bus.broadcast(d)
+}
+
+func (m *MemoryBoard) IncomingDeal() <-chan dkg.DealBundle {
+	return m.in.deals
+}
+
+func (m *MemoryBoard) PushResponses(r *dkg.ResponseBundle) {
+	m.bus.broadcast(r)
+}
+
+func (m *MemoryBoard) IncomingResponse() <-chan dkg.ResponseBundle {
+	return m.in.resps
+}
+
+func (m *MemoryBoard) PushJustifications(j *dkg.JustificationBundle) {
+	m.bus.broadcast(j)
+}
+
+func (m *MemoryBoard) IncomingJustification() <-chan dkg.JustificationBundle {
+	return m.in.justifs
+}
+
+func (m *MemoryBoard) PushExtractions(e *dkg.ExtractionBundle) {
+	m.bus.broadcast(e)
+}
+
+func (m *MemoryBoard) IncomingExtraction() <-chan dkg.ExtractionBundle {
+	return m.in.extracts
+}
+
+func (m *MemoryBoard) PushReconstructions(r *dkg.ReconstructionBundle) {
+	m.bus.broadcast(r)
+}
+
+func (m *MemoryBoard) IncomingReconstruction() <-chan dkg.ReconstructionBundle {
+	return m.in.recons
+}
@@ -0,0 +1,532 @@
+package board
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/share/dkg"
+	"github.com/drand/kyber/sign"
+)
+
+// Default values of the optional fields of TCPConfig.
+const (
+	DefaultQueueSize      = 64
+	DefaultMaxFrameSize   = 16 << 20
+	DefaultRetryPeriod    = 100 * time.Millisecond
+	DefaultMaxRetryPeriod = 5 * time.Second
+	DefaultTimeout        = 10 * time.Second
+)
+
+const challengeSize = 32
+
+var handshakeLabel = []byte("drand/kyber/share/dkg/board: tcp handshake v1")
+
+// Peer is a node of the DKG reachable over TCP.
+type Peer struct {
+	// Public is the longterm key of the node, as in the dkg.Node lists.
+	Public kyber.Point
+	// Address is the TCP address the node listens on.
+	Address string
+}
+
+// TCPConfig holds the information needed to run a TCPBoard.
+type TCPConfig struct {
+	Suite dkg.Suite
+
+	// Auth is the signature scheme used to authenticate the nodes with their
+	// longterm keys when they connect.
+	Auth sign.Scheme
+
+	// Longterm is the longterm secret key of this node.
+	Longterm kyber.Scalar
+
+	// Peers are all the nodes of the DKG, i.e. the union of the old and new
+	// nodes in a resharing. This node may be part of the list, in which case
+	// it is ignored.
+	Peers []Peer
+
+	// ListenAddr is the address to listen on for incoming connections. It is
+	// ignored if Listener is set.
+	ListenAddr string
+
+	// Listener is an optional listener to accept incoming connections from.
+	Listener net.Listener
+
+	// QueueSize is the maximum number of packets waiting to be sent to each
+	// peer, and waiting to be read by the protocol. Pushing a packet blocks
+	// while the queue of a peer is full, and reading from a peer stops while
+	// the incoming queue is full.
+	QueueSize int
+
+	// MaxFrameSize is the maximum size of a packet on the wire.
+	MaxFrameSize int
+
+	// RetryPeriod is the delay before reconnecting to a peer after a failure.
+	// It doubles after each failure, up to MaxRetryPeriod.
+	RetryPeriod    time.Duration
+	MaxRetryPeriod time.Duration
+
+	// Timeout bounds the duration of the handshake and of each write.
+	Timeout time.Duration
+
+	// Log is an optional logger of the connection errors.
+	Log dkg.Logger
+}
+
+var _ dkg.GJKRBoard = (*TCPBoard)(nil)
+
+// TCPBoard is a board broadcasting the packets to each peer over a TCP
+// connection. Packets are encoded with dkg.EncodePacket and sent as frames
+// prefixed by their length over outgoing connections, and received over
+// incoming connections. Both sides of a connection authenticate each other
+// with their longterm key during a handshake, and connections are
+// reestablished on failure.
+type TCPBoard struct {
+	c        TCPConfig
+	pub      kyber.Point
+	pubBuff  []byte
+	listener net.Listener
+	in       *inbox
+	peers    []*tcpPeer
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+}
+
+type tcpPeer struct {
+	Peer
+	pubBuff []byte
+	queue   chan []byte
+}
+
+// NewTCPBoard starts listening for incoming connections and returns the board.
+// Outgoing connections are established when the first packet is pushed.
+func NewTCPBoard(c *TCPConfig) (*TCPBoard, error) {
+	if c.Suite == nil || c.Auth == nil || c.Longterm == nil {
+		return nil, errors.New("board: tcp board needs a suite, an auth scheme and a longterm key")
+	}
+	conf := *c
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = DefaultQueueSize
+	}
+	if conf.MaxFrameSize <= 0 {
+		conf.MaxFrameSize = DefaultMaxFrameSize
+	}
+	if conf.RetryPeriod <= 0 {
+		conf.RetryPeriod = DefaultRetryPeriod
+	}
+	if conf.MaxRetryPeriod < conf.RetryPeriod {
+		conf.MaxRetryPeriod = DefaultMaxRetryPeriod
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DefaultTimeout
+	}
+
+	pub := conf.Suite.Point().Mul(conf.Longterm, nil)
+	pubBuff, err := pub.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := &TCPBoard{
+		c:       conf,
+		pub:     pub,
+		pubBuff: pubBuff,
+		conns:   make(map[net.Conn]bool),
+		done:    make(chan struct{}),
+	}
+	for _, p := range conf.Peers {
+		if p.Public.Equal(pub) {
+			continue
+		}
+		buff, err := p.Public.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b.peers = append(b.peers, &tcpPeer{
+			Peer:    p,
+			pubBuff: buff,
+			queue:   make(chan []byte, conf.QueueSize),
+		})
+	}
+
+	b.listener = conf.Listener
+	if b.listener == nil {
+		if b.listener, err = net.Listen("tcp", conf.ListenAddr); err != nil {
+			return nil, err
+		}
+	}
+	b.in = newInbox(conf.QueueSize)
+	b.wg.Add(1 + len(b.peers))
+	go b.acceptLoop()
+	for _, p := range b.peers {
+		go b.sendLoop(p)
+	}
+	return b, nil
+}
+
+// Addr returns the address the board listens on.
+func (b *TCPBoard) Addr() net.Addr {
+	return b.listener.Addr()
+}
+
+// Close closes all the connections and stops the board.
+func (b *TCPBoard) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.done)
+		err = b.listener.Close()
+		b.mu.Lock()
+		for conn := range b.conns {
+			conn.Close()
+		}
+		b.mu.Unlock()
+		b.in.close()
+		b.wg.Wait()
+	})
+	return err
+}
+
+func (b *TCPBoard) PushDeals(d *dkg.DealBundle) {
+	b.push(d)
+}
+
+func (b *TCPBoard) IncomingDeal() <-chan dkg.DealBundle {
+	return b.in.deals
+}
+
+func (b *TCPBoard) PushResponses(r *dkg.ResponseBundle) {
+	b.push(r)
+}
+
+func (b *TCPBoard) IncomingResponse() <-chan dkg.ResponseBundle {
+	return b.in.resps
+}
+
+func (b *TCPBoard) PushJustifications(j *dkg.JustificationBundle) {
+	b.push(j)
+}
+
+func (b *TCPBoard) IncomingJustification() <-chan dkg.JustificationBundle {
+	return b.in.justifs
+}
+
+func (b *TCPBoard) PushExtractions(e *dkg.ExtractionBundle) {
+	b.push(e)
+}
+
+func (b *TCPBoard) IncomingExtraction() <-chan dkg.ExtractionBundle {
+	return b.in.extracts
+}
+
+func (b *TCPBoard) PushReconstructions(r *dkg.ReconstructionBundle) {
+	b.push(r)
+}
+
+func (b *TCPBoard) IncomingReconstruction() <-chan dkg.ReconstructionBundle {
+	return b.in.recons
+}
+
+// push delivers the packet locally and queues it for each peer, waiting while
+// the queue of a peer is full.
+func (b *TCPBoard) push(p dkg.Packet) {
+	frame, err := dkg.EncodePacket(b.c.Suite, p)
+	if err != nil {
+		b.error("encoding packet", err)
+		return
+	}
+	if len(frame) > b.c.MaxFrameSize {
+		b.error("packet too large", len(frame))
+		return
+	}
+	b.in.push(clonePacket(p), false)
+	for _, peer := range b.peers {
+		select {
+		case peer.queue <- frame:
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// sendLoop sends the queued frames to the peer, (re)connecting when needed. A
+// frame that could not be written is sent again over the next connection.
+func (b *TCPBoard) sendLoop(p *tcpPeer) {
+	defer b.wg.Done()
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	var frame []byte
+	retry := b.c.RetryPeriod
+	for {
+		if frame == nil {
+			select {
+			case frame = <-p.queue:
+			case <-b.done:
+				return
+			}
+		}
+		if conn == nil {
+			var err error
+			if conn, err = b.dial(p); err != nil {
+				b.error("connecting to", p.Address, err)
+				select {
+				case <-time.After(retry):
+				case <-b.done:
+					return
+				}
+				if retry *= 2; retry > b.c.MaxRetryPeriod {
+					retry = b.c.MaxRetryPeriod
+				}
+				continue
+			}
+			retry = b.c.RetryPeriod
+		}
+		conn.SetWriteDeadline(time.Now().Add(b.c.Timeout))
+		if err := writeFrame(conn, frame); err != nil {
+			b.error("sending to", p.Address, err)
+			conn.Close()
+			conn = nil
+			continue
+		}
+		frame = nil
+	}
+}
+
+// dial connects to the peer and runs the client side of the handshake: the
+// client sends its key and a challenge, the server answers with its key, its
+// own challenge and its signature of the client challenge, and the client
+// finally signs the server challenge.
+func (b *TCPBoard) dial(p *tcpPeer) (net.Conn, error) {
+	d := net.Dialer{Timeout: b.c.Timeout}
+	conn, err := d.Dial("tcp", p.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(b.c.Timeout))
+	if err := b.clientHandshake(conn, p); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (b *TCPBoard) clientHandshake(conn net.Conn, p *tcpPeer) error {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+	w := &writer{}
+	w.bytes(b.pubBuff)
+	w.bytes(challenge)
+	if err := writeFrame(conn, w.buf.Bytes()); err != nil {
+		return err
+	}
+
+	frame, err := readFrame(conn, b.c.MaxFrameSize)
+	if err != nil {
+		return err
+	}
+	r := &reader{buf: frame}
+	serverPub := r.bytes()
+	serverChallenge := r.bytes()
+	serverSig := r.bytes()
+	if r.err != nil || len(r.buf) != 0 || len(serverChallenge) != challengeSize {
+		return errors.New("board: invalid handshake")
+	}
+	if !bytes.Equal(serverPub, p.pubBuff) {
+		return errors.New("board: unexpected peer key")
+	}
+	msg := handshakeMessage("server", challenge, b.pubBuff, p.pubBuff)
+	if err := b.c.Auth.Verify(p.Public, msg, serverSig); err != nil {
+		return fmt.Errorf("board: invalid peer signature: %w", err)
+	}
+
+	msg = handshakeMessage("client", serverChallenge, b.pubBuff, p.pubBuff)
+	sig, err := b.c.Auth.Sign(b.c.Longterm, msg)
+	if err != nil {
+		return err
+	}
+	w = &writer{}
+	w.bytes(sig)
+	return writeFrame(conn, w.buf.Bytes())
+}
+
+// serverHandshake runs the server side of the handshake and returns the peer
+// that connected.
+func (b *TCPBoard) serverHandshake(conn net.Conn) (*tcpPeer, error) {
+	frame, err := readFrame(conn, b.c.MaxFrameSize)
+	if err != nil {
+		return nil, err
+	}
+	r := &reader{buf: frame}
+	clientPub := r.bytes()
+	clientChallenge := r.bytes()
+	if r.err != nil || len(r.buf) != 0 || len(clientChallenge) != challengeSize {
+		return nil, errors.New("board: invalid handshake")
+	}
+	var peer *tcpPeer
+	for _, p := range b.peers {
+		if bytes.Equal(p.pubBuff, clientPub) {
+			peer = p
+			break
+		}
+	}
+	if peer == nil {
+		return nil, errors.New("board: unknown peer key")
+	}
+
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	sig, err := b.c.Auth.Sign(b.c.Longterm, handshakeMessage("server", clientChallenge, clientPub, b.pubBuff))
+	if err != nil {
+		return nil, err
+	}
+	w := &writer{}
+	w.bytes(b.pubBuff)
+	w.bytes(challenge)
+	w.bytes(sig)
+	if err := writeFrame(conn, w.buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	frame, err = readFrame(conn, b.c.MaxFrameSize)
+	if err != nil {
+		return nil, err
+	}
+	r = &reader{buf: frame}
+	clientSig := r.bytes()
+	if r.err != nil || len(r.buf) != 0 {
+		return nil, errors.New("board: invalid handshake")
+	}
+	msg := handshakeMessage("client", challenge, clientPub, b.pubBuff)
+	if err := b.c.Auth.Verify(peer.Public, msg, clientSig); err != nil {
+		return nil, fmt.Errorf("board: invalid peer signature: %w", err)
+	}
+	return peer, nil
+}
+
+func (b *TCPBoard) acceptLoop() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			select {
+			case <-b.done:
+				return
+			default:
+			}
+			b.error("accepting connection", err)
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		b.mu.Lock()
+		select {
+		case <-b.done:
+			b.mu.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		b.conns[conn] = true
+		b.wg.Add(1)
+		b.mu.Unlock()
+		go b.receiveLoop(conn)
+	}
+}
+
+// receiveLoop authenticates the peer and then reads its packets until the
+// connection fails. Reading stops while the incoming queue is full.
+func (b *TCPBoard) receiveLoop(conn net.Conn) {
+	defer b.wg.Done()
+	defer func() {
+		conn.Close()
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+	}()
+	conn.SetDeadline(time.Now().Add(b.c.Timeout))
+	peer, err := b.serverHandshake(conn)
+	if err != nil {
+		b.error("handshake from", conn.RemoteAddr(), err)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	for {
+		frame, err := readFrame(conn, b.c.MaxFrameSize)
+		if err != nil {
+			if err != io.EOF {
+				b.error("receiving from", peer.Address, err)
+			}
+			return
+		}
+		p, err := dkg.DecodePacket(b.c.Suite, frame)
+		if err != nil {
+			// the peer is authenticated but sends garbage
+			b.error("decoding packet from", peer.Address, err)
+			return
+		}
+		if !b.in.push(p, true) {
+			return
+		}
+	}
+}
+
+func (b *TCPBoard) error(keyvals ...interface{}) {
+	if b.c.Log != nil {
+		b.c.Log.Error(append([]interface{}{"tcp-board"}, keyvals...)...)
+	}
+}
+
+func handshakeMessage(role string, challenge, clientPub, serverPub []byte) []byte {
+	w := &writer{}
+	w.buf.Write(handshakeLabel)
+	w.bytes([]byte(role))
+	w.bytes(challenge)
+	w.bytes(clientPub)
+	w.bytes(serverPub)
+	return w.buf.Bytes()
+}
+
+func writeFrame(w io.Writer, frame []byte) error {
+	buff := make([]byte, 4+len(frame))
+	binary.BigEndian.PutUint32(buff, uint32(len(frame)))
+	copy(buff[4:], frame)
+	_, err := w.Write(buff)
+	return err
+}
+
+func readFrame(r io.Reader, max int) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if uint64(n) > uint64(max) {
+		return nil, fmt.Errorf("board: frame of %d bytes too large", n)
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
@@ -0,0 +1,161 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/sign"
+)
+
+// EpochCertificate certifies the distributed key of the group of an epoch.
+// The certificate of the initial DKG, epoch 0, is signed by a threshold of its
+// own group, and the certificate of each resharing is signed by a threshold of
+// the previous group, with their shares of the distributed key. A chain of
+// certificates thus lets a new member check that the group key it receives in
+// PublicCoeffs descends from the initial DKG through valid resharings.
+type EpochCertificate struct {
+	// Epoch is 0 for the initial DKG and increases by one at each resharing.
+	Epoch uint32
+	// Nodes is the QUAL of the epoch, the nodes holding a share.
+	Nodes []Node
+	// Commits are the public coefficients of the distributed polynomial. The
+	// threshold of the epoch is their number.
+	Commits []kyber.Point
+	// Signatures are the partial threshold signatures over the hash of the
+	// certificate, with scheme such as tbls.
+	Signatures [][]byte
+}
+
+// NewEpochCertificate returns the certificate, without signatures, of the
+// result of the DKG or resharing of the epoch.
+func NewEpochCertificate(epoch uint32, res *Result) *EpochCertificate {
+	return &EpochCertificate{
+		Epoch:   epoch,
+		Nodes:   append([]Node(nil), res.QUAL...),
+		Commits: append([]kyber.Point(nil), res.Key.Commits...),
+	}
+}
+
+// certificateTag separates the hashes of the certificates from the other
+// messages signed with the shares.
+const certificateTag = "dkg-epoch-cert-v1"
+
+// Hash hashes the epoch, the nodes and the commits of the certificate, each
+// list prefixed with its length.
+func (c *EpochCertificate) Hash() ([]byte, error) {
+	h := sha256.New()
+	h.Write([]byte(certificateTag))
+	if err := binary.Write(h, binary.BigEndian, c.Epoch); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(h, binary.BigEndian, uint32(len(c.Nodes))); err != nil {
+		return nil, err
+	}
+	for _, n := range c.Nodes {
+		if err := binary.Write(h, binary.BigEndian, n.Index); err != nil {
+			return nil, err
+		}
+		buff, err := n.Public.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		h.Write(buff)
+	}
+	if err := binary.Write(h, binary.BigEndian, uint32(len(c.Commits))); err != nil {
+		return nil, err
+	}
+	for _, commit := range c.Commits {
+		buff, err := commit.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		h.Write(buff)
+	}
+	return h.Sum(nil), nil
+}
+
+// Sign returns the partial signature of the certificate with the share of the
+// key. It must be the share of the previous epoch, or of the certified epoch
+// for the initial DKG.
+func (c *EpochCertificate) Sign(scheme sign.ThresholdScheme, key *DistKeyShare) ([]byte, error) {
+	msg, err := c.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return scheme.Sign(key.PriShare(), msg)
+}
+
+// VerifyEpochChain checks that the chain of certificates leads from the
+// genesis key, the distributed key of the initial DKG, to the current public
+// polynomial. The chain must start with the certificate of the initial DKG
+// and contain the certificates of all the epochs in order. The certificate of
+// each epoch must be signed by at least a threshold of distinct nodes of the
+// previous epoch, whose partial signatures are verified against the public
+// polynomial of the previous epoch, and keep the distributed key unchanged.
+func VerifyEpochChain(g kyber.Group, scheme sign.ThresholdScheme, genesis kyber.Point, chain []*EpochCertificate, current *share.PubPoly) error {
+	if len(chain) == 0 {
+		return errors.New("dkg: empty certificate chain")
+	}
+	var signers *EpochCertificate
+	for i, cert := range chain {
+		if cert.Epoch != uint32(i) {
+			return fmt.Errorf("dkg: certificate %d is for epoch %d", i, cert.Epoch)
+		}
+		if len(cert.Commits) == 0 || len(cert.Commits) > len(cert.Nodes) {
+			return fmt.Errorf("dkg: epoch %d: invalid threshold", cert.Epoch)
+		}
+		if !cert.Commits[0].Equal(genesis) {
+			return fmt.Errorf("dkg: epoch %d: distributed key changed", cert.Epoch)
+		}
+		if i == 0 {
+			// the initial group signs its own certificate
+			signers = cert
+		}
+		if err := signers.verifySignatures(g, scheme, cert); err != nil {
+			return fmt.Errorf("dkg: epoch %d: %w", cert.Epoch, err)
+		}
+		signers = cert
+	}
+	if current == nil {
+		return errors.New("dkg: nil public polynomial")
+	}
+	_, commits := current.Info()
+	if len(commits) != len(signers.Commits) {
+		return errors.New("dkg: public polynomial of another epoch")
+	}
+	for i := range commits {
+		if !commits[i].Equal(signers.Commits[i]) {
+			return errors.New("dkg: public polynomial of another epoch")
+		}
+	}
+	return nil
+}
+
+// verifySignatures checks that the certificate is signed by at least a
+// threshold of distinct nodes of the group of c.
+func (c *EpochCertificate) verifySignatures(g kyber.Group, scheme sign.ThresholdScheme, cert *EpochCertificate) error {
+	pubPoly := share.NewPubPoly(g, nil, c.Commits)
+	msg, err := cert.Hash()
+	if err != nil {
+		return err
+	}
+	signed := make(map[int]bool)
+	for _, sig := range cert.Signatures {
+		i, err := scheme.IndexOf(sig)
+		if err != nil || signed[i] || !isIndexIncluded(c.Nodes, uint32(i)) {
+			continue
+		}
+		if scheme.VerifyPartial(pubPoly, msg, sig) != nil {
+			continue
+		}
+		signed[i] = true
+	}
+	if len(signed) < len(c.Commits) {
+		return fmt.Errorf("only %d/%d valid signatures", len(signed), len(c.Commits))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package dkg
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/sign"
+	"github.com/drand/kyber/sign/schnorr"
+	"github.com/drand/kyber/sign/tbls"
+	"github.com/stretchr/testify/require"
+)
+
+// reshare runs a resharing from the nodes holding a result to the new nodes,
+// and returns the results of the new nodes, without updating them.
+func reshare(t *testing.T, suite Suite, oldTns, newTns []*TestNode, newT int) []*Result {
+	conf := &Config{
+		Suite:        suite,
+		OldNodes:     NodesFromTest(oldTns),
+		NewNodes:     NodesFromTest(newTns),
+		Threshold:    newT,
+		OldThreshold: len(oldTns[0].res.Key.Commits),
+		Auth:         schnorr.NewScheme(suite),
+		FastSync:     true,
+	}
+	all := append([]*TestNode(nil), newTns...)
+	for _, node := range oldTns {
+		if _, found := findPub(conf.NewNodes, node.Public); !found {
+			all = append(all, node)
+		}
+	}
+	SetupReshareNodes(all, conf, oldTns[0].res.Key.Commits)
+	var deals []*DealBundle
+	for _, node := range oldTns {
+		d, err := node.dkg.Deals()
+		require.NoError(t, err)
+		deals = append(deals, d)
+	}
+	var responses []*ResponseBundle
+	for _, node := range newTns {
+		resp, err := node.dkg.ProcessDeals(deals)
+		require.NoError(t, err)
+		responses = append(responses, resp)
+	}
+	var results []*Result
+	for _, node := range newTns {
+		res, _, err := node.dkg.ProcessResponses(responses)
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		results = append(results, res)
+	}
+	return results
+}
+
+// certify returns the certificate of the results, signed by the nodes.
+func certify(t *testing.T, scheme sign.ThresholdScheme, epoch uint32, results []*Result, signers []*TestNode) *EpochCertificate {
+	cert := NewEpochCertificate(epoch, results[0])
+	for _, node := range signers {
+		sig, err := cert.Sign(scheme, node.res.Key)
+		require.NoError(t, err)
+		cert.Signatures = append(cert.Signatures, sig)
+	}
+	return cert
+}
+
+func TestEpochCertificates(t *testing.T) {
+	suite := bn256.NewSuiteG2()
+	scheme := tbls.NewThresholdSchemeOnG1(bn256.NewSuite())
+
+	// genesis DKG
+	tns := GenerateTestNodes(suite, 5)
+	conf := Config{
+		Suite:     suite,
+		NewNodes:  NodesFromTest(tns),
+		Threshold: 3,
+		Auth:      schnorr.NewScheme(suite),
+	}
+	results := RunDKG(t, tns, conf, nil, nil, nil)
+	for i, node := range tns {
+		node.res = results[i]
+	}
+	genesis := results[0].Key.Public()
+	chain := []*EpochCertificate{certify(t, scheme, 0, results, tns[:3])}
+
+	// first resharing to a bigger group, signed by the genesis group
+	newTns := append(append([]*TestNode(nil), tns...), NewTestNode(suite, 5), NewTestNode(suite, 6))
+	results = reshare(t, suite, tns, newTns, 4)
+	chain = append(chain, certify(t, scheme, 1, results, tns[1:4]))
+	for i, node := range newTns {
+		node.res = results[i]
+	}
+
+	// second resharing without the first nodes
+	tns, newTns = newTns, newTns[2:]
+	results = reshare(t, suite, tns, newTns, 3)
+	chain = append(chain, certify(t, scheme, 2, results, tns[3:]))
+	current := share.NewPubPoly(suite, nil, results[0].Key.Commits)
+
+	require.NoError(t, VerifyEpochChain(suite, scheme, genesis, chain, current))
+
+	// the chain must start at the genesis, be complete and lead to the
+	// current polynomial
+	require.Error(t, VerifyEpochChain(suite, scheme, suite.Point().Pick(suite.RandomStream()), chain, current))
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, chain[1:], current))
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, []*EpochCertificate{chain[0], chain[2]}, current))
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, chain[:2], current))
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, nil, current))
+
+	// not enough distinct signers of the previous group
+	sigs := chain[1].Signatures
+	chain[1].Signatures = [][]byte{sigs[0], sigs[1], sigs[0]}
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, chain, current))
+	chain[1].Signatures = sigs
+
+	// signatures of the new group instead of the previous one
+	for i, node := range newTns {
+		node.res = results[i]
+	}
+	forged := certify(t, scheme, 2, results, newTns[:3])
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, append(chain[:2:2], forged), current))
+
+	// the signatures do not carry over to a certificate with reshuffled
+	// nodes or commits
+	shuffled := *chain[2]
+	shuffled.Nodes = append([]Node(nil), chain[2].Nodes...)
+	shuffled.Nodes[0], shuffled.Nodes[1] = shuffled.Nodes[1], shuffled.Nodes[0]
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, append(chain[:2:2], &shuffled), current))
+	shuffled = *chain[2]
+	shuffled.Commits = append([]kyber.Point(nil), chain[2].Commits...)
+	shuffled.Commits[1], shuffled.Commits[2] = shuffled.Commits[2], shuffled.Commits[1]
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, append(chain[:2:2], &shuffled), share.NewPubPoly(suite, nil, shuffled.Commits)))
+	require.NoError(t, VerifyEpochChain(suite, scheme, genesis, chain, current))
+
+	// a certificate for another key
+	chain[2].Commits[0] = suite.Point().Pick(suite.RandomStream())
+	require.Error(t, VerifyEpochChain(suite, scheme, genesis, chain, current))
+}
@@ -0,0 +1,106 @@
+package dkg
+
+import (
+	"errors"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+)
+
+// storeEncryptedShares keeps the encrypted shares of the dealer, to verify the
+// complaints against it.
+func (d *DistKeyGenerator) storeEncryptedShares(dealer Index, deals []Deal) {
+	if !d.c.VerifiableComplaints {
+		return
+	}
+	shares := make(map[uint32][]byte)
+	for _, deal := range deals {
+		shares[deal.ShareIndex] = deal.EncryptedShare
+	}
+	d.encShares[dealer] = shares
+}
+
+// complaintProof returns the proof of the complaint of this node against the
+// dealer. It returns nil if this node didn't receive any share from the
+// dealer, or if the ciphertext is malformed, in which case every node can
+// see it.
+func (d *DistKeyGenerator) complaintProof(dealer Index) (*ComplaintProof, error) {
+	cipher, ok := d.encShares[dealer][uint32(d.nidx)]
+	if !ok {
+		return nil, nil
+	}
+	// the share is invalid, which everyone can now check
+	d.proven = append(d.proven, dealer)
+	R, err := d.c.dealEncrypter().(VerifiableDealEncrypter).Ephemeral(d.suite, cipher)
+	if err != nil {
+		return nil, nil
+	}
+	proof, _, dh, err := dleq.NewDLEQProof(d.suite, d.suite.Point().Base(), R, d.long)
+	if err != nil {
+		return nil, err
+	}
+	return &ComplaintProof{DHKey: dh, Proof: *proof}, nil
+}
+
+// verifyComplaint checks the complaint of the share holder against the
+// dealer. It returns true if the complaint is proven, false if this node
+// didn't see the share so it must be justified as usual, and an error if the
+// complaint is false.
+func (d *DistKeyGenerator) verifyComplaint(dealer, holder Index, proof *ComplaintProof) (bool, error) {
+	cipher, ok := d.encShares[dealer][holder]
+	if !ok {
+		return false, nil
+	}
+	enc := d.c.dealEncrypter().(VerifiableDealEncrypter)
+	R, err := enc.Ephemeral(d.suite, cipher)
+	if err != nil {
+		// malformed ciphertext
+		return true, nil
+	}
+	if proof == nil {
+		return false, errors.New("complaint without proof")
+	}
+	public, ok := findIndex(d.c.NewNodes, holder)
+	p := proof.Proof
+	if !ok || proof.DHKey == nil || p.C == nil || p.R == nil || p.VG == nil || p.VH == nil ||
+		p.Verify(d.suite, d.suite.Point().Base(), R, public, proof.DHKey) != nil {
+		return false, errors.New("invalid complaint proof")
+	}
+	buff, err := enc.DecryptWithKey(d.suite, proof.DHKey, cipher)
+	if err != nil {
+		return true, nil
+	}
+	pubPoly, ok := d.allPublics[dealer]
+	if !ok && d.canIssue && dealer == d.oidx {
+		pubPoly = d.dpub
+	}
+	if _, _, reason := d.verifyShare(dealer, pubPoly, holder, buff); reason != "" {
+		return true, nil
+	}
+	return false, errors.New("complaint against a valid share")
+}
+
+// verifyShare decodes the decrypted share of the holder from the dealer and
+// checks it against the public polynomial of the dealer. It returns the
+// reason why the share is invalid, if it is.
+func (d *DistKeyGenerator) verifyShare(dealer Index, pubPoly *share.PubPoly, holder Index, buff []byte) (sh, blinding kyber.Scalar, reason string) {
+	sh, blinding, err := d.unmarshalShare(buff)
+	if err != nil {
+		return nil, nil, "invalid share encoding"
+	}
+	// check if share is valid w.r.t. public commitment
+	if !d.checkShare(pubPoly, holder, sh, blinding) {
+		return nil, nil, "share inconsistent with the public polynomial"
+	}
+	if d.isResharing {
+		// check that the evaluation this public polynomial at 0,
+		// corresponds to the commitment of the previous the dealer's index
+		oldShareCommit := d.olddpub.Eval(int(dealer)).V
+		if !oldShareCommit.Equal(pubPoly.Commit()) {
+			// inconsistent share from old member
+			return nil, nil, "deal inconsistent with the previous group"
+		}
+	}
+	return sh, blinding, ""
+}
@@ -1,18 +1,29 @@
+// Package dkg implements a distributed key generation and resharing
+// protocol, in which a group of nodes jointly creates a distributed private
+// key, each node receiving a share of it as a DistKeyShare usable e.g. with
+// sign/tbls, and can later reshare it to a new group. It runs in the
+// Joint-Feldman or GJKR mode, driven by the DistKeyGenerator directly or by
+// the Protocol over a Board.
+//
+// The package also implements a non-interactive, publicly verifiable DKG with
+// PVSSDeal and VerifyPVSSDeals. Its shares are the points s_i*G instead of
+// scalars, so its result is not a DistKeyShare and cannot be used with
+// sign/tbls, nor be reshared.
 package dkg
 
 import (
 	"bytes"
 	"crypto/rand"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/encrypt/ecies"
 	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/event"
 	"github.com/drand/kyber/sign"
 	"github.com/drand/kyber/util/random"
+	clock "github.com/jonboulle/clockwork"
 )
 
 type Suite interface {
@@ -110,11 +121,82 @@ type Config struct {
 	// during the protocol.
 	Auth sign.Scheme
 
+	// DealEncrypter encrypts the shares of the deals to their share holders.
+	// It defaults to ECIESEncrypter if nil.
+	DealEncrypter DealEncrypter
+
+	// VerifiableComplaints makes the share holders prove their complaints:
+	// a complaint reveals the Diffie-Hellman key decrypting the share of the
+	// complainer, with a DLEQ proof of its correctness, so that any node can
+	// check whether the deal is invalid. A proven complaint evicts the
+	// dealer, and a false one evicts the share holder, so that dealers don't
+	// reveal the shares of honest nodes in justifications. Dealers only
+	// justify the complaints about deals that were not seen by everyone. It
+	// requires a DealEncrypter implementing VerifiableDealEncrypter, such as
+	// ECIESEncrypter.
+	VerifiableComplaints bool
+
+	// Workers is the number of goroutines decrypting and verifying the
+	// shares of the deals, complaints and justifications, which dominate the
+	// time of each phase with large groups. The results do not depend on it.
+	// If it is 0 or 1, everything is processed sequentially. The
+	// DealEncrypter must be safe for concurrent use if it is greater than 1.
+	Workers int
+
+	// Mode selects the protocol to run. The default JointFeldman protocol is
+	// the fastest, while the GJKR protocol guarantees the distributed key is
+	// uniformly random even in presence of a rushing adversary, at the cost of
+	// an extraction phase. GJKR is only available for a fresh DKG, without
+	// FastSync.
+	Mode Mode
+
 	// Log enables the DKG logic and protocol to log important events (mostly
 	// errors).  from participants. Errors don't mean the protocol should be
 	// stopped, so logging is the best way to communicate information to the
 	// application layer. It can be nil.
 	Log Logger
+
+	// Events receives the typed events of the DKG and protocol, such as the
+	// deals rejected, the complaints issued or the nodes evicted, for
+	// monitoring. It can be nil.
+	Events event.Handler
+
+	// Clock gives the time to the Protocol, which reports the duration of
+	// each phase with it. It defaults to the real clock. A fake clock makes
+	// the timings reproducible when it also drives the Phaser, see
+	// NewTimePhaserClock.
+	Clock clock.Clock
+}
+
+// Mode is a type that represents the different DKG protocols available.
+type Mode int
+
+const (
+	// JointFeldman is the Joint-Feldman protocol of Pedersen where dealers
+	// commit to their polynomial with Feldman commitments. An adversary
+	// controlling some dealers can bias the distribution of the distributed
+	// key by making its deals fail after seeing the commitments of the honest
+	// dealers.
+	JointFeldman Mode = iota
+	// GJKR is the protocol of Gennaro, Jarecki, Krawczyk and Rabin from
+	// "Secure Distributed Key Generation for Discrete-Log Based
+	// Cryptosystems". Dealers first commit to their polynomial with hiding
+	// Pedersen commitments, and only reveal the Feldman commitments during an
+	// extraction phase, once the set of qualified dealers is fixed. The
+	// contribution of a qualified dealer failing to reveal its commitments is
+	// reconstructed from the shares of the other nodes.
+	GJKR
+)
+
+func (m Mode) String() string {
+	switch m {
+	case JointFeldman:
+		return "joint-feldman"
+	case GJKR:
+		return "gjkr"
+	default:
+		return "unknown"
+	}
 }
 
 // Phase is a type that represents the different stages of the DKG protocol.
@@ -126,6 +208,10 @@ const (
 	ResponsePhase
 	JustifPhase
 	FinishPhase
+	// ExtractionPhase and ReconstructionPhase are only used in GJKR mode,
+	// between JustifPhase and FinishPhase.
+	ExtractionPhase
+	ReconstructionPhase
 )
 
 func (p Phase) String() string {
@@ -140,6 +226,10 @@ func (p Phase) String() string {
 		return "justification"
 	case FinishPhase:
 		return "finished"
+	case ExtractionPhase:
+		return "extraction"
+	case ReconstructionPhase:
+		return "reconstruction"
 	default:
 		return "unknown"
 	}
@@ -187,6 +277,20 @@ type DistKeyGenerator struct {
 	processed bool
 	// public polynomial of the old group
 	olddpub *share.PubPoly
+
+	// GJKR mode only: Pedersen base point, blinding polynomial, blinding
+	// shares received, Feldman commitments extracted and dealers whose
+	// polynomial must be reconstructed.
+	pedersenBase   kyber.Point
+	dblind         *share.PriPoly
+	validBlindings map[uint32]kyber.Scalar
+	extracted      map[uint32]*share.PubPoly
+	reconstruct    []Index
+
+	// verifiable complaints only: encrypted shares of the deals seen, by
+	// dealer and share holder, and dealers this node proved to be cheating.
+	encShares map[uint32]map[uint32][]byte
+	proven    []Index
 }
 
 // NewDistKeyHandler takes a Config and returns a DistKeyGenerator that is able
@@ -201,11 +305,24 @@ func NewDistKeyHandler(c *Config) (*DistKeyGenerator, error) {
 	if c.Auth == nil {
 		return nil, errors.New("dkg: need authentication scheme")
 	}
+	if _, ok := c.dealEncrypter().(VerifiableDealEncrypter); c.VerifiableComplaints && !ok {
+		return nil, errors.New("dkg: verifiable complaints need a verifiable deal encrypter")
+	}
 
 	var isResharing bool
 	if c.Share != nil || c.PublicCoeffs != nil {
 		isResharing = true
 	}
+	if c.Mode == GJKR {
+		if isResharing {
+			return nil, errors.New("dkg: GJKR mode is only available for a fresh DKG")
+		}
+		if c.FastSync {
+			return nil, errors.New("dkg: GJKR mode does not support fast sync")
+		}
+	} else if c.Mode != JointFeldman {
+		return nil, fmt.Errorf("dkg: unknown mode %d", c.Mode)
+	}
 	if isResharing {
 		if len(c.OldNodes) == 0 {
 			return nil, errors.New("dkg: resharing config needs old nodes list")
@@ -245,25 +362,9 @@ func NewDistKeyHandler(c *Config) (*DistKeyGenerator, error) {
 	var oldThreshold int
 	if !isResharing && newPresent {
 		// fresk DKG present
-		randomStream := random.New()
-		// if the user provided a reader, use it alone or combined with crypto/rand
-		if c.Reader != nil && !c.UserReaderOnly {
-			randomStream = random.New(c.Reader, rand.Reader)
-		} else if c.Reader != nil && c.UserReaderOnly {
-			randomStream = random.New(c.Reader)
-		}
-		pickErr := func() (err error) {
-			defer func() {
-				if r := recover(); r != nil {
-					err = fmt.Errorf("error picking secret: %v", r)
-					return
-				}
-			}()
-			secretCoeff = c.Suite.Scalar().Pick(randomStream)
-			return nil
-		}()
-		if pickErr != nil {
-			return nil, pickErr
+		secretCoeff, err = c.pickSecret()
+		if err != nil {
+			return nil, err
 		}
 		// in fresh dkg case, we consider the old nodes same a new nodes
 		c.OldNodes = c.NewNodes
@@ -279,6 +380,17 @@ func NewDistKeyHandler(c *Config) (*DistKeyGenerator, error) {
 	}
 	dpriv = share.NewPriPoly(c.Suite, c.Threshold, secretCoeff, c.Suite.RandomStream())
 	dpub = dpriv.Commit(c.Suite.Point().Base())
+	var pedersenBase kyber.Point
+	var dblind *share.PriPoly
+	if c.Mode == GJKR {
+		// the dealer only publishes the Pedersen commitments during the deal
+		// phase
+		pedersenBase = PedersenBase(c.Suite)
+		dblind = share.NewPriPoly(c.Suite, c.Threshold, nil, c.Suite.RandomStream())
+		if dpub, err = dpub.Add(dblind.Commit(pedersenBase)); err != nil {
+			return nil, err
+		}
+	}
 	// resharing case and we are included in the new list of nodes
 	if isResharing && newPresent {
 		if c.PublicCoeffs == nil && c.Share == nil {
@@ -334,6 +446,13 @@ func NewDistKeyHandler(c *Config) (*DistKeyGenerator, error) {
 		statuses:    statuses,
 		validShares: make(map[uint32]kyber.Scalar),
 		allPublics:  make(map[uint32]*share.PubPoly),
+
+		pedersenBase:   pedersenBase,
+		dblind:         dblind,
+		validBlindings: make(map[uint32]kyber.Scalar),
+		extracted:      make(map[uint32]*share.PubPoly),
+
+		encShares: make(map[uint32]map[uint32][]byte),
 	}
 	return dkg, err
 }
@@ -345,29 +464,43 @@ func (d *DistKeyGenerator) Deals() (*DealBundle, error) {
 	if d.state != InitPhase {
 		return nil, fmt.Errorf("dkg not in the initial state, can't produce deals: %d", d.state)
 	}
-	deals := make([]Deal, 0, len(d.c.NewNodes))
+	holders := make([]Node, 0, len(d.c.NewNodes))
 	for _, node := range d.c.NewNodes {
-		// compute share
-		si := d.dpriv.Eval(int(node.Index)).V
-
 		if d.canReceive && uint32(d.nidx) == node.Index {
-			d.validShares[d.oidx] = si
+			d.validShares[d.oidx] = d.dpriv.Eval(int(node.Index)).V
+			if d.dblind != nil {
+				d.validBlindings[d.oidx] = d.dblind.Eval(int(node.Index)).V
+			}
 			d.allPublics[d.oidx] = d.dpub
 			// we set our own share as true, because we are not malicious!
 			d.statuses.Set(d.oidx, d.nidx, Success)
 			// we don't send our own share - useless
 			continue
 		}
+		holders = append(holders, node)
+	}
+	deals := make([]Deal, len(holders))
+	errs := make([]error, len(holders))
+	d.c.parallel(len(holders), func(i int) {
+		node := holders[i]
+		// compute share
+		si := d.dpriv.Eval(int(node.Index)).V
 		msg, _ := si.MarshalBinary()
-		cipher, err := ecies.Encrypt(d.c.Suite, node.Public, msg, sha256.New)
+		if d.dblind != nil {
+			// in GJKR mode the blinding share follows the share
+			bi := d.dblind.Eval(int(node.Index)).V
+			bbuff, _ := bi.MarshalBinary()
+			msg = append(msg, bbuff...)
+		}
+		deals[i].ShareIndex = node.Index
+		deals[i].EncryptedShare, errs[i] = d.c.dealEncrypter().Encrypt(d.c.Suite, node.Public, msg)
+	})
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		deals = append(deals, Deal{
-			ShareIndex:     node.Index,
-			EncryptedShare: cipher,
-		})
 	}
+	d.storeEncryptedShares(uint32(d.oidx), deals)
 	d.state = DealPhase
 	_, commits := d.dpub.Info()
 	bundle := &DealBundle{
@@ -401,8 +534,9 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 		return nil, nil
 	}
 
+	checks := d.checkDeals(bundles)
 	seenIndex := make(map[uint32]bool)
-	for _, bundle := range bundles {
+	for i, bundle := range bundles {
 		if bundle == nil {
 			d.c.Error("found nil Deal bundle")
 			continue
@@ -419,7 +553,7 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 		}
 
 		if bytes.Compare(bundle.SessionID, d.c.Nonce) != 0 {
-			d.evicted = append(d.evicted, bundle.DealerIndex)
+			d.evictDealer(bundle.DealerIndex, "invalid session ID in deal")
 			d.c.Error("Deal with invalid session ID")
 			continue
 		}
@@ -429,7 +563,7 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 			// so we evict him from the list
 			// since we assume broadcast channel, every honest player will evict
 			// this party as well
-			d.evicted = append(d.evicted, bundle.DealerIndex)
+			d.evictDealer(bundle.DealerIndex, "invalid public polynomial")
 			d.c.Error("Deal with nil public key or invalid threshold")
 			continue
 		}
@@ -437,18 +571,19 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 		if seenIndex[bundle.DealerIndex] {
 			// already saw a bundle from the same dealer - clear sign of
 			// cheating so we evict him from the list
-			d.evicted = append(d.evicted, bundle.DealerIndex)
+			d.evictDealer(bundle.DealerIndex, "duplicate deal bundle")
 			d.c.Error("Deal bundle already seen")
 			continue
 		}
 		seenIndex[bundle.DealerIndex] = true
 		d.allPublics[bundle.DealerIndex] = pubPoly
-		for _, deal := range bundle.Deals {
+		d.storeEncryptedShares(bundle.DealerIndex, bundle.Deals)
+		for j, deal := range bundle.Deals {
 			if !isIndexIncluded(d.c.NewNodes, deal.ShareIndex) {
 				// invalid index for share holder is a clear sign of cheating
 				// so we evict him from the list
 				// and we don't even need to look at the rest
-				d.evicted = append(d.evicted, bundle.DealerIndex)
+				d.evictDealer(bundle.DealerIndex, "deal for an invalid share holder")
 				d.c.Error("Deal share holder evicted normally")
 				break
 			}
@@ -456,39 +591,22 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 				// we dont look at other's shares
 				continue
 			}
-			shareBuff, err := ecies.Decrypt(d.c.Suite, d.long, deal.EncryptedShare, sha256.New)
-			if err != nil {
-				d.c.Error("Deal share decryption invalid")
-				continue
-			}
-			share := d.c.Suite.Scalar()
-			if err := share.UnmarshalBinary(shareBuff); err != nil {
-				d.c.Error("Deal share unmarshalling invalid")
-				continue
-			}
-			// check if share is valid w.r.t. public commitment
-			comm := pubPoly.Eval(int(d.nidx)).V
-			commShare := d.c.Suite.Point().Mul(share, nil)
-			if !comm.Equal(commShare) {
-				d.c.Error("Deal share invalid wrt public poly")
+			// the share was decrypted and verified by checkDeals
+			check := checks[i][j]
+			if check.reason != "" {
 				// invalid share - will issue complaint
+				d.c.Error(fmt.Sprintf("Deal share invalid: %s", check.reason))
+				d.rejectDeal(bundle.DealerIndex, check.reason)
 				continue
 			}
-
-			if d.isResharing {
-				// check that the evaluation this public polynomial at 0,
-				// corresponds to the commitment of the previous the dealer's index
-				oldShareCommit := d.olddpub.Eval(int(bundle.DealerIndex)).V
-				publicCommit := pubPoly.Commit()
-				if !oldShareCommit.Equal(publicCommit) {
-					// inconsistent share from old member
-					continue
-				}
-			}
 			// share is valid -> store it
 			d.statuses.Set(bundle.DealerIndex, deal.ShareIndex, true)
-			d.validShares[bundle.DealerIndex] = share
+			d.validShares[bundle.DealerIndex] = check.share
+			if check.blinding != nil {
+				d.validBlindings[bundle.DealerIndex] = check.blinding
+			}
 			d.c.Info("Valid deal processed received from dealer", bundle.DealerIndex)
+			d.c.emit(event.DealReceived{Dealer: bundle.DealerIndex, ShareHolder: uint32(d.nidx)})
 		}
 	}
 
@@ -524,11 +642,20 @@ func (d *DistKeyGenerator) ProcessDeals(bundles []*DealBundle) (*ResponseBundle,
 			}
 		} else {
 			// dealer i did not give a successful share (or absent etc)
-			responses = append(responses, Response{
+			response := Response{
 				DealerIndex: uint32(node.Index),
 				Status:      Complaint,
-			})
+			}
+			if d.c.VerifiableComplaints {
+				proof, err := d.complaintProof(node.Index)
+				if err != nil {
+					return nil, err
+				}
+				response.Proof = proof
+			}
+			responses = append(responses, response)
 			d.c.Info(fmt.Sprintf("Complaint towards node %d", node.Index))
+			d.c.emit(event.ComplaintIssued{Dealer: node.Index, ShareHolder: uint32(d.nidx)})
 		}
 	}
 	var bundle *ResponseBundle
@@ -573,16 +700,23 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 		}
 	}()
 
-	if !d.c.FastSync && len(bundles) == 0 && d.canReceive && d.statuses.CompleteSuccess() {
+	if !d.c.FastSync && d.c.Mode != GJKR && len(bundles) == 0 && d.canReceive && d.statuses.CompleteSuccess() {
 		// if we are not in fastsync, we expect only complaints
 		// if there is no complaints all is good
 		res, err = d.computeResult()
 		return
 	}
 
+	// our own complaints are proven as well, even though we don't process
+	// our own responses
+	for _, dealer := range d.proven {
+		d.evictDealer(dealer, "proven complaint")
+	}
+
+	checks := d.checkComplaints(bundles)
 	var validAuthors []Index
 	var foundComplaint bool
-	for _, bundle := range bundles {
+	for i, bundle := range bundles {
 		if bundle == nil {
 			continue
 		}
@@ -597,15 +731,15 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 
 		if bytes.Compare(bundle.SessionID, d.c.Nonce) != 0 {
 			d.c.Error("Response invalid session ID")
-			d.evictedHolders = append(d.evictedHolders, bundle.ShareIndex)
+			d.evictHolder(bundle.ShareIndex, "invalid session ID in response")
 			continue
 		}
 
-		for _, response := range bundle.Responses {
+		for j, response := range bundle.Responses {
 			if !isIndexIncluded(d.c.OldNodes, response.DealerIndex) {
 				// the index of the dealer doesn't exist - clear violation
 				// so we evict
-				d.evictedHolders = append(d.evictedHolders, bundle.ShareIndex)
+				d.evictHolder(bundle.ShareIndex, "response for an invalid dealer")
 				d.c.Error("Response dealer index already evicted")
 				continue
 			}
@@ -614,14 +748,31 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 				// we should only receive complaint if we are not in fast sync
 				// mode - clear violation
 				// so we evict
-				d.evictedHolders = append(d.evictedHolders, bundle.ShareIndex)
+				d.evictHolder(bundle.ShareIndex, "success response outside of fast sync")
 				d.c.Error("Response success but in regular mode")
 				continue
 			}
 
+			if d.c.VerifiableComplaints && response.Status == Complaint {
+				// the complaint was verified by checkComplaints
+				check := checks[i][j]
+				if check.err != nil {
+					// false complaint - the share is not revealed and the
+					// share holder is evicted
+					d.statuses.Set(response.DealerIndex, bundle.ShareIndex, Success)
+					d.evictHolder(bundle.ShareIndex, check.err.Error())
+					d.c.Error(fmt.Sprintf("Response with false complaint from node %d", bundle.ShareIndex))
+					continue
+				}
+				if check.proven {
+					d.evictDealer(response.DealerIndex, "proven complaint")
+				}
+			}
+
 			d.statuses.Set(response.DealerIndex, bundle.ShareIndex, response.Status)
 			if response.Status == Complaint {
 				foundComplaint = true
+				d.c.emit(event.ComplaintReceived{Dealer: response.DealerIndex, ShareHolder: bundle.ShareIndex})
 			}
 			validAuthors = append(validAuthors, bundle.ShareIndex)
 		}
@@ -642,7 +793,7 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 			}
 			if !contains(allSent, n.Index) {
 				d.c.Error(fmt.Sprintf("Response not seen from node %d (eviction)", n.Index))
-				d.evictedHolders = append(d.evictedHolders, n.Index)
+				d.evictHolder(n.Index, "missing response")
 			}
 		}
 	}
@@ -650,7 +801,7 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 	// there is no complaint in the responses received and the status matrix
 	// is all filled with success that means we can finish the protocol -
 	// regardless of the mode chosen (fast sync or not).
-	if !foundComplaint && d.statuses.CompleteSuccess() {
+	if !foundComplaint && d.statuses.CompleteSuccess() && d.c.Mode != GJKR {
 		d.c.Info("msg", "DKG successful")
 		d.state = FinishPhase
 		if d.canReceive {
@@ -668,7 +819,7 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 	for _, n := range d.c.OldNodes {
 		complaints := d.statuses.StatusesOfDealer(n.Index).LengthComplaints()
 		if complaints >= d.c.Threshold {
-			d.evicted = append(d.evicted, n.Index)
+			d.evictDealer(n.Index, "too many complaints")
 			d.c.Error(fmt.Sprintf("Response phase eviction of node %d", n.Index))
 		}
 	}
@@ -690,9 +841,14 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 		}
 		// create justifications for the requested share
 		var sh = d.dpriv.Eval(int(shareIndex)).V
+		var blinding kyber.Scalar
+		if d.dblind != nil {
+			blinding = d.dblind.Eval(int(shareIndex)).V
+		}
 		justifications = append(justifications, Justification{
 			ShareIndex: shareIndex,
 			Share:      sh,
+			Blinding:   blinding,
 		})
 		d.c.Info(fmt.Sprintf("Producing justifications for node %d", shareIndex))
 		foundJustifs = true
@@ -723,7 +879,9 @@ func (d *DistKeyGenerator) ProcessResponses(bundles []*ResponseBundle) (res *Res
 // results if there is enough QUALified nodes, or an error otherwise. Note that
 // this method returns "nil,nil" if this node is a node only present in the old
 // group of the dkg: indeed a node leaving the group don't need to process
-// justifications, and can simply leave the protocol.
+// justifications, and can simply leave the protocol. In GJKR mode, it also
+// returns "nil,nil" once the qualified dealers are known, and the nodes must
+// then run the extraction phase with Extraction and ProcessExtractions.
 func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle) (*Result, error) {
 	if !d.canReceive {
 		// an old node leaving the group do not need to process justifications.
@@ -735,15 +893,16 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 		return nil, fmt.Errorf("node can only process justifications after processing responses - current state %s", d.state.String())
 	}
 
+	checks := d.checkJustifications(bundles)
 	seen := make(map[uint32]bool)
-	for _, bundle := range bundles {
+	for i, bundle := range bundles {
 		if bundle == nil {
 			continue
 		}
 		if seen[bundle.DealerIndex] {
 			// bundle contains duplicate - clear violation
 			// so we evict
-			d.evicted = append(d.evicted, bundle.DealerIndex)
+			d.evictDealer(bundle.DealerIndex, "duplicate justification bundle")
 			d.c.Error("Justification bundle contains duplicate - evicting dealer", bundle.DealerIndex)
 			continue
 		}
@@ -763,18 +922,18 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 			continue
 		}
 		if bytes.Compare(bundle.SessionID, d.c.Nonce) != 0 {
-			d.evicted = append(d.evicted, bundle.DealerIndex)
+			d.evictDealer(bundle.DealerIndex, "invalid session ID in justification")
 			d.c.Error("Justification bundle contains invalid session ID - evicting dealer", bundle.DealerIndex)
 			continue
 		}
 		d.c.Info("ProcessJustifications - basic sanity checks done", true)
 
 		seen[bundle.DealerIndex] = true
-		for _, justif := range bundle.Justifications {
+		for j, justif := range bundle.Justifications {
 			if !isIndexIncluded(d.c.NewNodes, justif.ShareIndex) {
 				// invalid index - clear violation
 				// so we evict
-				d.evicted = append(d.evicted, bundle.DealerIndex)
+				d.evictDealer(bundle.DealerIndex, "justification for an invalid share holder")
 				d.c.Error("Invalid index in justifications - evicting dealer", bundle.DealerIndex)
 				continue
 			}
@@ -782,16 +941,19 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 			if !ok {
 				// dealer hasn't given any public polynomial at the first phase
 				// so we evict directly - no need to look at its justifications
-				d.evicted = append(d.evicted, bundle.DealerIndex)
+				d.evictDealer(bundle.DealerIndex, "missing public polynomial")
 				d.c.Error("Public polynomial missing - evicting dealer", bundle.DealerIndex)
 				break
 			}
-			// compare commit and public poly
-			commit := d.c.Suite.Point().Mul(justif.Share, nil)
-			expected := pubPoly.Eval(int(justif.ShareIndex)).V
-			if !commit.Equal(expected) {
+			// compare commit and public poly, checked by checkJustifications
+			if !checks[i][j] {
 				// invalid justification - evict
-				d.evicted = append(d.evicted, bundle.DealerIndex)
+				d.c.emit(event.JustificationRejected{
+					Dealer:      bundle.DealerIndex,
+					ShareHolder: justif.ShareIndex,
+					Reason:      "share inconsistent with the public polynomial",
+				})
+				d.evictDealer(bundle.DealerIndex, "invalid justification")
 				d.c.Error("New share commit invalid - evicting dealer", bundle.DealerIndex)
 				continue
 			}
@@ -802,7 +964,7 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 				publicCommit := pubPoly.Commit()
 				if !oldShareCommit.Equal(publicCommit) {
 					// inconsistent share from old member
-					d.evicted = append(d.evicted, bundle.DealerIndex)
+					d.evictDealer(bundle.DealerIndex, "justification inconsistent with the previous group")
 
 					d.c.Error("Old share commit not equal to public commit - evicting dealer", bundle.DealerIndex)
 					continue
@@ -811,10 +973,14 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 			}
 			// valid share -> mark OK
 			d.statuses.Set(bundle.DealerIndex, justif.ShareIndex, true)
+			d.c.emit(event.JustificationAccepted{Dealer: bundle.DealerIndex, ShareHolder: justif.ShareIndex})
 			if justif.ShareIndex == uint32(d.nidx) {
 				// store the share if it's for us
 				d.c.Info("Saving our key share for", justif.ShareIndex)
 				d.validShares[bundle.DealerIndex] = justif.Share
+				if justif.Blinding != nil {
+					d.validBlindings[bundle.DealerIndex] = justif.Blinding
+				}
 			}
 		}
 	}
@@ -832,6 +998,7 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 		}
 		if !d.statuses.AllTrue(n.Index) {
 			// this dealer has some unjustified shares
+			d.evictDealer(n.Index, "unjustified complaints")
 			continue
 		}
 		allGood++
@@ -849,6 +1016,16 @@ func (d *DistKeyGenerator) ProcessJustifications(bundles []*JustificationBundle)
 		return nil, fmt.Errorf("process-justifications: only %d/%d valid deals - dkg abort", allGood, targetThreshold)
 	}
 
+	if d.c.Mode == GJKR {
+		// the set of qualified dealers is now fixed, they can reveal their
+		// Feldman commitments
+		for _, index := range d.evicted {
+			d.statuses.SetAll(index, false)
+		}
+		d.state = ExtractionPhase
+		return nil, nil
+	}
+
 	// otherwise it's all good - let's compute the result
 	return d.computeResult()
 }
@@ -1130,6 +1307,28 @@ func GetNonce() []byte {
 	return nonce[:]
 }
 
+// evictDealer excludes the dealer from the set of qualified dealers.
+func (d *DistKeyGenerator) evictDealer(dealer Index, reason string) {
+	if !contains(d.evicted, dealer) {
+		d.c.emit(event.NodeEvicted{Node: dealer, Dealer: true, Reason: reason})
+	}
+	d.evicted = append(d.evicted, dealer)
+}
+
+// evictHolder excludes the share holder from the new group.
+func (d *DistKeyGenerator) evictHolder(holder Index, reason string) {
+	if !contains(d.evictedHolders, holder) {
+		d.c.emit(event.NodeEvicted{Node: holder, Reason: reason})
+	}
+	d.evictedHolders = append(d.evictedHolders, holder)
+}
+
+// rejectDeal signals that the share of this node from the dealer is invalid,
+// which makes this node complain against the dealer.
+func (d *DistKeyGenerator) rejectDeal(dealer Index, reason string) {
+	d.c.emit(event.DealRejected{Dealer: dealer, ShareHolder: uint32(d.nidx), Reason: reason})
+}
+
 func (d *DistKeyGenerator) sign(p Packet) ([]byte, error) {
 	msg := p.Hash()
 	priv := d.c.Longterm
@@ -1156,6 +1355,28 @@ func (c *Config) Error(keyvals ...interface{}) {
 	}
 }
 
+func (c *Config) emit(e event.Event) {
+	event.Emit(c.Events, e)
+}
+
+// pickSecret picks the secret coefficient of a dealer in a fresh DKG from
+// the Reader of the config, combined or not with crypto/rand.
+func (c *Config) pickSecret() (secret kyber.Scalar, err error) {
+	randomStream := random.New()
+	// if the user provided a reader, use it alone or combined with crypto/rand
+	if c.Reader != nil && !c.UserReaderOnly {
+		randomStream = random.New(c.Reader, rand.Reader)
+	} else if c.Reader != nil && c.UserReaderOnly {
+		randomStream = random.New(c.Reader)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("error picking secret: %v", r)
+		}
+	}()
+	return c.Suite.Scalar().Pick(randomStream), nil
+}
+
 // CheckForDuplicates looks at the lits of node indices in the OldNodes and
 // NewNodes list. It returns an error if there is a duplicate in either list.
 // NOTE: It only looks at indices because it is plausible that one party may
@@ -1,15 +1,19 @@
 package dkg
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/rand"
+	"runtime"
 	"testing"
 
 	"github.com/drand/kyber"
 	"github.com/drand/kyber/group/edwards25519"
 	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/proof/dleq"
 	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/event"
 	"github.com/drand/kyber/sign/schnorr"
 	"github.com/drand/kyber/sign/tbls"
 	"github.com/drand/kyber/util/random"
@@ -143,9 +147,18 @@ func testResults(t *testing.T, suite Suite, thr, n int, results []*Result) {
 type MapDeal func([]*DealBundle) []*DealBundle
 type MapResponse func([]*ResponseBundle) []*ResponseBundle
 type MapJustif func([]*JustificationBundle) []*JustificationBundle
+type MapExtraction func([]*ExtractionBundle) []*ExtractionBundle
+type MapReconstruction func([]*ReconstructionBundle) []*ReconstructionBundle
 
 func RunDKG(t *testing.T, tns []*TestNode, conf Config,
 	dm MapDeal, rm MapResponse, jm MapJustif) []*Result {
+	return RunDKGExtraction(t, tns, conf, dm, rm, jm, nil, nil)
+}
+
+// RunDKGExtraction runs the DKG as RunDKG, and the extraction phases in GJKR
+// mode, in which case em can change the extraction bundles.
+func RunDKGExtraction(t *testing.T, tns []*TestNode, conf Config,
+	dm MapDeal, rm MapResponse, jm MapJustif, em MapExtraction, rcm MapReconstruction) []*Result {
 
 	SetupNodes(tns, &conf)
 	var deals []*DealBundle
@@ -174,6 +187,8 @@ func RunDKG(t *testing.T, tns []*TestNode, conf Config,
 
 	var justifs []*JustificationBundle
 	var results []*Result
+	// nodes that did not finish after the response phase
+	var pending []*TestNode
 	for _, node := range tns {
 		res, just, err := node.dkg.ProcessResponses(respBundles)
 		if !errors.Is(err, ErrEvicted) {
@@ -182,12 +197,14 @@ func RunDKG(t *testing.T, tns []*TestNode, conf Config,
 		}
 		if res != nil {
 			results = append(results, res)
+			continue
 		} else if just != nil {
 			justifs = append(justifs, just)
 		}
+		pending = append(pending, node)
 	}
 
-	if len(justifs) == 0 {
+	if len(pending) == 0 {
 		return results
 	}
 
@@ -195,18 +212,72 @@ func RunDKG(t *testing.T, tns []*TestNode, conf Config,
 		justifs = jm(justifs)
 	}
 
-	for _, node := range tns {
+	var qualified []*TestNode
+	for _, node := range pending {
 		res, err := node.dkg.ProcessJustifications(justifs)
 		if errors.Is(err, ErrEvicted) {
 			continue
 		}
 		require.NoError(t, err)
+		if conf.Mode == GJKR {
+			require.Nil(t, res)
+			qualified = append(qualified, node)
+			continue
+		}
+		require.NotNil(t, res)
+		results = append(results, res)
+	}
+	if conf.Mode != GJKR {
+		return results
+	}
+
+	var extracts []*ExtractionBundle
+	for _, node := range qualified {
+		e, err := node.dkg.Extraction()
+		require.NoError(t, err)
+		if e != nil {
+			extracts = append(extracts, e)
+		}
+	}
+	if em != nil {
+		extracts = em(extracts)
+	}
+	var recons []*ReconstructionBundle
+	// nodes that did not finish after the extraction phase
+	pending = nil
+	for _, node := range qualified {
+		res, recon, err := node.dkg.ProcessExtractions(extracts)
+		require.NoError(t, err)
+		if res != nil {
+			results = append(results, res)
+			continue
+		} else if recon != nil {
+			recons = append(recons, recon)
+		}
+		pending = append(pending, node)
+	}
+	if len(recons) == 0 {
+		return results
+	}
+	if rcm != nil {
+		recons = rcm(recons)
+	}
+	for _, node := range pending {
+		res, err := node.dkg.ProcessReconstructions(recons)
+		require.NoError(t, err)
 		require.NotNil(t, res)
 		results = append(results, res)
 	}
 	return results
 }
 
+// forEachMode runs the test in each mode of the protocol.
+func forEachMode(t *testing.T, test func(t *testing.T, mode Mode)) {
+	for _, mode := range []Mode{JointFeldman, GJKR} {
+		t.Run(mode.String(), func(t *testing.T) { test(t, mode) })
+	}
+}
+
 // This tests makes a dealer being evicted and checks if the dealer knows about the eviction
 // itself and quits the DKG
 func TestSelfEvictionDealer(t *testing.T) {
@@ -365,20 +436,23 @@ func TestDKGSkipIndex(t *testing.T) {
 
 }
 func TestDKGFull(t *testing.T) {
-	n := 5
-	thr := n
-	suite := edwards25519.NewBlakeSHA256Ed25519()
-	tns := GenerateTestNodes(suite, n)
-	list := NodesFromTest(tns)
-	conf := Config{
-		Suite:     suite,
-		NewNodes:  list,
-		Threshold: thr,
-		Auth:      schnorr.NewScheme(suite),
-	}
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 5
+		thr := n
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		list := NodesFromTest(tns)
+		conf := Config{
+			Suite:     suite,
+			NewNodes:  list,
+			Threshold: thr,
+			Auth:      schnorr.NewScheme(suite),
+			Mode:      mode,
+		}
 
-	results := RunDKG(t, tns, conf, nil, nil, nil)
-	testResults(t, suite, thr, n, results)
+		results := RunDKG(t, tns, conf, nil, nil, nil)
+		testResults(t, suite, thr, n, results)
+	})
 }
 
 func TestSelfEvictionShareHolder(t *testing.T) {
@@ -577,68 +651,362 @@ func TestDKGResharing(t *testing.T) {
 }
 
 func TestDKGThreshold(t *testing.T) {
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 5
+		thr := 4
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		list := NodesFromTest(tns)
+		conf := Config{
+			Suite:     suite,
+			NewNodes:  list,
+			Threshold: thr,
+			Auth:      schnorr.NewScheme(suite),
+			Mode:      mode,
+		}
+
+		dm := func(deals []*DealBundle) []*DealBundle {
+			// we make first dealer absent
+			deals = deals[1:]
+			require.Len(t, deals, n-1)
+			// we make the second dealer creating a invalid share for 3rd participant
+			deals[0].Deals[2].EncryptedShare = []byte("Another one bites the dust")
+			return deals
+		}
+		rm := func(resp []*ResponseBundle) []*ResponseBundle {
+			for _, bundle := range resp {
+				// first dealer should not see anything bad
+				require.NotEqual(t, 0, bundle.ShareIndex)
+			}
+			// we must find at least a complaint about node 0
+			require.True(t, IsDealerIncluded(resp, 0))
+			// if we are checking responses from node 2, then it must also
+			// include a complaint for node 1
+			require.True(t, IsDealerIncluded(resp, 1))
+			return resp
+		}
+		jm := func(justs []*JustificationBundle) []*JustificationBundle {
+			var found0 bool
+			var found1 bool
+			for _, bundle := range justs {
+				found0 = found0 || bundle.DealerIndex == 0
+				found1 = found1 || bundle.DealerIndex == 1
+			}
+			require.True(t, found0 && found1)
+			return justs
+		}
+		results := RunDKG(t, tns, conf, dm, rm, jm)
+		var filtered = results[:0]
+		for _, n := range tns {
+			if 0 == n.Index {
+				// node 0 is excluded by all others since he didn't even provide a
+				// deal at the first phase,i.e. it didn't even provide a public
+				// polynomial at the first phase.
+				continue
+			}
+			for _, res := range results {
+				if res.Key.Share.I != int(n.Index) {
+					continue
+				}
+				for _, nodeQual := range res.QUAL {
+					require.NotEqual(t, uint32(0), nodeQual.Index)
+				}
+				filtered = append(filtered, res)
+			}
+		}
+		testResults(t, suite, thr, n, filtered)
+	})
+}
+
+func TestDKGEvents(t *testing.T) {
 	n := 5
 	thr := 4
 	suite := edwards25519.NewBlakeSHA256Ed25519()
 	tns := GenerateTestNodes(suite, n)
 	list := NodesFromTest(tns)
+	var events []event.Event
 	conf := Config{
 		Suite:     suite,
 		NewNodes:  list,
 		Threshold: thr,
 		Auth:      schnorr.NewScheme(suite),
+		Events:    event.HandlerFunc(func(e event.Event) { events = append(events, e) }),
 	}
-
 	dm := func(deals []*DealBundle) []*DealBundle {
-		// we make first dealer absent
+		// the first dealer is absent, and the second one gives an invalid
+		// share to the fourth participant
 		deals = deals[1:]
-		require.Len(t, deals, n-1)
-		// we make the second dealer creating a invalid share for 3rd participant
 		deals[0].Deals[2].EncryptedShare = []byte("Another one bites the dust")
 		return deals
 	}
-	rm := func(resp []*ResponseBundle) []*ResponseBundle {
-		for _, bundle := range resp {
-			// first dealer should not see anything bad
-			require.NotEqual(t, 0, bundle.ShareIndex)
-		}
-		// we must find at least a complaint about node 0
-		require.True(t, IsDealerIncluded(resp, 0))
-		// if we are checking responses from node 2, then it must also
-		// include a complaint for node 1
-		require.True(t, IsDealerIncluded(resp, 1))
-		return resp
-	}
-	jm := func(justs []*JustificationBundle) []*JustificationBundle {
-		var found0 bool
-		var found1 bool
-		for _, bundle := range justs {
-			found0 = found0 || bundle.DealerIndex == 0
-			found1 = found1 || bundle.DealerIndex == 1
-		}
-		require.True(t, found0 && found1)
-		return justs
-	}
-	results := RunDKG(t, tns, conf, dm, rm, jm)
-	var filtered = results[:0]
-	for _, n := range tns {
-		if 0 == n.Index {
-			// node 0 is excluded by all others since he didn't even provide a
-			// deal at the first phase,i.e. it didn't even provide a public
-			// polynomial at the first phase.
-			continue
+	RunDKG(t, tns, conf, dm, nil, nil)
+
+	require.Contains(t, events, event.DealReceived{Dealer: 1, ShareHolder: 4})
+	require.Contains(t, events, event.DealRejected{Dealer: 1, ShareHolder: 3, Reason: "share decryption failed"})
+	require.Contains(t, events, event.ComplaintIssued{Dealer: 0, ShareHolder: 1})
+	require.Contains(t, events, event.ComplaintIssued{Dealer: 1, ShareHolder: 3})
+	require.Contains(t, events, event.ComplaintReceived{Dealer: 1, ShareHolder: 3})
+	require.Contains(t, events, event.JustificationAccepted{Dealer: 1, ShareHolder: 3})
+	require.Contains(t, events, event.NodeEvicted{Node: 0, Dealer: true, Reason: "too many complaints"})
+	for _, e := range events {
+		if e, ok := e.(event.NodeEvicted); ok {
+			require.Equal(t, uint32(0), e.Node)
 		}
+	}
+}
+
+// taggedEncrypter prefixes the ECIES ciphertexts with a tag and counts its
+// calls.
+type taggedEncrypter struct {
+	ECIESEncrypter
+	encrypted, decrypted int
+}
+
+var encrypterTag = []byte("tagged")
+
+func (e *taggedEncrypter) Encrypt(s Suite, public kyber.Point, share []byte) ([]byte, error) {
+	e.encrypted++
+	cipher, err := e.ECIESEncrypter.Encrypt(s, public, share)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, encrypterTag...), cipher...), nil
+}
+
+func (e *taggedEncrypter) Decrypt(s Suite, private kyber.Scalar, cipher []byte) ([]byte, error) {
+	e.decrypted++
+	if !bytes.HasPrefix(cipher, encrypterTag) {
+		return nil, errors.New("missing tag")
+	}
+	return e.ECIESEncrypter.Decrypt(s, private, cipher[len(encrypterTag):])
+}
+
+func TestDKGDealEncrypter(t *testing.T) {
+	n := 5
+	thr := 4
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	enc := new(taggedEncrypter)
+	conf := Config{
+		Suite:         suite,
+		NewNodes:      NodesFromTest(tns),
+		Threshold:     thr,
+		Auth:          schnorr.NewScheme(suite),
+		DealEncrypter: enc,
+	}
+	dm := func(deals []*DealBundle) []*DealBundle {
+		for _, d := range deals {
+			for _, deal := range d.Deals {
+				require.True(t, bytes.HasPrefix(deal.EncryptedShare, encrypterTag))
+			}
+		}
+		return deals
+	}
+	results := RunDKG(t, tns, conf, dm, nil, nil)
+	testResults(t, suite, thr, n, results)
+	// the dealers do not encrypt their own share
+	require.Equal(t, n*(n-1), enc.encrypted)
+	require.Equal(t, n*(n-1), enc.decrypted)
+
+	// nodes using the default encryption can't decrypt the tagged shares
+	tns = GenerateTestNodes(suite, n)
+	conf.NewNodes = NodesFromTest(tns)
+	SetupNodes(tns, &conf)
+	var deals []*DealBundle
+	for _, node := range tns {
+		d, err := node.dkg.Deals()
+		require.NoError(t, err)
+		deals = append(deals, d)
+	}
+	tns[0].dkg.c.DealEncrypter = nil
+	resp, err := tns[0].dkg.ProcessDeals(deals)
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, n-1)
+	for _, r := range resp.Responses {
+		require.Equal(t, Complaint, r.Status)
+	}
+}
+
+func TestDKGVerifiableComplaints(t *testing.T) {
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 5
+		thr := 3
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		var events []event.Event
+		conf := Config{
+			Suite:                suite,
+			NewNodes:             NodesFromTest(tns),
+			Threshold:            thr,
+			Auth:                 schnorr.NewScheme(suite),
+			Mode:                 mode,
+			VerifiableComplaints: true,
+			Events:               event.HandlerFunc(func(e event.Event) { events = append(events, e) }),
+		}
+		dm := func(deals []*DealBundle) []*DealBundle {
+			// the second dealer gives a well encrypted but invalid share to
+			// the fourth participant
+			msg, _ := suite.Scalar().Pick(random.New()).MarshalBinary()
+			if mode == GJKR {
+				msg = append(msg, msg...)
+			}
+			cipher, err := ECIESEncrypter{}.Encrypt(suite, tns[3].Public, msg)
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), deals[1].Deals[2].ShareIndex)
+			deals[1].Deals[2].EncryptedShare = cipher
+			return deals
+		}
+		rm := func(resps []*ResponseBundle) []*ResponseBundle {
+			require.Len(t, resps, 1)
+			require.Len(t, resps[0].Responses, 1)
+			require.NotNil(t, resps[0].Responses[0].Proof)
+			return resps
+		}
+		jm := func(justifs []*JustificationBundle) []*JustificationBundle {
+			// the complaint is proven, so the dealer has nothing to justify
+			require.Empty(t, justifs)
+			return justifs
+		}
+		results := RunDKG(t, tns, conf, dm, rm, jm)
+		var filtered []*Result
 		for _, res := range results {
-			if res.Key.Share.I != int(n.Index) {
+			if res.Key.Share.I == 1 {
+				// the cheating dealer has its own view
 				continue
 			}
-			for _, nodeQual := range res.QUAL {
-				require.NotEqual(t, uint32(0), nodeQual.Index)
+			require.Len(t, res.QUAL, n-1)
+			for _, node := range res.QUAL {
+				require.NotEqual(t, uint32(1), node.Index)
 			}
 			filtered = append(filtered, res)
 		}
+		require.Len(t, filtered, n-1)
+		testResults(t, suite, thr, n, filtered)
+		require.Contains(t, events, event.NodeEvicted{Node: 1, Dealer: true, Reason: "proven complaint"})
+		require.NotContains(t, events, event.JustificationAccepted{Dealer: 1, ShareHolder: 3})
+	})
+}
+
+func TestDKGFalseComplaints(t *testing.T) {
+	n := 6
+	thr := 3
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	var events []event.Event
+	conf := Config{
+		Suite:                suite,
+		NewNodes:             NodesFromTest(tns),
+		Threshold:            thr,
+		Auth:                 schnorr.NewScheme(suite),
+		VerifiableComplaints: true,
+		Events:               event.HandlerFunc(func(e event.Event) { events = append(events, e) }),
+	}
+	var deals []*DealBundle
+	dm := func(d []*DealBundle) []*DealBundle {
+		deals = d
+		return d
+	}
+	// complaint of the share holder against the first dealer, with a proof
+	// made with its key
+	complaint := func(holder Index, key kyber.Scalar) *ResponseBundle {
+		var cipher []byte
+		for _, deal := range deals[0].Deals {
+			if deal.ShareIndex == holder {
+				cipher = deal.EncryptedShare
+			}
+		}
+		R, err := ECIESEncrypter{}.Ephemeral(suite, cipher)
+		require.NoError(t, err)
+		proof, _, dh, err := dleq.NewDLEQProof(suite, suite.Point().Base(), R, key)
+		require.NoError(t, err)
+		return &ResponseBundle{
+			ShareIndex: holder,
+			Responses: []Response{{
+				DealerIndex: 0,
+				Status:      Complaint,
+				Proof:       &ComplaintProof{DHKey: dh, Proof: *proof},
+			}},
+			SessionID: deals[0].SessionID,
+		}
 	}
+	rm := func(resps []*ResponseBundle) []*ResponseBundle {
+		require.Empty(t, resps)
+		withoutProof := complaint(2, tns[2].Private)
+		withoutProof.Responses[0].Proof = nil
+		return []*ResponseBundle{
+			withoutProof,
+			complaint(3, tns[3].Private),
+			complaint(4, tns[3].Private),
+		}
+	}
+	results := RunDKG(t, tns, conf, dm, rm, nil)
+	var filtered []*Result
+	for _, res := range results {
+		if res.Key.Share.I >= 2 && res.Key.Share.I <= 4 {
+			continue
+		}
+		require.Len(t, res.QUAL, n-3)
+		filtered = append(filtered, res)
+	}
+	require.Len(t, filtered, n-3)
 	testResults(t, suite, thr, n, filtered)
+	require.Contains(t, events, event.NodeEvicted{Node: 2, Reason: "complaint without proof"})
+	require.Contains(t, events, event.NodeEvicted{Node: 3, Reason: "complaint against a valid share"})
+	require.Contains(t, events, event.NodeEvicted{Node: 4, Reason: "invalid complaint proof"})
+	for _, e := range events {
+		if e, ok := e.(event.NodeEvicted); ok {
+			require.False(t, e.Dealer)
+		}
+	}
+}
+
+func TestDKGWorkers(t *testing.T) {
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 7
+		thr := 4
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		run := func(workers int) ([]*Result, []event.Event) {
+			var events []event.Event
+			conf := Config{
+				Suite:                suite,
+				NewNodes:             NodesFromTest(tns),
+				Threshold:            thr,
+				Auth:                 schnorr.NewScheme(suite),
+				Mode:                 mode,
+				VerifiableComplaints: true,
+				Workers:              workers,
+				Events:               event.HandlerFunc(func(e event.Event) { events = append(events, e) }),
+			}
+			dm := func(deals []*DealBundle) []*DealBundle {
+				// the second dealer gives an invalid share to the fourth
+				// participant, the third one doesn't give any share to the
+				// fifth one and doesn't justify it
+				deals[1].Deals[2].EncryptedShare = []byte("Smooth Criminal")
+				deals[2].Deals = append(deals[2].Deals[:3], deals[2].Deals[4:]...)
+				return deals
+			}
+			results := RunDKG(t, tns, conf, dm, nil, nil)
+			return results, events
+		}
+		results, events := run(0)
+		presults, pevents := run(8)
+		require.Equal(t, events, pevents)
+		require.Len(t, presults, len(results))
+		for i, res := range results {
+			require.Equal(t, res.QUAL, presults[i].QUAL)
+		}
+		require.Contains(t, events, event.NodeEvicted{Node: 1, Dealer: true, Reason: "proven complaint"})
+		require.Contains(t, events, event.NodeEvicted{Node: 2, Dealer: true, Reason: "unjustified complaints"})
+		// the cheating dealers have their own view
+		var filtered []*Result
+		for _, res := range presults {
+			if res.Key.Share.I != 1 && res.Key.Share.I != 2 {
+				filtered = append(filtered, res)
+			}
+		}
+		testResults(t, suite, thr, n, filtered)
+	})
 }
 
 func TestDKGResharingFast(t *testing.T) {
@@ -876,69 +1244,72 @@ func TestDKGAbsentAuth(t *testing.T) {
 }
 
 func TestDKGNonceInvalidEviction(t *testing.T) {
-	n := 7
-	thr := 4
-	suite := edwards25519.NewBlakeSHA256Ed25519()
-	tns := GenerateTestNodes(suite, n)
-	list := NodesFromTest(tns)
-	conf := Config{
-		Suite:     suite,
-		NewNodes:  list,
-		Threshold: thr,
-		Auth:      schnorr.NewScheme(suite),
-	}
-
-	genPublic := func() []kyber.Point {
-		points := make([]kyber.Point, thr)
-		for i := 0; i < thr; i++ {
-			points[i] = suite.Point().Pick(random.New())
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 7
+		thr := 4
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		list := NodesFromTest(tns)
+		conf := Config{
+			Suite:     suite,
+			NewNodes:  list,
+			Threshold: thr,
+			Auth:      schnorr.NewScheme(suite),
+			Mode:      mode,
 		}
-		return points
-	}
 
-	dm := func(deals []*DealBundle) []*DealBundle {
-		deals[0].SessionID = []byte("Beat It")
-		require.Equal(t, deals[0].DealerIndex, Index(0))
-		// change the public polynomial so it trigggers a response and a
-		// justification
-		deals[1].Public = genPublic()
-		require.Equal(t, deals[1].DealerIndex, Index(1))
-		return deals
-	}
-	rm := func(resp []*ResponseBundle) []*ResponseBundle {
-		for _, bundle := range resp {
-			for _, r := range bundle.Responses {
-				// he's evicted so there's not even a complaint
-				require.NotEqual(t, 0, r.DealerIndex)
+		genPublic := func() []kyber.Point {
+			points := make([]kyber.Point, thr)
+			for i := 0; i < thr; i++ {
+				points[i] = suite.Point().Pick(random.New())
 			}
-			if bundle.ShareIndex == 2 {
-				bundle.SessionID = []byte("Billie Jean")
+			return points
+		}
+
+		dm := func(deals []*DealBundle) []*DealBundle {
+			deals[0].SessionID = []byte("Beat It")
+			require.Equal(t, deals[0].DealerIndex, Index(0))
+			// change the public polynomial so it trigggers a response and a
+			// justification
+			deals[1].Public = genPublic()
+			require.Equal(t, deals[1].DealerIndex, Index(1))
+			return deals
+		}
+		rm := func(resp []*ResponseBundle) []*ResponseBundle {
+			for _, bundle := range resp {
+				for _, r := range bundle.Responses {
+					// he's evicted so there's not even a complaint
+					require.NotEqual(t, 0, r.DealerIndex)
+				}
+				if bundle.ShareIndex == 2 {
+					bundle.SessionID = []byte("Billie Jean")
+				}
 			}
+			return resp
+		}
+		jm := func(just []*JustificationBundle) []*JustificationBundle {
+			require.Len(t, just, 1)
+			just[0].SessionID = []byte("Free")
+			return just
 		}
-		return resp
-	}
-	jm := func(just []*JustificationBundle) []*JustificationBundle {
-		require.Len(t, just, 1)
-		just[0].SessionID = []byte("Free")
-		return just
-	}
 
-	results := RunDKG(t, tns, conf, dm, rm, jm)
-	// make sure the first, second, and third node are not here
-	isEvicted := func(i Index) bool {
-		return i == 0 || i == 1 || i == 2
-	}
-	filtered := results[:0]
-	for _, r := range results {
-		if isEvicted(Index(r.Key.Share.I)) {
-			continue
+		results := RunDKG(t, tns, conf, dm, rm, jm)
+		// make sure the first, second, and third node are not here
+		isEvicted := func(i Index) bool {
+			return i == 0 || i == 1 || i == 2
 		}
-		require.NotContains(t, r.QUAL, Index(0))
-		require.NotContains(t, r.QUAL, Index(1))
-		require.NotContains(t, r.QUAL, Index(2))
-		filtered = append(filtered, r)
-	}
-	testResults(t, suite, thr, n, filtered)
+		filtered := results[:0]
+		for _, r := range results {
+			if isEvicted(Index(r.Key.Share.I)) {
+				continue
+			}
+			require.NotContains(t, r.QUAL, Index(0))
+			require.NotContains(t, r.QUAL, Index(1))
+			require.NotContains(t, r.QUAL, Index(2))
+			filtered = append(filtered, r)
+		}
+		testResults(t, suite, thr, n, filtered)
+	})
 }
 
 func TestDKGInvalidResponse(t *testing.T) {
@@ -1025,8 +1396,54 @@ func TestDKGInvalidResponse(t *testing.T) {
 }
 
 func TestDKGTooManyComplaints(t *testing.T) {
-	n := 5
-	thr := 3
+	forEachMode(t, func(t *testing.T, mode Mode) {
+		n := 5
+		thr := 3
+		suite := edwards25519.NewBlakeSHA256Ed25519()
+		tns := GenerateTestNodes(suite, n)
+		list := NodesFromTest(tns)
+		conf := Config{
+			Suite:     suite,
+			NewNodes:  list,
+			Threshold: thr,
+			Auth:      schnorr.NewScheme(suite),
+			Mode:      mode,
+		}
+
+		dm := func(deals []*DealBundle) []*DealBundle {
+			// we make the second dealer creating a invalid share for too many
+			// participants
+			for i := 0; i <= thr; i++ {
+				deals[0].Deals[i].EncryptedShare = []byte("Another one bites the dust")
+			}
+			return deals
+		}
+		results := RunDKG(t, tns, conf, dm, nil, nil)
+		var filtered = results[:0]
+		for _, n := range tns {
+			if 0 == n.Index {
+				// node 0 is excluded by all others since he didn't even provide a
+				// deal at the first phase,i.e. it didn't even provide a public
+				// polynomial at the first phase.
+				continue
+			}
+			for _, res := range results {
+				if res.Key.Share.I != int(n.Index) {
+					continue
+				}
+				for _, nodeQual := range res.QUAL {
+					require.NotEqual(t, uint32(0), nodeQual.Index)
+				}
+				filtered = append(filtered, res)
+			}
+		}
+		testResults(t, suite, thr, n, filtered)
+	})
+}
+
+func TestGJKRReconstruction(t *testing.T) {
+	n := 6
+	thr := 4
 	suite := edwards25519.NewBlakeSHA256Ed25519()
 	tns := GenerateTestNodes(suite, n)
 	list := NodesFromTest(tns)
@@ -1035,36 +1452,162 @@ func TestDKGTooManyComplaints(t *testing.T) {
 		NewNodes:  list,
 		Threshold: thr,
 		Auth:      schnorr.NewScheme(suite),
+		Mode:      GJKR,
 	}
 
-	dm := func(deals []*DealBundle) []*DealBundle {
-		// we make the second dealer creating a invalid share for too many
-		// participants
-		for i := 0; i <= thr; i++ {
-			deals[0].Deals[i].EncryptedShare = []byte("Another one bites the dust")
+	em := func(extracts []*ExtractionBundle) []*ExtractionBundle {
+		require.Len(t, extracts, n)
+		var filtered []*ExtractionBundle
+		for _, e := range extracts {
+			switch e.DealerIndex {
+			case 1:
+				// dealer 1 does not reveal its commitments
+				continue
+			case 2:
+				// dealer 2 reveals commitments of another polynomial
+				e.Public[0] = suite.Point().Pick(random.New())
+			}
+			filtered = append(filtered, e)
 		}
-		return deals
+		return filtered
 	}
-	results := RunDKG(t, tns, conf, dm, nil, nil)
-	var filtered = results[:0]
-	for _, n := range tns {
-		if 0 == n.Index {
-			// node 0 is excluded by all others since he didn't even provide a
-			// deal at the first phase,i.e. it didn't even provide a public
-			// polynomial at the first phase.
+	results := RunDKGExtraction(t, tns, conf, nil, nil, nil, em, nil)
+	require.Len(t, results, n)
+	testResults(t, suite, thr, n, results)
+	// the contributions of dealers 1 and 2 are still part of the key
+	require.Len(t, results[0].QUAL, n)
+	expected := suite.Point().Null()
+	for _, node := range tns {
+		expected.Add(expected, suite.Point().Mul(node.dkg.dpriv.Secret(), nil))
+	}
+	require.True(t, expected.Equal(results[0].Key.Public()))
+}
+
+func TestGJKRExtractionDuplicates(t *testing.T) {
+	n := 6
+	thr := 4
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	list := NodesFromTest(tns)
+	conf := Config{
+		Suite:     suite,
+		NewNodes:  list,
+		Threshold: thr,
+		Auth:      schnorr.NewScheme(suite),
+		Mode:      GJKR,
+	}
+
+	em := func(extracts []*ExtractionBundle) []*ExtractionBundle {
+		require.Len(t, extracts, n)
+		var out []*ExtractionBundle
+		for _, e := range extracts {
+			// every bundle is rebroadcasted
+			out = append(out, e, e)
+			switch e.DealerIndex {
+			case 1:
+				// anyone posts a forged extraction of dealer 1 first
+				forged := *e
+				forged.Public = append([]kyber.Point{suite.Point().Pick(random.New())}, e.Public[1:]...)
+				out = append([]*ExtractionBundle{&forged}, out...)
+			case 2:
+				// dealer 2 signs another valid extraction
+				dealer := tns[2].dkg
+				_, pedersen := dealer.dpub.Info()
+				other := *e
+				other.Challenge, other.Response, other.BlindingResponse = dealer.extractionProof(e.DealerIndex, pedersen, e.Public)
+				sig, err := dealer.sign(&other)
+				require.NoError(t, err)
+				other.Signature = sig
+				out = append(out, &other)
+			}
+		}
+		return out
+	}
+	results := RunDKGExtraction(t, tns, conf, nil, nil, nil, em, nil)
+	require.Len(t, results, n)
+	testResults(t, suite, thr, n, results)
+	// only the equivocating dealer is reconstructed
+	for _, node := range tns {
+		if node.Index == 2 {
+			require.Empty(t, node.dkg.reconstruct)
 			continue
 		}
-		for _, res := range results {
-			if res.Key.Share.I != int(n.Index) {
-				continue
+		require.Equal(t, []Index{2}, node.dkg.reconstruct)
+	}
+}
+
+func TestGJKRReconstructionForged(t *testing.T) {
+	n := 5
+	thr := 4
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	list := NodesFromTest(tns)
+	conf := Config{
+		Suite:     suite,
+		NewNodes:  list,
+		Threshold: thr,
+		Auth:      schnorr.NewScheme(suite),
+		Mode:      GJKR,
+	}
+
+	em := func(extracts []*ExtractionBundle) []*ExtractionBundle {
+		var filtered []*ExtractionBundle
+		for _, e := range extracts {
+			// dealer 1 does not reveal its commitments
+			if e.DealerIndex != 1 {
+				filtered = append(filtered, e)
 			}
-			for _, nodeQual := range res.QUAL {
-				require.NotEqual(t, uint32(0), nodeQual.Index)
+		}
+		return filtered
+	}
+	rcm := func(recons []*ReconstructionBundle) []*ReconstructionBundle {
+		var forged []*ReconstructionBundle
+		for i, r := range recons {
+			// the bundles of the holders are preceded by an unsigned bundle
+			// or by a bundle of another session in their name
+			f := *r
+			if i%2 == 0 {
+				f.Signature = nil
+			} else {
+				f.SessionID = []byte("another session")
 			}
-			filtered = append(filtered, res)
+			forged = append(forged, &f)
 		}
+		return append(forged, recons...)
 	}
-	testResults(t, suite, thr, n, filtered)
+	results := RunDKGExtraction(t, tns, conf, nil, nil, nil, em, rcm)
+	require.Len(t, results, n)
+	testResults(t, suite, thr, n, results)
+	require.Len(t, results[0].QUAL, n)
+}
+
+func TestGJKRConfig(t *testing.T) {
+	n := 5
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	list := NodesFromTest(tns)
+	conf := &Config{
+		Suite:    suite,
+		NewNodes: list,
+		Longterm: tns[0].Private,
+		Auth:     schnorr.NewScheme(suite),
+		Nonce:    GetNonce(),
+		Mode:     GJKR,
+		FastSync: true,
+	}
+	_, err := NewDistKeyHandler(conf)
+	require.Error(t, err)
+
+	conf.FastSync = false
+	conf.OldNodes = list
+	conf.OldThreshold = MinimumT(n)
+	conf.PublicCoeffs = []kyber.Point{suite.Point().Base()}
+	_, err = NewDistKeyHandler(conf)
+	require.Error(t, err)
+
+	conf.Mode = Mode(42)
+	_, err = NewDistKeyHandler(conf)
+	require.Error(t, err)
 }
 
 func TestConfigDuplicate(t *testing.T) {
@@ -1111,3 +1654,205 @@ func TestMinimumT(t *testing.T) {
 		})
 	}
 }
+
+// benchWorkers are the numbers of workers the benchmarks compare.
+func benchWorkers() []int {
+	if runtime.NumCPU() == 1 {
+		return []int{1}
+	}
+	return []int{1, runtime.NumCPU()}
+}
+
+func BenchmarkDeals(b *testing.B) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	for _, n := range []int{128, 256, 512} {
+		tns := GenerateTestNodes(suite, n)
+		for _, workers := range benchWorkers() {
+			conf := Config{
+				Suite:     suite,
+				NewNodes:  NodesFromTest(tns),
+				Threshold: MinimumT(n),
+				Auth:      schnorr.NewScheme(suite),
+				Workers:   workers,
+			}
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					SetupNodes(tns[:1], &conf)
+					b.StartTimer()
+					_, err := tns[0].dkg.Deals()
+					require.NoError(b, err)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkProcessDeals(b *testing.B) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	for _, n := range []int{128, 256, 512} {
+		tns := GenerateTestNodes(suite, n)
+		conf := Config{
+			Suite:     suite,
+			NewNodes:  NodesFromTest(tns),
+			Threshold: MinimumT(n),
+			Auth:      schnorr.NewScheme(suite),
+			Workers:   runtime.NumCPU(),
+		}
+		SetupNodes(tns, &conf)
+		// only the deals of the first node matter
+		deals := make([]*DealBundle, n)
+		for i, node := range tns {
+			msg, _ := node.dkg.dpriv.Eval(0).V.MarshalBinary()
+			cipher, err := ECIESEncrypter{}.Encrypt(suite, tns[0].Public, msg)
+			require.NoError(b, err)
+			_, commits := node.dkg.dpub.Info()
+			deals[i] = &DealBundle{
+				DealerIndex: node.Index,
+				Deals:       []Deal{{ShareIndex: 0, EncryptedShare: cipher}},
+				Public:      commits,
+				SessionID:   node.dkg.c.Nonce,
+			}
+		}
+		for _, workers := range benchWorkers() {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					c := *tns[0].dkg.c
+					c.Workers = workers
+					d, err := NewDistKeyHandler(&c)
+					require.NoError(b, err)
+					_, err = d.Deals()
+					require.NoError(b, err)
+					b.StartTimer()
+					resp, err := d.ProcessDeals(deals)
+					require.NoError(b, err)
+					require.Nil(b, resp)
+				}
+			})
+		}
+	}
+}
+
+// benchComplaints returns the config of the first of n nodes with verifiable
+// complaints, and the deals it receives, in which each dealer 1 to k gives an
+// invalid share to the holder of the same index, with the proven complaints
+// of these holders and the justifications of the dealers. k is the largest
+// number of dealers that can be evicted without aborting the DKG.
+func benchComplaints(b *testing.B, n int) (*Config, []*DealBundle, []*ResponseBundle, []*JustificationBundle) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	conf := Config{
+		Suite:                suite,
+		NewNodes:             NodesFromTest(tns),
+		Threshold:            MinimumT(n),
+		Auth:                 schnorr.NewScheme(suite),
+		VerifiableComplaints: true,
+		Workers:              runtime.NumCPU(),
+	}
+	SetupNodes(tns, &conf)
+	encrypt := func(to kyber.Point, s kyber.Scalar) []byte {
+		msg, err := s.MarshalBinary()
+		require.NoError(b, err)
+		cipher, err := ECIESEncrypter{}.Encrypt(suite, to, msg)
+		require.NoError(b, err)
+		return cipher
+	}
+	nonce := tns[0].dkg.c.Nonce
+	k := n - MinimumT(n)
+	deals := make([]*DealBundle, n)
+	var responses []*ResponseBundle
+	var justifs []*JustificationBundle
+	for i, node := range tns {
+		_, commits := node.dkg.dpub.Info()
+		deals[i] = &DealBundle{
+			DealerIndex: node.Index,
+			Deals:       []Deal{{ShareIndex: 0, EncryptedShare: encrypt(tns[0].Public, node.dkg.dpriv.Eval(0).V)}},
+			Public:      commits,
+			SessionID:   nonce,
+		}
+		if i == 0 || i > k {
+			continue
+		}
+		cipher := encrypt(node.Public, suite.Scalar().Pick(random.New()))
+		deals[i].Deals = append(deals[i].Deals, Deal{ShareIndex: node.Index, EncryptedShare: cipher})
+		R, err := ECIESEncrypter{}.Ephemeral(suite, cipher)
+		require.NoError(b, err)
+		proof, _, dh, err := dleq.NewDLEQProof(suite, suite.Point().Base(), R, node.Private)
+		require.NoError(b, err)
+		responses = append(responses, &ResponseBundle{
+			ShareIndex: node.Index,
+			Responses: []Response{{
+				DealerIndex: node.Index,
+				Status:      Complaint,
+				Proof:       &ComplaintProof{DHKey: dh, Proof: *proof},
+			}},
+			SessionID: nonce,
+		})
+		justifs = append(justifs, &JustificationBundle{
+			DealerIndex: node.Index,
+			Justifications: []Justification{{
+				ShareIndex: node.Index,
+				Share:      node.dkg.dpriv.Eval(int(node.Index)).V,
+			}},
+			SessionID: nonce,
+		})
+	}
+	return tns[0].dkg.c, deals, responses, justifs
+}
+
+// benchResponsePhase returns a new generator of the config that processed
+// the deals, with the workers of the config.
+func benchResponsePhase(b *testing.B, c Config, deals []*DealBundle) *DistKeyGenerator {
+	d, err := NewDistKeyHandler(&c)
+	require.NoError(b, err)
+	_, err = d.Deals()
+	require.NoError(b, err)
+	resp, err := d.ProcessDeals(deals)
+	require.NoError(b, err)
+	require.Nil(b, resp)
+	return d
+}
+
+func BenchmarkProcessResponses(b *testing.B) {
+	for _, n := range []int{128, 256, 512} {
+		conf, deals, responses, _ := benchComplaints(b, n)
+		for _, workers := range benchWorkers() {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					d := benchResponsePhase(b, *conf, deals)
+					d.c.Workers = workers
+					b.StartTimer()
+					_, _, err := d.ProcessResponses(responses)
+					require.NoError(b, err)
+					require.Len(b, d.evicted, len(responses))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkProcessJustifications(b *testing.B) {
+	for _, n := range []int{128, 256, 512} {
+		conf, deals, responses, justifs := benchComplaints(b, n)
+		// the complaints are justified instead of proven
+		c := *conf
+		c.VerifiableComplaints = false
+		for _, workers := range benchWorkers() {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					d := benchResponsePhase(b, c, deals)
+					_, _, err := d.ProcessResponses(responses)
+					require.NoError(b, err)
+					d.c.Workers = workers
+					b.StartTimer()
+					res, err := d.ProcessJustifications(justifs)
+					require.NoError(b, err)
+					require.NotNil(b, res)
+				}
+			})
+		}
+	}
+}
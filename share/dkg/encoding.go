@@ -0,0 +1,550 @@
+package dkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/internal/subgroup"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/pvss"
+)
+
+// EncodingVersion is the version of the binary encoding of the packets,
+// distributed key shares and results.
+const EncodingVersion byte = 1
+
+// Types of the encoded values.
+const (
+	typeDeal byte = iota + 1
+	typeResponse
+	typeJustification
+	typeExtraction
+	typeReconstruction
+	typePVSSDeal
+	typeDistKeyShare
+	typeResult
+)
+
+var errShortBuffer = errors.New("dkg: encoding too short")
+
+// EncodePacket returns the canonical binary encoding of the packet. The
+// encoding starts with the version of the encoding, the type of the packet
+// and the name of the group, followed by the fields of the packet in order.
+// Integers are encoded in big endian on 4 bytes, byte slices, points and
+// scalars are prefixed by their length, lists by their number of elements and
+// optional scalars by a presence byte.
+func EncodePacket(g kyber.Group, p Packet) ([]byte, error) {
+	var w *encoder
+	switch b := p.(type) {
+	case *DealBundle:
+		w = newEncoder(g, typeDeal)
+		w.uint32(b.DealerIndex)
+		w.uint32(uint32(len(b.Deals)))
+		for _, d := range b.Deals {
+			w.uint32(d.ShareIndex)
+			w.bytes(d.EncryptedShare)
+		}
+		w.points(b.Public)
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	case *ResponseBundle:
+		w = newEncoder(g, typeResponse)
+		w.uint32(b.ShareIndex)
+		w.uint32(uint32(len(b.Responses)))
+		for _, r := range b.Responses {
+			w.uint32(r.DealerIndex)
+			w.bool(r.Status)
+			w.bool(r.Proof != nil)
+			if r.Proof != nil {
+				w.point(r.Proof.DHKey)
+				w.scalar(r.Proof.Proof.C)
+				w.scalar(r.Proof.Proof.R)
+				w.point(r.Proof.Proof.VG)
+				w.point(r.Proof.Proof.VH)
+			}
+		}
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	case *JustificationBundle:
+		w = newEncoder(g, typeJustification)
+		w.uint32(b.DealerIndex)
+		w.uint32(uint32(len(b.Justifications)))
+		for _, j := range b.Justifications {
+			w.uint32(j.ShareIndex)
+			w.scalar(j.Share)
+			w.optScalar(j.Blinding)
+		}
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	case *ExtractionBundle:
+		w = newEncoder(g, typeExtraction)
+		w.uint32(b.DealerIndex)
+		w.points(b.Public)
+		w.scalar(b.Challenge)
+		w.scalar(b.Response)
+		w.scalar(b.BlindingResponse)
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	case *ReconstructionBundle:
+		w = newEncoder(g, typeReconstruction)
+		w.uint32(b.ShareIndex)
+		w.uint32(uint32(len(b.Shares)))
+		for _, s := range b.Shares {
+			w.uint32(s.DealerIndex)
+			w.scalar(s.Share)
+			w.scalar(s.Blinding)
+		}
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	case *PVSSDealBundle:
+		w = newEncoder(g, typePVSSDeal)
+		w.uint32(b.DealerIndex)
+		w.points(b.Public)
+		w.uint32(uint32(len(b.EncShares)))
+		for _, s := range b.EncShares {
+			if s == nil {
+				w.fail(errors.New("dkg: nil encrypted share"))
+				break
+			}
+			w.index(s.S.I)
+			w.point(s.S.V)
+			w.scalar(s.P.C)
+			w.scalar(s.P.R)
+			w.point(s.P.VG)
+			w.point(s.P.VH)
+		}
+		w.bytes(b.SessionID)
+		w.bytes(b.Signature)
+	default:
+		return nil, fmt.Errorf("dkg: cannot encode packet of type %T", p)
+	}
+	return w.finish()
+}
+
+// DecodePacket decodes a packet encoded with EncodePacket for the same group.
+// It returns an error if the encoding is for another version or group, if it
+// is followed by trailing bytes, or if a point is not a valid element of the
+// group.
+func DecodePacket(g kyber.Group, buf []byte) (Packet, error) {
+	r, typ := newDecoder(g, buf)
+	var p Packet
+	switch typ {
+	case typeDeal:
+		b := &DealBundle{DealerIndex: r.uint32()}
+		n := r.count(8)
+		for i := 0; i < n; i++ {
+			b.Deals = append(b.Deals, Deal{
+				ShareIndex:     r.uint32(),
+				EncryptedShare: r.bytes(),
+			})
+		}
+		b.Public = r.points()
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	case typeResponse:
+		b := &ResponseBundle{ShareIndex: r.uint32()}
+		n := r.count(6)
+		for i := 0; i < n; i++ {
+			resp := Response{
+				DealerIndex: r.uint32(),
+				Status:      r.bool(),
+			}
+			if r.bool() {
+				resp.Proof = &ComplaintProof{DHKey: r.point()}
+				resp.Proof.Proof = dleq.Proof{C: r.scalar(), R: r.scalar(), VG: r.point(), VH: r.point()}
+			}
+			b.Responses = append(b.Responses, resp)
+		}
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	case typeJustification:
+		b := &JustificationBundle{DealerIndex: r.uint32()}
+		n := r.count(9)
+		for i := 0; i < n; i++ {
+			b.Justifications = append(b.Justifications, Justification{
+				ShareIndex: r.uint32(),
+				Share:      r.scalar(),
+				Blinding:   r.optScalar(),
+			})
+		}
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	case typeExtraction:
+		b := &ExtractionBundle{DealerIndex: r.uint32()}
+		b.Public = r.points()
+		b.Challenge = r.scalar()
+		b.Response = r.scalar()
+		b.BlindingResponse = r.scalar()
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	case typeReconstruction:
+		b := &ReconstructionBundle{ShareIndex: r.uint32()}
+		n := r.count(12)
+		for i := 0; i < n; i++ {
+			b.Shares = append(b.Shares, ReconstructionShare{
+				DealerIndex: r.uint32(),
+				Share:       r.scalar(),
+				Blinding:    r.scalar(),
+			})
+		}
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	case typePVSSDeal:
+		b := &PVSSDealBundle{DealerIndex: r.uint32()}
+		b.Public = r.points()
+		n := r.count(24)
+		for i := 0; i < n; i++ {
+			s := &pvss.PubVerShare{}
+			s.S = share.PubShare{I: r.index(), V: r.point()}
+			s.P = dleq.Proof{C: r.scalar(), R: r.scalar(), VG: r.point(), VH: r.point()}
+			b.EncShares = append(b.EncShares, s)
+		}
+		b.SessionID = r.bytes()
+		b.Signature = r.bytes()
+		p = b
+	default:
+		r.fail(errors.New("dkg: encoding is not a packet"))
+	}
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// EncodeDistKeyShare returns the canonical binary encoding of the distributed
+// key share, with the same header as EncodePacket. The encoding contains the
+// private share, so it must be stored securely.
+func EncodeDistKeyShare(g kyber.Group, d *DistKeyShare) ([]byte, error) {
+	w := newEncoder(g, typeDistKeyShare)
+	w.distKeyShare(d)
+	return w.finish()
+}
+
+// DecodeDistKeyShare decodes a distributed key share encoded with
+// EncodeDistKeyShare for the same group.
+func DecodeDistKeyShare(g kyber.Group, buf []byte) (*DistKeyShare, error) {
+	r, typ := newDecoder(g, buf)
+	if typ != typeDistKeyShare {
+		r.fail(errors.New("dkg: encoding is not a distributed key share"))
+	}
+	d := r.distKeyShare()
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// EncodeResult returns the canonical binary encoding of the result of a DKG,
+// with the same header as EncodePacket. The encoding contains the private
+// share, so it must be stored securely.
+func EncodeResult(g kyber.Group, res *Result) ([]byte, error) {
+	w := newEncoder(g, typeResult)
+	w.uint32(uint32(len(res.QUAL)))
+	for _, n := range res.QUAL {
+		w.uint32(n.Index)
+		w.point(n.Public)
+	}
+	w.distKeyShare(res.Key)
+	return w.finish()
+}
+
+// DecodeResult decodes a result encoded with EncodeResult for the same group.
+func DecodeResult(g kyber.Group, buf []byte) (*Result, error) {
+	r, typ := newDecoder(g, buf)
+	if typ != typeResult {
+		r.fail(errors.New("dkg: encoding is not a result"))
+	}
+	res := &Result{}
+	n := r.count(8)
+	for i := 0; i < n; i++ {
+		res.QUAL = append(res.QUAL, Node{Index: r.uint32(), Public: r.point()})
+	}
+	res.Key = r.distKeyShare()
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// encoder writes the values of an encoding. The first error is kept and
+// returned by finish.
+type encoder struct {
+	buf bytes.Buffer
+	err error
+}
+
+func newEncoder(g kyber.Group, typ byte) *encoder {
+	w := &encoder{}
+	w.byte(EncodingVersion)
+	w.byte(typ)
+	w.bytes([]byte(g.String()))
+	return w
+}
+
+func (w *encoder) finish() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func (w *encoder) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *encoder) byte(b byte) {
+	w.buf.WriteByte(b)
+}
+
+func (w *encoder) bool(b bool) {
+	if b {
+		w.byte(1)
+	} else {
+		w.byte(0)
+	}
+}
+
+func (w *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *encoder) index(i int) {
+	if i < 0 || i > math.MaxUint32 {
+		w.fail(fmt.Errorf("dkg: share index %d out of range", i))
+	}
+	w.uint32(uint32(i))
+}
+
+func (w *encoder) bytes(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *encoder) marshal(m interface{ MarshalBinary() ([]byte, error) }) {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		w.fail(err)
+	}
+	w.bytes(b)
+}
+
+func (w *encoder) scalar(s kyber.Scalar) {
+	if s == nil {
+		w.fail(errors.New("dkg: nil scalar"))
+		return
+	}
+	w.marshal(s)
+}
+
+func (w *encoder) optScalar(s kyber.Scalar) {
+	w.bool(s != nil)
+	if s != nil {
+		w.marshal(s)
+	}
+}
+
+func (w *encoder) point(p kyber.Point) {
+	if p == nil {
+		w.fail(errors.New("dkg: nil point"))
+		return
+	}
+	w.marshal(p)
+}
+
+func (w *encoder) points(ps []kyber.Point) {
+	w.uint32(uint32(len(ps)))
+	for _, p := range ps {
+		w.point(p)
+	}
+}
+
+func (w *encoder) distKeyShare(d *DistKeyShare) {
+	if d == nil || d.Share == nil {
+		w.fail(errors.New("dkg: nil distributed key share"))
+		return
+	}
+	w.points(d.Commits)
+	w.index(d.Share.I)
+	w.scalar(d.Share.V)
+}
+
+// decoder reads the values written by encoder. The first error is kept and
+// all the subsequent reads return zero values.
+type decoder struct {
+	g   kyber.Group
+	buf []byte
+	err error
+}
+
+// newDecoder checks the header of the encoding and returns a decoder for the
+// rest of it, along with the type of the encoded value.
+func newDecoder(g kyber.Group, buf []byte) (*decoder, byte) {
+	r := &decoder{g: g, buf: buf}
+	if v := r.byte(); r.err == nil && v != EncodingVersion {
+		r.fail(fmt.Errorf("dkg: unsupported encoding version %d", v))
+	}
+	typ := r.byte()
+	if name := r.bytes(); r.err == nil && string(name) != g.String() {
+		r.fail(fmt.Errorf("dkg: encoding for group %q instead of %q", name, g.String()))
+	}
+	return r, typ
+}
+
+func (r *decoder) finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.buf) != 0 {
+		return errors.New("dkg: trailing bytes after encoding")
+	}
+	return nil
+}
+
+func (r *decoder) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *decoder) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.buf) < n {
+		r.fail(errShortBuffer)
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *decoder) byte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *decoder) bool() bool {
+	switch r.byte() {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.fail(errors.New("dkg: invalid boolean"))
+		return false
+	}
+}
+
+func (r *decoder) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *decoder) index() int {
+	i := r.uint32()
+	if uint64(i) > uint64(math.MaxInt) {
+		r.fail(fmt.Errorf("dkg: share index %d out of range", i))
+		return 0
+	}
+	return int(i)
+}
+
+// count reads a number of elements of at least min bytes each, and checks
+// that they can fit in the rest of the buffer.
+func (r *decoder) count(min int) int {
+	n := r.uint32()
+	if r.err == nil && uint64(n)*uint64(min) > uint64(len(r.buf)) {
+		r.fail(errShortBuffer)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *decoder) bytes() []byte {
+	n := r.count(1)
+	b := r.next(n)
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (r *decoder) scalar() kyber.Scalar {
+	b := r.bytes()
+	if r.err != nil {
+		return nil
+	}
+	s := r.g.Scalar()
+	if err := s.UnmarshalBinary(b); err != nil {
+		r.fail(err)
+		return nil
+	}
+	return s
+}
+
+func (r *decoder) optScalar() kyber.Scalar {
+	if !r.bool() {
+		return nil
+	}
+	return r.scalar()
+}
+
+// point reads a point and checks that it belongs to the prime order subgroup.
+func (r *decoder) point() kyber.Point {
+	b := r.bytes()
+	if r.err != nil {
+		return nil
+	}
+	p := r.g.Point()
+	if err := p.UnmarshalBinary(b); err != nil {
+		r.fail(err)
+		return nil
+	}
+	if !subgroup.Contains(r.g, p) {
+		r.fail(errors.New("dkg: point not in the expected group"))
+		return nil
+	}
+	return p
+}
+
+func (r *decoder) points() []kyber.Point {
+	n := r.count(4)
+	var ps []kyber.Point
+	for i := 0; i < n && r.err == nil; i++ {
+		ps = append(ps, r.point())
+	}
+	if r.err != nil {
+		return nil
+	}
+	return ps
+}
+
+func (r *decoder) distKeyShare() *DistKeyShare {
+	d := &DistKeyShare{Commits: r.points()}
+	d.Share = &share.PriShare{I: r.index(), V: r.scalar()}
+	if r.err != nil {
+		return nil
+	}
+	return d
+}
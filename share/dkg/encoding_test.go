@@ -0,0 +1,157 @@
+package dkg
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/pvss"
+	"github.com/drand/kyber/sign/schnorr"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func testPackets(suite Suite) []Packet {
+	s := func() kyber.Scalar { return suite.Scalar().Pick(random.New()) }
+	p := func() kyber.Point { return suite.Point().Pick(random.New()) }
+	return []Packet{
+		&DealBundle{
+			DealerIndex: 3,
+			Deals:       []Deal{{ShareIndex: 1, EncryptedShare: []byte("share")}},
+			Public:      []kyber.Point{p(), p()},
+			SessionID:   []byte("session"),
+			Signature:   []byte("signature"),
+		},
+		&ResponseBundle{
+			ShareIndex: 2,
+			Responses: []Response{
+				{DealerIndex: 1, Status: Complaint},
+				{DealerIndex: 3, Status: Complaint, Proof: &ComplaintProof{
+					DHKey: p(),
+					Proof: dleq.Proof{C: s(), R: s(), VG: p(), VH: p()},
+				}},
+				{DealerIndex: 4, Status: Success},
+			},
+			SessionID: []byte("session"),
+		},
+		&JustificationBundle{
+			DealerIndex:    1,
+			Justifications: []Justification{{ShareIndex: 2, Share: s()}, {ShareIndex: 3, Share: s(), Blinding: s()}},
+			Signature:      []byte("signature"),
+		},
+		&ExtractionBundle{
+			DealerIndex:      5,
+			Public:           []kyber.Point{p()},
+			Challenge:        s(),
+			Response:         s(),
+			BlindingResponse: s(),
+		},
+		&ReconstructionBundle{
+			ShareIndex: 6,
+			Shares:     []ReconstructionShare{{DealerIndex: 2, Share: s(), Blinding: s()}},
+		},
+		&PVSSDealBundle{
+			DealerIndex: 7,
+			Public:      []kyber.Point{p(), p(), p()},
+			EncShares: []*pvss.PubVerShare{{
+				S: share.PubShare{I: 4, V: p()},
+				P: dleq.Proof{C: s(), R: s(), VG: p(), VH: p()},
+			}},
+			SessionID: []byte("session"),
+			Signature: []byte("signature"),
+		},
+	}
+}
+
+func TestEncodePacket(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	for _, packet := range testPackets(suite) {
+		buff, err := EncodePacket(suite, packet)
+		require.NoError(t, err)
+		decoded, err := DecodePacket(suite, buff)
+		require.NoError(t, err)
+		require.IsType(t, packet, decoded)
+		require.Equal(t, packet.Hash(), decoded.Hash())
+		require.Equal(t, packet.Index(), decoded.Index())
+		// the encoding is canonical
+		reencoded, err := EncodePacket(suite, decoded)
+		require.NoError(t, err)
+		require.Equal(t, buff, reencoded)
+
+		for i := 0; i < len(buff); i++ {
+			_, err = DecodePacket(suite, buff[:i])
+			require.Error(t, err)
+		}
+		_, err = DecodePacket(suite, append(buff, 0))
+		require.Error(t, err)
+
+		// wrong version
+		buff[0]++
+		_, err = DecodePacket(suite, buff)
+		require.Error(t, err)
+	}
+}
+
+func TestEncodePacketGroup(t *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	packet := testPackets(suite)[0]
+	buff, err := EncodePacket(suite, packet)
+	require.NoError(t, err)
+	_, err = DecodePacket(bn256.NewSuite().G1().(Suite), buff)
+	require.Error(t, err)
+
+	// a point with a small order component is rejected
+	torsion := suite.Point()
+	b, _ := hex.DecodeString("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a")
+	require.NoError(t, torsion.UnmarshalBinary(b))
+	deal := packet.(*DealBundle)
+	deal.Public[1] = suite.Point().Add(deal.Public[1], torsion)
+	buff, err = EncodePacket(suite, deal)
+	require.NoError(t, err)
+	_, err = DecodePacket(suite, buff)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "point not in the expected group")
+
+	_, err = EncodePacket(suite, nil)
+	require.Error(t, err)
+}
+
+func TestEncodeResult(t *testing.T) {
+	n := 4
+	thr := 3
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	conf := Config{
+		Suite:     suite,
+		NewNodes:  NodesFromTest(tns),
+		Threshold: thr,
+		Auth:      schnorr.NewScheme(suite),
+	}
+	results := RunDKG(t, tns, conf, nil, nil, nil)
+	for _, res := range results {
+		buff, err := EncodeResult(suite, res)
+		require.NoError(t, err)
+		decoded, err := DecodeResult(suite, buff)
+		require.NoError(t, err)
+		require.True(t, res.PublicEqual(decoded))
+		require.Equal(t, res.Key.Share.I, decoded.Key.Share.I)
+		require.True(t, res.Key.Share.V.Equal(decoded.Key.Share.V))
+		_, err = DecodeResult(suite, buff[:len(buff)-1])
+		require.Error(t, err)
+		_, err = DecodeDistKeyShare(suite, buff)
+		require.Error(t, err)
+
+		buff, err = EncodeDistKeyShare(suite, res.Key)
+		require.NoError(t, err)
+		key, err := DecodeDistKeyShare(suite, buff)
+		require.NoError(t, err)
+		require.True(t, res.Key.Public().Equal(key.Public()))
+		require.True(t, res.Key.Share.V.Equal(key.Share.V))
+		_, err = DecodePacket(suite, buff)
+		require.Error(t, err)
+	}
+}
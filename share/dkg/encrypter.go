@@ -0,0 +1,64 @@
+package dkg
+
+import (
+	"crypto/sha256"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/encrypt/ecies"
+)
+
+// DealEncrypter encrypts the shares of a dealer to the longterm keys of their
+// share holders, and decrypts the shares a node receives with its longterm
+// key. All the nodes of a DKG must use the same encryption.
+type DealEncrypter interface {
+	// Encrypt encrypts the share to the longterm public key of its holder.
+	Encrypt(s Suite, public kyber.Point, share []byte) ([]byte, error)
+	// Decrypt decrypts a share encrypted to the longterm public key
+	// corresponding to the private key.
+	Decrypt(s Suite, private kyber.Scalar, ciphertext []byte) ([]byte, error)
+}
+
+// VerifiableDealEncrypter is a DealEncrypter whose ciphertexts can be
+// decrypted with a Diffie-Hellman key, the product of the private key of the
+// recipient and of an ephemeral point of the ciphertext. It is required by
+// the verifiable complaints, where share holders reveal this key to prove that
+// their share is invalid.
+type VerifiableDealEncrypter interface {
+	DealEncrypter
+	// Ephemeral returns the ephemeral point of the ciphertext.
+	Ephemeral(s Suite, ciphertext []byte) (kyber.Point, error)
+	// DecryptWithKey decrypts the ciphertext with its Diffie-Hellman key.
+	DecryptWithKey(s Suite, dh kyber.Point, ciphertext []byte) ([]byte, error)
+}
+
+// ECIESEncrypter is the default DealEncrypter, encrypting the shares with
+// ECIES, with AES-GCM and keys derived with SHA-256.
+type ECIESEncrypter struct{}
+
+// Encrypt implements the DealEncrypter interface.
+func (ECIESEncrypter) Encrypt(s Suite, public kyber.Point, share []byte) ([]byte, error) {
+	return ecies.Encrypt(s, public, share, sha256.New)
+}
+
+// Decrypt implements the DealEncrypter interface.
+func (ECIESEncrypter) Decrypt(s Suite, private kyber.Scalar, ciphertext []byte) ([]byte, error) {
+	return ecies.Decrypt(s, private, ciphertext, sha256.New)
+}
+
+// Ephemeral implements the VerifiableDealEncrypter interface.
+func (ECIESEncrypter) Ephemeral(s Suite, ciphertext []byte) (kyber.Point, error) {
+	return ecies.EphemeralKey(s, ciphertext)
+}
+
+// DecryptWithKey implements the VerifiableDealEncrypter interface.
+func (ECIESEncrypter) DecryptWithKey(s Suite, dh kyber.Point, ciphertext []byte) ([]byte, error) {
+	return ecies.DecryptWithSharedKey(s, dh, ciphertext, sha256.New)
+}
+
+// dealEncrypter returns the encryption of the deals, ECIES by default.
+func (c *Config) dealEncrypter() DealEncrypter {
+	if c.DealEncrypter == nil {
+		return ECIESEncrypter{}
+	}
+	return c.DealEncrypter
+}
@@ -0,0 +1,386 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/share"
+)
+
+// This file contains the logic specific to the GJKR mode. The deal, response
+// and justification phases run as in the Joint-Feldman protocol, except that
+// the public polynomial of each dealer is committed to with Pedersen
+// commitments C_k = a_k*G + b_k*H, and each deal carries both the share and the
+// blinding share. Since these commitments reveal nothing about the secret of
+// the dealer, an adversary cannot choose which of its deals to make fail
+// depending on the contributions of the honest dealers. Once the set of
+// qualified dealers is fixed, each of them reveals its Feldman commitments
+// A_k = a_k*G with a non-interactive proof of knowledge of the representation
+// of C_k with respect to A_k. Since the proof is publicly verifiable, all
+// honest nodes agree on the dealers failing to reveal their commitments, whose
+// polynomial is then reconstructed from the shares of the other nodes.
+
+// PedersenBase returns the second base point H used by the Pedersen
+// commitments in GJKR mode. It is derived deterministically so that nobody
+// knows its discrete logarithm with respect to the standard base point.
+func PedersenBase(s Suite) kyber.Point {
+	return s.Point().Pick(s.XOF([]byte("drand/kyber/share/dkg: pedersen base")))
+}
+
+// Extraction returns the bundle revealing the Feldman commitments of this
+// dealer. It must be called in GJKR mode after ProcessJustifications, and
+// returns nil if this node is not a qualified dealer.
+func (d *DistKeyGenerator) Extraction() (*ExtractionBundle, error) {
+	if d.c.Mode != GJKR {
+		return nil, errors.New("dkg: extraction only happens in GJKR mode")
+	}
+	if d.state != ExtractionPhase {
+		return nil, fmt.Errorf("extraction can only happen after processing justifications - current state %s", d.state)
+	}
+	if !d.canIssue || !contains(d.qualDealers(), d.oidx) {
+		return nil, nil
+	}
+	feldman := d.dpriv.Commit(nil)
+	d.extracted[d.oidx] = feldman
+	_, commits := feldman.Info()
+	_, pedersen := d.dpub.Info()
+	c, z, zb := d.extractionProof(uint32(d.oidx), pedersen, commits)
+	bundle := &ExtractionBundle{
+		DealerIndex:      uint32(d.oidx),
+		Public:           commits,
+		Challenge:        c,
+		Response:         z,
+		BlindingResponse: zb,
+		SessionID:        d.c.Nonce,
+	}
+	var err error
+	bundle.Signature, err = d.sign(bundle)
+	return bundle, err
+}
+
+// ProcessExtractions verifies the Feldman commitments revealed by the
+// qualified dealers. If all qualified dealers revealed valid commitments, it
+// returns the result and the DKG is finished. Otherwise it returns the bundle
+// revealing the shares of this node for the failing dealers, and the nodes
+// must call ProcessReconstructions with the bundles of all nodes. Bundles
+// with an invalid session ID, signature or proof are ignored, and only two
+// different valid bundles of the same dealer count as an equivocation.
+func (d *DistKeyGenerator) ProcessExtractions(bundles []*ExtractionBundle) (*Result, *ReconstructionBundle, error) {
+	if d.state != ExtractionPhase {
+		return nil, nil, fmt.Errorf("can only process extractions after processing justifications - current state %s", d.state)
+	}
+	qual := d.qualDealers()
+	if d.canIssue && contains(qual, d.oidx) {
+		// our own commitments are valid, even if we did not send them
+		d.extracted[d.oidx] = d.dpriv.Commit(nil)
+	}
+	// hashes of the valid extraction of each dealer
+	valid := make(map[uint32][]byte)
+	var invalid []Index
+	for _, bundle := range bundles {
+		if bundle == nil {
+			continue
+		}
+		if d.canIssue && bundle.DealerIndex == uint32(d.oidx) {
+			// we dont treat our own extraction
+			continue
+		}
+		if !contains(qual, bundle.DealerIndex) {
+			d.c.Error("Extraction from non qualified dealer", bundle.DealerIndex)
+			continue
+		}
+		if !bytes.Equal(bundle.SessionID, d.c.Nonce) {
+			d.c.Error("Extraction with invalid session ID", bundle.DealerIndex)
+			continue
+		}
+		if err := VerifyPacketSignature(d.c, bundle); err != nil {
+			d.c.Error("Extraction with invalid signature", bundle.DealerIndex)
+			continue
+		}
+		if len(bundle.Public) != d.c.Threshold || !d.verifyExtraction(bundle) {
+			d.c.Error("Extraction with invalid proof", bundle.DealerIndex)
+			continue
+		}
+		hash := bundle.Hash()
+		if prev, ok := valid[bundle.DealerIndex]; ok {
+			if !bytes.Equal(prev, hash) && !contains(invalid, bundle.DealerIndex) {
+				// two different valid extractions - clear violation so the
+				// polynomial of this dealer is reconstructed
+				invalid = append(invalid, bundle.DealerIndex)
+				d.c.Error("Extraction bundle equivocation", bundle.DealerIndex)
+			}
+			// same bundle just rebroadcasted otherwise
+			continue
+		}
+		valid[bundle.DealerIndex] = hash
+		d.extracted[bundle.DealerIndex] = share.NewPubPoly(d.suite, nil, bundle.Public)
+	}
+	for _, index := range invalid {
+		delete(d.extracted, index)
+	}
+
+	d.reconstruct = nil
+	for _, index := range qual {
+		if _, ok := d.extracted[index]; !ok {
+			d.reconstruct = append(d.reconstruct, index)
+		}
+	}
+	if len(d.reconstruct) == 0 {
+		return d.computeExtractedResult()
+	}
+
+	d.state = ReconstructionPhase
+	if !d.canReceive {
+		return nil, nil, nil
+	}
+	shares := make([]ReconstructionShare, 0, len(d.reconstruct))
+	for _, index := range d.reconstruct {
+		d.c.Info(fmt.Sprintf("Revealing share of dealer %d for reconstruction", index))
+		shares = append(shares, ReconstructionShare{
+			DealerIndex: index,
+			Share:       d.validShares[index],
+			Blinding:    d.validBlindings[index],
+		})
+	}
+	bundle := &ReconstructionBundle{
+		ShareIndex: uint32(d.nidx),
+		Shares:     shares,
+		SessionID:  d.c.Nonce,
+	}
+	sig, err := d.sign(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundle.Signature = sig
+	return nil, bundle, nil
+}
+
+// ProcessReconstructions reconstructs the polynomials of the qualified dealers
+// that did not reveal valid Feldman commitments from the shares revealed by
+// the nodes, and returns the result. It returns an error if there are not
+// enough valid shares to reconstruct one of them.
+func (d *DistKeyGenerator) ProcessReconstructions(bundles []*ReconstructionBundle) (*Result, error) {
+	if d.state != ReconstructionPhase {
+		return nil, fmt.Errorf("can only process reconstructions after processing extractions - current state %s", d.state)
+	}
+	shares := make(map[uint32][]*share.PriShare, len(d.reconstruct))
+	if d.canReceive {
+		for _, index := range d.reconstruct {
+			shares[index] = append(shares[index], &share.PriShare{
+				I: int(d.nidx),
+				V: d.validShares[index],
+			})
+		}
+	}
+	seen := make(map[uint32]bool)
+	for _, bundle := range bundles {
+		if bundle == nil {
+			continue
+		}
+		if d.canReceive && bundle.ShareIndex == uint32(d.nidx) {
+			continue
+		}
+		if !isIndexIncluded(d.c.NewNodes, bundle.ShareIndex) {
+			d.c.Error("Reconstruction bundle with invalid index", bundle.ShareIndex)
+			continue
+		}
+		if !bytes.Equal(bundle.SessionID, d.c.Nonce) {
+			d.c.Error("Reconstruction with invalid session ID", bundle.ShareIndex)
+			continue
+		}
+		if err := VerifyPacketSignature(d.c, bundle); err != nil {
+			d.c.Error("Reconstruction with invalid signature", bundle.ShareIndex)
+			continue
+		}
+		// only an authenticated bundle of the holder keeps its other
+		// bundles out
+		if seen[bundle.ShareIndex] {
+			d.c.Error("Reconstruction bundle with duplicate index", bundle.ShareIndex)
+			continue
+		}
+		seen[bundle.ShareIndex] = true
+		for _, sh := range bundle.Shares {
+			if !contains(d.reconstruct, sh.DealerIndex) {
+				continue
+			}
+			if !d.checkShare(d.allPublics[sh.DealerIndex], bundle.ShareIndex, sh.Share, sh.Blinding) {
+				d.c.Error("Reconstruction share invalid", bundle.ShareIndex, sh.DealerIndex)
+				continue
+			}
+			shares[sh.DealerIndex] = append(shares[sh.DealerIndex], &share.PriShare{
+				I: int(bundle.ShareIndex),
+				V: sh.Share,
+			})
+		}
+	}
+
+	for _, index := range d.reconstruct {
+		if len(shares[index]) < d.c.Threshold {
+			d.state = FinishPhase
+			return nil, fmt.Errorf("process-reconstructions: only %d/%d valid shares for dealer %d - dkg abort", len(shares[index]), d.c.Threshold, index)
+		}
+		priPoly, err := share.RecoverPriPoly(d.suite, shares[index], d.c.Threshold, len(d.c.NewNodes))
+		if err != nil {
+			return nil, err
+		}
+		d.c.Info("Reconstructed polynomial of dealer", index)
+		d.extracted[index] = priPoly.Commit(nil)
+	}
+	res, _, err := d.computeExtractedResult()
+	return res, err
+}
+
+// computeExtractedResult computes the result from the Feldman commitments of
+// all the qualified dealers.
+func (d *DistKeyGenerator) computeExtractedResult() (*Result, *ReconstructionBundle, error) {
+	d.state = FinishPhase
+	if !d.canReceive {
+		return nil, nil, nil
+	}
+	for index, pub := range d.extracted {
+		d.allPublics[index] = pub
+	}
+	res, err := d.computeResult()
+	return res, nil, err
+}
+
+// qualDealers returns the dealers whose contribution is part of the
+// distributed key, i.e. the dealers whose shares are all valid and that did
+// not misbehave as share holders.
+func (d *DistKeyGenerator) qualDealers() []Index {
+	var qual []Index
+	for _, n := range d.c.OldNodes {
+		if !d.statuses.AllTrue(n.Index) || contains(d.evictedHolders, n.Index) {
+			continue
+		}
+		qual = append(qual, n.Index)
+	}
+	return qual
+}
+
+// checkShare returns true if the share, and the blinding share in GJKR mode,
+// of the share holder idx is consistent with the public polynomial pub of the
+// dealer.
+func (d *DistKeyGenerator) checkShare(pub *share.PubPoly, idx Index, sh, blinding kyber.Scalar) bool {
+	if pub == nil || sh == nil {
+		return false
+	}
+	commit := d.suite.Point().Mul(sh, nil)
+	if d.c.Mode == GJKR {
+		if blinding == nil {
+			return false
+		}
+		commit.Add(commit, d.suite.Point().Mul(blinding, d.pedersenBase))
+	}
+	return commit.Equal(pub.Eval(int(idx)).V)
+}
+
+// unmarshalShare decodes a decrypted deal, made of the share followed by the
+// blinding share in GJKR mode.
+func (d *DistKeyGenerator) unmarshalShare(buff []byte) (kyber.Scalar, kyber.Scalar, error) {
+	sh := d.suite.Scalar()
+	if d.c.Mode != GJKR {
+		return sh, nil, sh.UnmarshalBinary(buff)
+	}
+	l := sh.MarshalSize()
+	if len(buff) != 2*l {
+		return nil, nil, errors.New("dkg: invalid deal length")
+	}
+	blinding := d.suite.Scalar()
+	if err := sh.UnmarshalBinary(buff[:l]); err != nil {
+		return nil, nil, err
+	}
+	if err := blinding.UnmarshalBinary(buff[l:]); err != nil {
+		return nil, nil, err
+	}
+	return sh, blinding, nil
+}
+
+// extractionProof proves the knowledge of the coefficients a_k and b_k such
+// that feldman[k] = a_k*G and pedersen[k] - feldman[k] = b_k*H. All the
+// coefficients are combined with random weights, so that a single Schnorr
+// proof of each representation is needed. By the binding property of the
+// Pedersen commitments, the a_k are the coefficients committed to during the
+// deal phase.
+func (d *DistKeyGenerator) extractionProof(dealer uint32, pedersen, feldman []kyber.Point) (c, z, zb kyber.Scalar) {
+	transcript := d.extractionTranscript(dealer, pedersen, feldman)
+	weights := d.extractionWeights(transcript, len(feldman))
+	coeffs := d.dpriv.Coefficients()
+	blindings := d.dblind.Coefficients()
+	a := d.suite.Scalar().Zero()
+	b := d.suite.Scalar().Zero()
+	tmp := d.suite.Scalar()
+	for k, w := range weights {
+		a.Add(a, tmp.Mul(w, coeffs[k]))
+		b.Add(b, tmp.Mul(w, blindings[k]))
+	}
+	r := d.suite.Scalar().Pick(d.suite.RandomStream())
+	rb := d.suite.Scalar().Pick(d.suite.RandomStream())
+	R := d.suite.Point().Mul(r, nil)
+	Rb := d.suite.Point().Mul(rb, d.pedersenBase)
+	c = d.extractionChallenge(transcript, R, Rb)
+	z = d.suite.Scalar().Add(r, tmp.Mul(c, a))
+	zb = d.suite.Scalar().Add(rb, tmp.Mul(c, b))
+	return c, z, zb
+}
+
+// verifyExtraction verifies the proof of the bundle with respect to the
+// Pedersen commitments received from the dealer during the deal phase.
+func (d *DistKeyGenerator) verifyExtraction(bundle *ExtractionBundle) bool {
+	pub, ok := d.allPublics[bundle.DealerIndex]
+	if !ok || bundle.Challenge == nil || bundle.Response == nil || bundle.BlindingResponse == nil {
+		return false
+	}
+	_, pedersen := pub.Info()
+	transcript := d.extractionTranscript(bundle.DealerIndex, pedersen, bundle.Public)
+	weights := d.extractionWeights(transcript, len(bundle.Public))
+	A := d.suite.Point().Null()
+	C := d.suite.Point().Null()
+	tmp := d.suite.Point()
+	for k, w := range weights {
+		A.Add(A, tmp.Mul(w, bundle.Public[k]))
+		C.Add(C, tmp.Mul(w, pedersen[k]))
+	}
+	C.Sub(C, A)
+	// R = z*G - c*A and Rb = zb*H - c*(C - A)
+	R := d.suite.Point().Mul(bundle.Response, nil)
+	R.Sub(R, tmp.Mul(bundle.Challenge, A))
+	Rb := d.suite.Point().Mul(bundle.BlindingResponse, d.pedersenBase)
+	Rb.Sub(Rb, tmp.Mul(bundle.Challenge, C))
+	return d.extractionChallenge(transcript, R, Rb).Equal(bundle.Challenge)
+}
+
+func (d *DistKeyGenerator) extractionTranscript(dealer uint32, pedersen, feldman []kyber.Point) []byte {
+	h := sha256.New()
+	h.Write(d.c.Nonce)
+	binary.Write(h, binary.BigEndian, dealer)
+	for _, points := range [][]kyber.Point{pedersen, feldman} {
+		for _, p := range points {
+			buff, _ := p.MarshalBinary()
+			h.Write(buff)
+		}
+	}
+	return h.Sum(nil)
+}
+
+func (d *DistKeyGenerator) extractionWeights(transcript []byte, n int) []kyber.Scalar {
+	xof := d.suite.XOF(append([]byte("weights"), transcript...))
+	weights := make([]kyber.Scalar, n)
+	for k := range weights {
+		weights[k] = d.suite.Scalar().Pick(xof)
+	}
+	return weights
+}
+
+func (d *DistKeyGenerator) extractionChallenge(transcript []byte, R, Rb kyber.Point) kyber.Scalar {
+	buff := append([]byte("challenge"), transcript...)
+	rbuff, _ := R.MarshalBinary()
+	rbbuff, _ := Rb.MarshalBinary()
+	buff = append(buff, rbuff...)
+	buff = append(buff, rbbuff...)
+	return d.suite.Scalar().Pick(d.suite.XOF(buff))
+}
@@ -0,0 +1,144 @@
+package dkg
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/share"
+)
+
+// parallel calls f for every i in [0, n), spread over the workers of the
+// config, and returns once all the calls are done. The calls must be
+// independent from each other, so that the result does not depend on the
+// number of workers.
+func (c *Config) parallel(n int, f func(i int)) {
+	workers := c.Workers
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	var next int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= n {
+					return
+				}
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// shareCheck is the result of the decryption and verification of a share.
+type shareCheck struct {
+	share, blinding kyber.Scalar
+	// reason is the reason why the share is invalid, or empty if it is valid.
+	reason string
+}
+
+// checkDeals decrypts and verifies the shares of this node in the bundles
+// concurrently. The result for the deal j of the bundle i is at [i][j], and
+// is nil if the deal is not for this node or the bundle is invalid.
+func (d *DistKeyGenerator) checkDeals(bundles []*DealBundle) [][]*shareCheck {
+	type job struct{ i, j int }
+	var jobs []job
+	pubPolys := make([]*share.PubPoly, len(bundles))
+	checks := make([][]*shareCheck, len(bundles))
+	for i, bundle := range bundles {
+		if bundle == nil || len(bundle.Public) != d.c.Threshold {
+			continue
+		}
+		pubPolys[i] = share.NewPubPoly(d.suite, d.suite.Point().Base(), bundle.Public)
+		checks[i] = make([]*shareCheck, len(bundle.Deals))
+		for j, deal := range bundle.Deals {
+			if deal.ShareIndex == uint32(d.nidx) {
+				jobs = append(jobs, job{i, j})
+			}
+		}
+	}
+	d.c.parallel(len(jobs), func(k int) {
+		i, j := jobs[k].i, jobs[k].j
+		dealer := bundles[i].DealerIndex
+		buff, err := d.c.dealEncrypter().Decrypt(d.suite, d.long, bundles[i].Deals[j].EncryptedShare)
+		if err != nil {
+			checks[i][j] = &shareCheck{reason: "share decryption failed"}
+			return
+		}
+		sh, blinding, reason := d.verifyShare(dealer, pubPolys[i], d.nidx, buff)
+		checks[i][j] = &shareCheck{share: sh, blinding: blinding, reason: reason}
+	})
+	return checks
+}
+
+// complaintCheck is the result of the verification of a complaint.
+type complaintCheck struct {
+	proven bool
+	err    error
+}
+
+// checkComplaints verifies the complaints of the bundles concurrently, when
+// the verifiable complaints are enabled. The result for the response j of the
+// bundle i is at [i][j], and is nil if the response is not a complaint.
+func (d *DistKeyGenerator) checkComplaints(bundles []*ResponseBundle) [][]*complaintCheck {
+	checks := make([][]*complaintCheck, len(bundles))
+	if !d.c.VerifiableComplaints {
+		return checks
+	}
+	type job struct{ i, j int }
+	var jobs []job
+	for i, bundle := range bundles {
+		if bundle == nil {
+			continue
+		}
+		checks[i] = make([]*complaintCheck, len(bundle.Responses))
+		for j, response := range bundle.Responses {
+			if response.Status == Complaint {
+				jobs = append(jobs, job{i, j})
+			}
+		}
+	}
+	d.c.parallel(len(jobs), func(k int) {
+		i, j := jobs[k].i, jobs[k].j
+		response := bundles[i].Responses[j]
+		proven, err := d.verifyComplaint(response.DealerIndex, bundles[i].ShareIndex, response.Proof)
+		checks[i][j] = &complaintCheck{proven: proven, err: err}
+	})
+	return checks
+}
+
+// checkJustifications verifies the shares revealed by the justifications
+// concurrently. The result for the justification j of the bundle i is at
+// [i][j].
+func (d *DistKeyGenerator) checkJustifications(bundles []*JustificationBundle) [][]bool {
+	type job struct{ i, j int }
+	var jobs []job
+	checks := make([][]bool, len(bundles))
+	for i, bundle := range bundles {
+		if bundle == nil {
+			continue
+		}
+		checks[i] = make([]bool, len(bundle.Justifications))
+		for j := range bundle.Justifications {
+			jobs = append(jobs, job{i, j})
+		}
+	}
+	d.c.parallel(len(jobs), func(k int) {
+		i, j := jobs[k].i, jobs[k].j
+		justif := bundles[i].Justifications[j]
+		pubPoly := d.allPublics[bundles[i].DealerIndex]
+		checks[i][j] = d.checkShare(pubPoly, justif.ShareIndex, justif.Share, justif.Blinding)
+	})
+	return checks
+}
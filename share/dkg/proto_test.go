@@ -7,6 +7,7 @@ import (
 
 	"github.com/drand/kyber"
 	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/share/event"
 	"github.com/drand/kyber/sign/schnorr"
 	"github.com/drand/kyber/util/random"
 	clock "github.com/jonboulle/clockwork"
@@ -72,14 +73,32 @@ func (n *TestNetwork) BroadcastJustification(a *JustificationBundle) {
 	}
 }
 
+func (n *TestNetwork) BroadcastExtraction(a *ExtractionBundle) {
+	for _, board := range n.boards {
+		if !n.isNoop(board.index) {
+			board.newExtracts <- *a
+		}
+	}
+}
+
+func (n *TestNetwork) BroadcastReconstruction(a *ReconstructionBundle) {
+	for _, board := range n.boards {
+		if !n.isNoop(board.index) {
+			board.newRecons <- *a
+		}
+	}
+}
+
 type TestBoard struct {
-	index    uint32
-	newDeals chan DealBundle
-	newResps chan ResponseBundle
-	newJusts chan JustificationBundle
-	network  *TestNetwork
-	badDeal  bool
-	badSig   bool
+	index       uint32
+	newDeals    chan DealBundle
+	newResps    chan ResponseBundle
+	newJusts    chan JustificationBundle
+	newExtracts chan ExtractionBundle
+	newRecons   chan ReconstructionBundle
+	network     *TestNetwork
+	badDeal     bool
+	badSig      bool
 }
 
 func NewTestBoard(index uint32, n int, network *TestNetwork) *TestBoard {
@@ -89,6 +108,9 @@ func NewTestBoard(index uint32, n int, network *TestNetwork) *TestBoard {
 		newDeals: make(chan DealBundle, n),
 		newResps: make(chan ResponseBundle, n),
 		newJusts: make(chan JustificationBundle, n),
+
+		newExtracts: make(chan ExtractionBundle, n),
+		newRecons:   make(chan ReconstructionBundle, n),
 	}
 }
 
@@ -110,6 +132,14 @@ func (t *TestBoard) PushJustifications(j *JustificationBundle) {
 	t.network.BroadcastJustification(j)
 }
 
+func (t *TestBoard) PushExtractions(e *ExtractionBundle) {
+	t.network.BroadcastExtraction(e)
+}
+
+func (t *TestBoard) PushReconstructions(r *ReconstructionBundle) {
+	t.network.BroadcastReconstruction(r)
+}
+
 func (t *TestBoard) IncomingDeal() <-chan DealBundle {
 	return t.newDeals
 }
@@ -122,6 +152,14 @@ func (t *TestBoard) IncomingJustification() <-chan JustificationBundle {
 	return t.newJusts
 }
 
+func (t *TestBoard) IncomingExtraction() <-chan ExtractionBundle {
+	return t.newExtracts
+}
+
+func (t *TestBoard) IncomingReconstruction() <-chan ReconstructionBundle {
+	return t.newRecons
+}
+
 func SetupProto(tns []*TestNode, dkgC *Config, period time.Duration, network *TestNetwork) {
 	for _, n := range tns {
 		clock := clock.NewFakeClock()
@@ -153,11 +191,13 @@ func TestProtoFull(t *testing.T) {
 	tns := GenerateTestNodes(suite, n)
 	list := NodesFromTest(tns)
 	network := NewTestNetwork(n)
+	counter := event.NewCounter()
 	dkgConf := Config{
 		Suite:     suite,
 		NewNodes:  list,
 		Threshold: thr,
 		Auth:      schnorr.NewScheme(suite),
+		Events:    counter,
 	}
 	SetupNodes(tns, &dkgConf)
 	SetupProto(tns, &dkgConf, period, network)
@@ -193,6 +233,8 @@ func TestProtoFull(t *testing.T) {
 		}
 	}
 	testResults(t, suite, thr, n, results)
+	// each node went through the deal, response and justification phases
+	require.Equal(t, 3*n, counter.Count("phase_transition"))
 
 }
 
@@ -364,6 +406,50 @@ func TestProtoThreshold(t *testing.T) {
 
 }
 
+func TestProtoGJKR(t *testing.T) {
+	n := 5
+	realN := 4
+	thr := 4
+	period := 1 * time.Second
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	list := NodesFromTest(tns)
+	tns = tns[:realN]
+	network := NewTestNetwork(realN)
+	dkgConf := Config{
+		Suite:     suite,
+		NewNodes:  list,
+		Threshold: thr,
+		Auth:      schnorr.NewScheme(suite),
+		Mode:      GJKR,
+	}
+	SetupNodes(tns, &dkgConf)
+	SetupProto(tns, &dkgConf, period, network)
+
+	var resCh = make(chan OptionResult, 1)
+	for _, node := range tns {
+		go func(n *TestNode) { resCh <- <-n.proto.WaitEnd() }(node)
+	}
+	for _, node := range tns {
+		go node.phaser.StartPhases(dkgConf.Phases()...)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// the extraction and reconstruction phases take two more periods
+	for i := 0; i < 5; i++ {
+		moveTime(tns, period)
+		time.Sleep(100 * time.Millisecond)
+	}
+	var results []*Result
+	for optRes := range resCh {
+		require.NoError(t, optRes.Error)
+		results = append(results, optRes.Result)
+		if len(results) == realN {
+			break
+		}
+	}
+	testResults(t, suite, thr, n, results)
+}
+
 func TestProtoFullFast(t *testing.T) {
 	n := 5
 	thr := n
@@ -2,9 +2,13 @@ package dkg
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/drand/kyber/share/event"
+	clock "github.com/jonboulle/clockwork"
 )
 
 // Board is the interface between the dkg protocol and the external world. It
@@ -21,9 +25,32 @@ type Board interface {
 	IncomingJustification() <-chan JustificationBundle
 }
 
+// IdleBoard is a Board that the protocol notifies each time it has
+// processed a packet or a phase and waits for the next one. It lets e.g. a
+// simulation deliver the packets and phases to the protocol one at a time.
+type IdleBoard interface {
+	Board
+	// Idle is called by the protocol before waiting for the next packet or
+	// phase, including the first one.
+	Idle()
+}
+
+// GJKRBoard is the Board required to run the protocol in GJKR mode, which
+// needs two more kinds of packets during the extraction and reconstruction
+// phases.
+type GJKRBoard interface {
+	Board
+	PushExtractions(*ExtractionBundle)
+	IncomingExtraction() <-chan ExtractionBundle
+	PushReconstructions(*ReconstructionBundle)
+	IncomingReconstruction() <-chan ReconstructionBundle
+}
+
 // Phaser must signal on its channel when the protocol should move to a next
 // phase. Phase must be sequential: DealPhase (start), ResponsePhase,
-// JustifPhase and then FinishPhase.
+// JustifPhase and then FinishPhase. In GJKR mode, the ExtractionPhase and
+// ReconstructionPhase come between JustifPhase and FinishPhase, see
+// Config.Phases.
 // Note that if the dkg protocol finishes before the phaser sends the
 // FinishPhase, the protocol will not listen on the channel anymore. This can
 // happen if there is no complaints, or if using the "FastSync" mode.
@@ -42,24 +69,45 @@ type TimePhaser struct {
 }
 
 func NewTimePhaser(p time.Duration) *TimePhaser {
-	return NewTimePhaserFunc(func(Phase) { time.Sleep(p) })
+	return NewTimePhaserClock(clock.NewRealClock(), p)
+}
+
+// NewTimePhaserClock returns a TimePhaser sleeping for the period on the
+// given clock between the phases.
+func NewTimePhaserClock(c clock.Clock, p time.Duration) *TimePhaser {
+	return NewTimePhaserFunc(func(Phase) { c.Sleep(p) })
 }
 
 func NewTimePhaserFunc(sleepPeriod func(Phase)) *TimePhaser {
 	return &TimePhaser{
-		out:   make(chan Phase, 4),
+		out:   make(chan Phase, 6),
 		sleep: sleepPeriod,
 	}
 }
 
 func (t *TimePhaser) Start() {
-	t.out <- DealPhase
-	t.sleep(DealPhase)
-	t.out <- ResponsePhase
-	t.sleep(ResponsePhase)
-	t.out <- JustifPhase
-	t.sleep(JustifPhase)
-	t.out <- FinishPhase
+	t.StartPhases(DealPhase, ResponsePhase, JustifPhase, FinishPhase)
+}
+
+// StartPhases signals each of the given phases in order, sleeping in between.
+// It is used to run the protocol in GJKR mode with the phases given by
+// Config.Phases.
+func (t *TimePhaser) StartPhases(phases ...Phase) {
+	for i, phase := range phases {
+		t.out <- phase
+		if i < len(phases)-1 {
+			t.sleep(phase)
+		}
+	}
+}
+
+// Phases returns the sequence of phases a Phaser must signal to run the
+// protocol with this config.
+func (c *Config) Phases() []Phase {
+	if c.Mode == GJKR {
+		return []Phase{DealPhase, ResponsePhase, JustifPhase, ExtractionPhase, ReconstructionPhase, FinishPhase}
+	}
+	return []Phase{DealPhase, ResponsePhase, JustifPhase, FinishPhase}
 }
 
 func (t *TimePhaser) NextPhase() chan Phase {
@@ -77,6 +125,10 @@ type Protocol struct {
 	canIssue  bool
 	res       chan OptionResult
 	skipVerif bool
+	clock     clock.Clock
+	// current phase and its start, to report phase transitions
+	phase Phase
+	since time.Time
 }
 
 // XXX TO DELETE
@@ -93,6 +145,13 @@ func NewProtocol(c *Config, b Board, phaser Phaser, skipVerification bool) (*Pro
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := b.(GJKRBoard); c.Mode == GJKR && !ok {
+		return nil, errors.New("dkg: GJKR mode requires a GJKRBoard")
+	}
+	clk := c.Clock
+	if clk == nil {
+		clk = clock.NewRealClock()
+	}
 	p := &Protocol{
 		board:     b,
 		phaser:    phaser,
@@ -100,6 +159,9 @@ func NewProtocol(c *Config, b Board, phaser Phaser, skipVerification bool) (*Pro
 		canIssue:  dkg.canIssue,
 		res:       make(chan OptionResult, 1),
 		skipVerif: skipVerification,
+		clock:     clk,
+		phase:     InitPhase,
+		since:     clk.Now(),
 	}
 	go p.Start()
 	return p, nil
@@ -122,9 +184,23 @@ func (p *Protocol) Start() {
 	var deals = newSet()
 	var resps = newSet()
 	var justifs = newSet()
+	var extracts = newSet()
+	var recons = newSet()
+	// the extraction and reconstruction channels are only used in GJKR mode,
+	// and never ready otherwise
+	var incomingExtraction <-chan ExtractionBundle
+	var incomingReconstruction <-chan ReconstructionBundle
+	var gjkr = p.dkg.c.Mode == GJKR
+	if gjkr {
+		board := p.board.(GJKRBoard)
+		incomingExtraction = board.IncomingExtraction()
+		incomingReconstruction = board.IncomingReconstruction()
+	}
 	for {
+		p.idle()
 		select {
 		case newPhase := <-p.phaser.NextPhase():
+			p.transition(newPhase)
 			switch newPhase {
 			case DealPhase:
 				if !p.sendDeals() {
@@ -138,8 +214,20 @@ func (p *Protocol) Start() {
 				if !p.sendJustifications(resps.ToResponses()) {
 					return
 				}
+			case ExtractionPhase:
+				if !p.sendExtraction(justifs.ToJustifications()) {
+					return
+				}
+			case ReconstructionPhase:
+				if !p.sendReconstruction(extracts.ToExtractions()) {
+					return
+				}
 			case FinishPhase:
-				p.finish(justifs.ToJustifications())
+				if gjkr {
+					p.finishReconstruction(recons.ToReconstructions())
+				} else {
+					p.finish(justifs.ToJustifications())
+				}
 				return
 			}
 		case newDeal := <-p.board.IncomingDeal():
@@ -154,6 +242,14 @@ func (p *Protocol) Start() {
 			if err := p.verify(&newJust); err == nil {
 				justifs.Push(&newJust)
 			}
+		case newExtract := <-incomingExtraction:
+			if err := p.verify(&newExtract); err == nil {
+				extracts.Push(&newExtract)
+			}
+		case newRecon := <-incomingReconstruction:
+			if err := p.verify(&newRecon); err == nil {
+				recons.Push(&newRecon)
+			}
 		}
 	}
 }
@@ -198,8 +294,10 @@ func (p *Protocol) startFast() {
 		return false
 	}
 	for {
+		p.idle()
 		select {
 		case newPhase := <-p.phaser.NextPhase():
+			p.transition(newPhase)
 			switch newPhase {
 			case DealPhase:
 				p.Info("phaser", "msg", "moving to sending deals phase")
@@ -279,6 +377,26 @@ func (p *Protocol) startFast() {
 	}
 }
 
+// idle notifies an IdleBoard that the protocol waits for the next packet or
+// phase.
+func (p *Protocol) idle() {
+	if b, ok := p.board.(IdleBoard); ok {
+		b.Idle()
+	}
+}
+
+// transition reports the move to a new phase.
+func (p *Protocol) transition(phase Phase) {
+	now := p.clock.Now()
+	p.dkg.c.emit(event.PhaseTransition{
+		From:    p.phase.String(),
+		To:      phase.String(),
+		Elapsed: now.Sub(p.since),
+	})
+	p.phase = phase
+	p.since = now
+}
+
 func (p *Protocol) verify(packet Packet) error {
 	if p.skipVerif {
 		return nil
@@ -347,6 +465,55 @@ func (p *Protocol) finish(justifs []*JustificationBundle) {
 	}
 }
 
+// sendExtraction processes the justifications and sends out the Feldman
+// commitments of this node in GJKR mode.
+func (p *Protocol) sendExtraction(justifs []*JustificationBundle) bool {
+	if _, err := p.dkg.ProcessJustifications(justifs); err != nil {
+		p.res <- OptionResult{
+			Error: err,
+		}
+		return false
+	}
+	bundle, err := p.dkg.Extraction()
+	if err != nil {
+		p.res <- OptionResult{
+			Error: err,
+		}
+		return false
+	}
+	if bundle != nil {
+		p.Info("sendExtraction", "sending out extraction bundle")
+		p.board.(GJKRBoard).PushExtractions(bundle)
+	}
+	return true
+}
+
+// sendReconstruction processes the extractions and sends out the shares of
+// this node for the dealers to reconstruct, if any, in GJKR mode.
+func (p *Protocol) sendReconstruction(extracts []*ExtractionBundle) bool {
+	res, recon, err := p.dkg.ProcessExtractions(extracts)
+	if err != nil || res != nil {
+		p.res <- OptionResult{
+			Error:  err,
+			Result: res,
+		}
+		return false
+	}
+	if recon != nil {
+		p.Info("sendReconstruction", "sending", fmt.Sprintf("shares of %d dealers", len(recon.Shares)))
+		p.board.(GJKRBoard).PushReconstructions(recon)
+	}
+	return true
+}
+
+func (p *Protocol) finishReconstruction(recons []*ReconstructionBundle) {
+	res, err := p.dkg.ProcessReconstructions(recons)
+	p.res <- OptionResult{
+		Error:  err,
+		Result: res,
+	}
+}
+
 func (p *Protocol) WaitEnd() <-chan OptionResult {
 	return p.res
 }
@@ -420,6 +587,22 @@ func (s *set) ToJustifications() []*JustificationBundle {
 	return justs
 }
 
+func (s *set) ToExtractions() []*ExtractionBundle {
+	extracts := make([]*ExtractionBundle, 0, len(s.vals))
+	for _, p := range s.vals {
+		extracts = append(extracts, p.(*ExtractionBundle))
+	}
+	return extracts
+}
+
+func (s *set) ToReconstructions() []*ReconstructionBundle {
+	recons := make([]*ReconstructionBundle, 0, len(s.vals))
+	for _, p := range s.vals {
+		recons = append(recons, p.(*ReconstructionBundle))
+	}
+	return recons
+}
+
 func (s *set) Len() int {
 	return len(s.vals)
 }
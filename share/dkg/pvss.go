@@ -0,0 +1,348 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/pvss"
+)
+
+// This file implements a non-interactive and publicly verifiable DKG based on
+// the PVSS scheme of Schoenmakers. Each dealer publishes a single
+// PVSSDealBundle holding the commitments of its polynomial, with respect to
+// the base point returned by PVSSBase, and the share of each node encrypted
+// under the longterm key of the node, along with a proof that the encrypted
+// share is consistent with the commitments. There is no response nor
+// justification phase: anyone, e.g. a smart contract acting as the Board, can
+// verify the bundles and compute the qualified dealers and the distributed
+// key with VerifyPVSSDeals from the bundles alone.
+//
+// As with PVSS, the distributed secret is the point S = s*G where G is the
+// standard base point, and its public commitment is s*H where H is the PVSS
+// base point. The share of each node is s_i*G, which the node obtains by
+// decrypting its aggregated encrypted share with DecryptShare. Any threshold
+// of decrypted shares recovers S. Since no node learns the scalar s_i, the
+// outcome is a PVSSResult rather than a DistKeyShare: it cannot be used for
+// threshold signing with sign/tbls, encoded as a Result, or reshared with
+// the interactive protocol.
+
+// PVSSBase returns the base point H of the commitments of the publicly
+// verifiable DKG. It is derived deterministically so that nobody knows its
+// discrete logarithm with respect to the standard base point.
+func PVSSBase(s Suite) kyber.Point {
+	return s.Point().Pick(s.XOF([]byte("drand/kyber/share/dkg: pvss base")))
+}
+
+var _ Packet = (*PVSSDealBundle)(nil)
+
+// PVSSDealBundle is the only message sent out by dealers in the publicly
+// verifiable DKG.
+type PVSSDealBundle struct {
+	DealerIndex uint32
+	// Public coefficients of the polynomial used to create the shares, with
+	// respect to the PVSS base point
+	Public []kyber.Point
+	// Encrypted shares and their consistency proof. The index of each share
+	// is the index of its share holder.
+	EncShares []*pvss.PubVerShare
+	// SessionID of the current run
+	SessionID []byte
+	// Signature over the hash of the whole bundle
+	Signature []byte
+}
+
+// Hash hashes the index, public coefficients and encrypted shares
+func (p *PVSSDealBundle) Hash() []byte {
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, p.DealerIndex)
+	for _, c := range p.Public {
+		cbuff, _ := c.MarshalBinary()
+		h.Write(cbuff)
+	}
+	for _, es := range p.EncShares {
+		if es == nil {
+			continue
+		}
+		binary.Write(h, binary.BigEndian, uint32(es.S.I))
+		for _, pt := range []kyber.Point{es.S.V, es.P.VG, es.P.VH} {
+			if pt == nil {
+				continue
+			}
+			buff, _ := pt.MarshalBinary()
+			h.Write(buff)
+		}
+		for _, sc := range []kyber.Scalar{es.P.C, es.P.R} {
+			if sc == nil {
+				continue
+			}
+			buff, _ := sc.MarshalBinary()
+			h.Write(buff)
+		}
+	}
+	h.Write(p.SessionID)
+	return h.Sum(nil)
+}
+
+func (p *PVSSDealBundle) Index() Index {
+	return p.DealerIndex
+}
+
+func (p *PVSSDealBundle) Sig() []byte {
+	return p.Signature
+}
+
+// PVSSDeal returns the deal bundle of the node holding the longterm key of
+// the config for a publicly verifiable DKG among the NewNodes. Only the
+// Suite, Longterm, NewNodes, Threshold, Reader, UserReaderOnly, Nonce and
+// Auth fields of the config are used.
+func PVSSDeal(c *Config) (*PVSSDealBundle, error) {
+	if err := c.checkPVSS(); err != nil {
+		return nil, err
+	}
+	pub := c.Suite.Point().Mul(c.Longterm, nil)
+	idx, ok := findPub(c.NewNodes, pub)
+	if !ok {
+		return nil, errors.New("dkg: public key not found in new list")
+	}
+	secret, err := c.pickSecret()
+	if err != nil {
+		return nil, err
+	}
+	H := PVSSBase(c.Suite)
+	priPoly := share.NewPriPoly(c.Suite, c.pvssThreshold(), secret, c.Suite.RandomStream())
+	n := len(c.NewNodes)
+	HS := make([]kyber.Point, n)
+	X := make([]kyber.Point, n)
+	values := make([]kyber.Scalar, n)
+	for i, node := range c.NewNodes {
+		HS[i] = H
+		X[i] = node.Public
+		values[i] = priPoly.Eval(int(node.Index)).V
+	}
+	proofs, _, sX, err := dleq.NewDLEQProofBatch(c.Suite, HS, X, values)
+	if err != nil {
+		return nil, err
+	}
+	encShares := make([]*pvss.PubVerShare, n)
+	for i, node := range c.NewNodes {
+		encShares[i] = &pvss.PubVerShare{
+			S: share.PubShare{I: int(node.Index), V: sX[i]},
+			P: *proofs[i],
+		}
+	}
+	_, commits := priPoly.Commit(H).Info()
+	bundle := &PVSSDealBundle{
+		DealerIndex: idx,
+		Public:      commits,
+		EncShares:   encShares,
+		SessionID:   c.Nonce,
+	}
+	bundle.Signature, err = c.Auth.Sign(c.Longterm, bundle.Hash())
+	return bundle, err
+}
+
+// PVSSResult is the outcome of the publicly verifiable DKG, computed by
+// VerifyPVSSDeals from the deal bundles alone. Unlike a Result, it holds
+// no DistKeyShare, as the share of each node is the point s_i*G.
+type PVSSResult struct {
+	// QUAL is the list of dealers whose deal is valid.
+	QUAL []Node
+	// Nodes is the list of share holders.
+	Nodes []Node
+	// Commits are the coefficients of the distributed polynomial with respect
+	// to the PVSS base point. The first one is the commitment s*H to the
+	// distributed secret.
+	Commits []kyber.Point
+	// EncShares are the shares of each node encrypted under its longterm
+	// key, in the order of Nodes.
+	EncShares []*share.PubShare
+}
+
+// VerifyPVSSDeals verifies the deal bundles of a publicly verifiable DKG and
+// returns its result. Bundles with an invalid signature, session ID,
+// commitments or encrypted share are ignored, so that nobody can exclude a
+// dealer by posting bundles on its behalf. Rebroadcasts of the same bundle
+// count once, but all the bundles of a dealer signing two different ones are
+// ignored. It returns an error if there are less valid deals than the
+// threshold. Only the Suite, NewNodes, Threshold, Nonce and
+// Auth fields of the config are used.
+func VerifyPVSSDeals(c *Config, bundles []*PVSSDealBundle) (*PVSSResult, error) {
+	if err := c.checkPVSS(); err != nil {
+		return nil, err
+	}
+	t := c.pvssThreshold()
+	H := PVSSBase(c.Suite)
+	valid := make(map[uint32]*PVSSDealBundle)
+	var evicted []Index
+	for _, bundle := range bundles {
+		if bundle == nil || contains(evicted, bundle.DealerIndex) {
+			continue
+		}
+		if err := verifyPVSSDeal(c, H, t, bundle); err != nil {
+			c.Error(fmt.Sprintf("PVSS deal from dealer %d invalid: %v", bundle.DealerIndex, err))
+			continue
+		}
+		if prev, ok := valid[bundle.DealerIndex]; ok {
+			if !bytes.Equal(prev.Hash(), bundle.Hash()) {
+				// two different valid bundles from the same dealer - clear
+				// violation
+				c.Error("PVSS deal bundle equivocation - evicting dealer", bundle.DealerIndex)
+				delete(valid, bundle.DealerIndex)
+				evicted = append(evicted, bundle.DealerIndex)
+			}
+			// same bundle just rebroadcasted otherwise
+			continue
+		}
+		valid[bundle.DealerIndex] = bundle
+	}
+
+	res := &PVSSResult{
+		Nodes:     c.NewNodes,
+		EncShares: make([]*share.PubShare, len(c.NewNodes)),
+	}
+	for i, node := range c.NewNodes {
+		res.EncShares[i] = &share.PubShare{I: int(node.Index), V: c.Suite.Point().Null()}
+	}
+	var finalPub *share.PubPoly
+	for _, node := range c.NewNodes {
+		bundle, ok := valid[node.Index]
+		if !ok {
+			continue
+		}
+		res.QUAL = append(res.QUAL, node)
+		for i, es := range bundle.EncShares {
+			res.EncShares[i].V.Add(res.EncShares[i].V, es.S.V)
+		}
+		pub := share.NewPubPoly(c.Suite, H, bundle.Public)
+		if finalPub == nil {
+			finalPub = pub
+			continue
+		}
+		var err error
+		if finalPub, err = finalPub.Add(pub); err != nil {
+			return nil, err
+		}
+	}
+	if len(res.QUAL) < t {
+		return nil, fmt.Errorf("dkg: only %d/%d valid pvss deals - dkg abort", len(res.QUAL), t)
+	}
+	_, res.Commits = finalPub.Info()
+	return res, nil
+}
+
+// verifyPVSSDeal returns an error if the bundle is not a valid deal.
+func verifyPVSSDeal(c *Config, H kyber.Point, t int, bundle *PVSSDealBundle) error {
+	if !isIndexIncluded(c.NewNodes, bundle.DealerIndex) {
+		return errors.New("dealer not in the group")
+	}
+	if !bytes.Equal(bundle.SessionID, c.Nonce) {
+		return errors.New("invalid session ID")
+	}
+	if len(bundle.Public) != t {
+		return errors.New("invalid number of commitments")
+	}
+	for _, p := range bundle.Public {
+		if p == nil {
+			return errors.New("nil commitment")
+		}
+	}
+	if len(bundle.EncShares) != len(c.NewNodes) {
+		return errors.New("invalid number of shares")
+	}
+	if err := VerifyPacketSignature(c, bundle); err != nil {
+		return err
+	}
+	pubPoly := share.NewPubPoly(c.Suite, H, bundle.Public)
+	for i, node := range c.NewNodes {
+		es := bundle.EncShares[i]
+		if es == nil || es.S.I != int(node.Index) || es.S.V == nil ||
+			es.P.C == nil || es.P.R == nil || es.P.VG == nil || es.P.VH == nil {
+			return fmt.Errorf("invalid share for node %d", node.Index)
+		}
+		sH := pubPoly.Eval(int(node.Index)).V
+		if err := es.P.Verify(c.Suite, H, node.Public, sH, es.S.V); err != nil {
+			return fmt.Errorf("invalid share for node %d: %v", node.Index, err)
+		}
+	}
+	return nil
+}
+
+// DecryptShare decrypts the share s_i*G of the node holding the given
+// longterm key, and returns it with a proof of correct decryption.
+func (r *PVSSResult) DecryptShare(suite Suite, longterm kyber.Scalar) (*pvss.PubVerShare, error) {
+	pub := suite.Point().Mul(longterm, nil)
+	for i, node := range r.Nodes {
+		if !node.Public.Equal(pub) {
+			continue
+		}
+		encShare := r.EncShares[i]
+		V := suite.Point().Mul(suite.Scalar().Inv(longterm), encShare.V)
+		proof, _, _, err := dleq.NewDLEQProof(suite, suite.Point().Base(), V, longterm)
+		if err != nil {
+			return nil, err
+		}
+		return &pvss.PubVerShare{S: share.PubShare{I: encShare.I, V: V}, P: *proof}, nil
+	}
+	return nil, errors.New("dkg: public key not found in the share holders")
+}
+
+// VerifyShare checks that the decrypted share is the correct decryption of
+// the encrypted share of its node.
+func (r *PVSSResult) VerifyShare(suite Suite, decShare *pvss.PubVerShare) error {
+	for i, node := range r.Nodes {
+		if int(node.Index) != decShare.S.I {
+			continue
+		}
+		return decShare.P.Verify(suite, suite.Point().Base(), decShare.S.V, node.Public, r.EncShares[i].V)
+	}
+	return errors.New("dkg: share index not found in the share holders")
+}
+
+// RecoverSecret verifies the decrypted shares and recovers the distributed
+// secret s*G from a threshold of valid ones.
+func (r *PVSSResult) RecoverSecret(suite Suite, decShares []*pvss.PubVerShare) (kyber.Point, error) {
+	var shares []*share.PubShare
+	for _, ds := range decShares {
+		if ds == nil || r.VerifyShare(suite, ds) != nil {
+			continue
+		}
+		shares = append(shares, &share.PubShare{I: ds.S.I, V: ds.S.V})
+	}
+	t := len(r.Commits)
+	if len(shares) < t {
+		return nil, fmt.Errorf("dkg: only %d/%d valid decrypted shares", len(shares), t)
+	}
+	return share.RecoverCommit(suite, shares, t, len(r.Nodes))
+}
+
+// PublicKey returns the commitment s*H to the distributed secret, with
+// respect to the PVSS base point.
+func (r *PVSSResult) PublicKey() kyber.Point {
+	return r.Commits[0]
+}
+
+func (c *Config) checkPVSS() error {
+	if len(c.NewNodes) == 0 {
+		return errors.New("dkg: can't run with empty node list")
+	}
+	if len(c.Nonce) != NonceLength {
+		return errors.New("dkg: invalid nonce length")
+	}
+	if c.Auth == nil {
+		return errors.New("dkg: need authentication scheme")
+	}
+	return c.CheckForDuplicates()
+}
+
+func (c *Config) pvssThreshold() int {
+	if c.Threshold != 0 {
+		return c.Threshold
+	}
+	return MinimumT(len(c.NewNodes))
+}
@@ -0,0 +1,90 @@
+package dkg
+
+import (
+	"testing"
+
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/share/pvss"
+	"github.com/drand/kyber/sign/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPVSSDKG(t *testing.T) {
+	n := 7
+	thr := 4
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	tns := GenerateTestNodes(suite, n)
+	// indices don't need to be sequential
+	tns[3].Index = 42
+	list := NodesFromTest(tns)
+	conf := Config{
+		Suite:     suite,
+		NewNodes:  list,
+		Threshold: thr,
+		Auth:      schnorr.NewScheme(suite),
+		Nonce:     GetNonce(),
+	}
+
+	var bundles []*PVSSDealBundle
+	for _, node := range tns {
+		c := conf
+		c.Longterm = node.Private
+		bundle, err := PVSSDeal(&c)
+		require.NoError(t, err)
+		bundles = append(bundles, bundle)
+	}
+	// dealer 0 gives an inconsistent encrypted share
+	bundles[0].EncShares[2].S.V = suite.Point().Pick(suite.RandomStream())
+	// dealer 1 gives an invalid signature
+	bundles[1].Signature[0] ^= 0x01
+	// dealer 2 signs two different bundles
+	c := conf
+	c.Longterm = tns[2].Private
+	dup, err := PVSSDeal(&c)
+	require.NoError(t, err)
+	bundles = append(bundles, dup)
+	// dealer 3 rebroadcasts its bundle
+	bundles = append(bundles, bundles[3])
+	// anyone posts first a forged bundle of dealer 4
+	forged := *bundles[5]
+	forged.DealerIndex = tns[4].Index
+	bundles = append([]*PVSSDealBundle{&forged}, bundles...)
+
+	res, err := VerifyPVSSDeals(&conf, bundles)
+	require.NoError(t, err)
+	require.Len(t, res.QUAL, n-3)
+	for _, node := range res.QUAL {
+		require.NotContains(t, []Index{0, 1, 2}, node.Index)
+	}
+	require.Contains(t, res.QUAL, list[3])
+	require.Contains(t, res.QUAL, list[4])
+	require.Len(t, res.Commits, thr)
+
+	// the result only depends on the transcript
+	res2, err := VerifyPVSSDeals(&conf, bundles)
+	require.NoError(t, err)
+	require.True(t, res.PublicKey().Equal(res2.PublicKey()))
+
+	var decShares []*pvss.PubVerShare
+	for _, node := range tns {
+		ds, err := res.DecryptShare(suite, node.Private)
+		require.NoError(t, err)
+		require.NoError(t, res.VerifyShare(suite, ds))
+		decShares = append(decShares, ds)
+	}
+	secret, err := res.RecoverSecret(suite, decShares[:thr])
+	require.NoError(t, err)
+	secret2, err := res.RecoverSecret(suite, decShares[n-thr:])
+	require.NoError(t, err)
+	require.True(t, secret.Equal(secret2))
+
+	// invalid decrypted shares are ignored
+	decShares[0].S.V = suite.Point().Pick(suite.RandomStream())
+	require.Error(t, res.VerifyShare(suite, decShares[0]))
+	_, err = res.RecoverSecret(suite, decShares[:thr])
+	require.Error(t, err)
+
+	// not enough valid deals
+	_, err = VerifyPVSSDeals(&conf, bundles[:thr+1])
+	require.Error(t, err)
+}
@@ -0,0 +1,244 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drand/kyber/share/dkg"
+)
+
+var _ dkg.GJKRBoard = (*board)(nil)
+var _ dkg.IdleBoard = (*board)(nil)
+
+// board connects a node to the simulated network. Its channels are
+// unbuffered, so that a packet is delivered only when the protocol of the
+// node reads it. As an IdleBoard, it signals the simulation each time the
+// protocol of the node waits for the next packet or phase.
+type board struct {
+	s        *Simulation
+	index    uint32
+	idle     chan struct{}
+	deals    chan dkg.DealBundle
+	resps    chan dkg.ResponseBundle
+	justifs  chan dkg.JustificationBundle
+	extracts chan dkg.ExtractionBundle
+	recons   chan dkg.ReconstructionBundle
+}
+
+func newBoard(s *Simulation, index uint32) *board {
+	return &board{
+		s:        s,
+		index:    index,
+		idle:     make(chan struct{}),
+		deals:    make(chan dkg.DealBundle),
+		resps:    make(chan dkg.ResponseBundle),
+		justifs:  make(chan dkg.JustificationBundle),
+		extracts: make(chan dkg.ExtractionBundle),
+		recons:   make(chan dkg.ReconstructionBundle),
+	}
+}
+
+func (b *board) deliver(p dkg.Packet) {
+	switch p := p.(type) {
+	case *dkg.DealBundle:
+		b.deals <- *p
+	case *dkg.ResponseBundle:
+		b.resps <- *p
+	case *dkg.JustificationBundle:
+		b.justifs <- *p
+	case *dkg.ExtractionBundle:
+		b.extracts <- *p
+	case *dkg.ReconstructionBundle:
+		b.recons <- *p
+	}
+}
+
+func (b *board) PushDeals(d *dkg.DealBundle) {
+	b.s.send(b.index, d)
+}
+
+func (b *board) Idle() {
+	b.idle <- struct{}{}
+}
+
+func (b *board) IncomingDeal() <-chan dkg.DealBundle {
+	return b.deals
+}
+
+func (b *board) PushResponses(r *dkg.ResponseBundle) {
+	b.s.send(b.index, r)
+}
+
+func (b *board) IncomingResponse() <-chan dkg.ResponseBundle {
+	return b.resps
+}
+
+func (b *board) PushJustifications(j *dkg.JustificationBundle) {
+	b.s.send(b.index, j)
+}
+
+func (b *board) IncomingJustification() <-chan dkg.JustificationBundle {
+	return b.justifs
+}
+
+func (b *board) PushExtractions(e *dkg.ExtractionBundle) {
+	b.s.send(b.index, e)
+}
+
+func (b *board) IncomingExtraction() <-chan dkg.ExtractionBundle {
+	return b.extracts
+}
+
+func (b *board) PushReconstructions(r *dkg.ReconstructionBundle) {
+	b.s.send(b.index, r)
+}
+
+func (b *board) IncomingReconstruction() <-chan dkg.ReconstructionBundle {
+	return b.recons
+}
+
+// phaser signals the phases scheduled by the simulation.
+type phaser struct {
+	out chan dkg.Phase
+}
+
+func (p *phaser) NextPhase() chan dkg.Phase {
+	return p.out
+}
+
+// clonePacket returns a copy of the packet that does not share its slices
+// with p, since the dkg package sorts some of them in place when hashing.
+func clonePacket(p dkg.Packet) dkg.Packet {
+	switch b := p.(type) {
+	case *dkg.DealBundle:
+		c := *b
+		c.Deals = append([]dkg.Deal(nil), b.Deals...)
+		for i := range c.Deals {
+			c.Deals[i].EncryptedShare = append([]byte(nil), b.Deals[i].EncryptedShare...)
+		}
+		return &c
+	case *dkg.ResponseBundle:
+		c := *b
+		c.Responses = append([]dkg.Response(nil), b.Responses...)
+		return &c
+	case *dkg.JustificationBundle:
+		c := *b
+		c.Justifications = append([]dkg.Justification(nil), b.Justifications...)
+		return &c
+	case *dkg.ExtractionBundle:
+		c := *b
+		return &c
+	case *dkg.ReconstructionBundle:
+		c := *b
+		c.Shares = append([]dkg.ReconstructionShare(nil), b.Shares...)
+		return &c
+	}
+	return p
+}
+
+// Kind is the type of a packet of the protocol.
+type Kind int
+
+const (
+	DealKind Kind = iota
+	ResponseKind
+	JustificationKind
+	ExtractionKind
+	ReconstructionKind
+)
+
+// KindOf returns the kind of the packet.
+func KindOf(p dkg.Packet) Kind {
+	switch p.(type) {
+	case *dkg.DealBundle:
+		return DealKind
+	case *dkg.ResponseBundle:
+		return ResponseKind
+	case *dkg.JustificationBundle:
+		return JustificationKind
+	case *dkg.ExtractionBundle:
+		return ExtractionKind
+	case *dkg.ReconstructionBundle:
+		return ReconstructionKind
+	}
+	panic(fmt.Sprintf("sim: unknown packet type %T", p))
+}
+
+func (k Kind) String() string {
+	switch k {
+	case DealKind:
+		return "deal"
+	case ResponseKind:
+		return "response"
+	case JustificationKind:
+		return "justification"
+	case ExtractionKind:
+		return "extraction"
+	case ReconstructionKind:
+		return "reconstruction"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// EventType is the type of an event of the trace.
+type EventType int
+
+const (
+	// PhaseEvent is a new phase signaled to a node.
+	PhaseEvent EventType = iota
+	// SendEvent is a packet sent from a node to another, after the faults
+	// are applied.
+	SendEvent
+	// DropEvent is a packet lost on the network, or sent from or to a crashed
+	// node.
+	DropEvent
+	// DeliverEvent is a packet delivered to a node.
+	DeliverEvent
+	// FinishEvent is the end of the protocol of a node.
+	FinishEvent
+)
+
+func (t EventType) String() string {
+	switch t {
+	case PhaseEvent:
+		return "phase"
+	case SendEvent:
+		return "send"
+	case DropEvent:
+		return "drop"
+	case DeliverEvent:
+		return "deliver"
+	case FinishEvent:
+		return "finish"
+	default:
+		return fmt.Sprintf("event(%d)", int(t))
+	}
+}
+
+// Event is an entry of the trace of a simulation.
+type Event struct {
+	At   time.Duration
+	Type EventType
+	// From and To are the sender and recipient of a packet, or both the
+	// index of the node for phase and finish events.
+	From, To uint32
+	Kind     Kind
+	Phase    dkg.Phase
+	Note     string
+}
+
+func (e Event) String() string {
+	switch e.Type {
+	case PhaseEvent:
+		return fmt.Sprintf("%v %s node %d: %s", e.At, e.Type, e.To, e.Phase)
+	case FinishEvent:
+		return fmt.Sprintf("%v %s node %d: %s", e.At, e.Type, e.To, e.Note)
+	default:
+		s := fmt.Sprintf("%v %s %s %d -> %d", e.At, e.Type, e.Kind, e.From, e.To)
+		if e.Note != "" {
+			s += ": " + e.Note
+		}
+		return s
+	}
+}
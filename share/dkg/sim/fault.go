@@ -0,0 +1,219 @@
+package sim
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/drand/kyber/share/dkg"
+)
+
+// Message is a packet sent from a node to another on the simulated network.
+// Faults can modify the packet, the delay or drop the message.
+type Message struct {
+	From, To uint32
+	Packet   dkg.Packet
+	// Delay is the time the message spends on the network.
+	Delay time.Duration
+	// Dropped messages are not delivered.
+	Dropped bool
+}
+
+// Fault is a rule applied by the network to every message sent. The faults
+// must draw all their random choices from Env.Rand for the simulation to be
+// reproducible. An error aborts the simulation, which returns it from Run.
+type Fault interface {
+	Apply(e *Env, m *Message) error
+}
+
+// FaultFunc is a function implementing Fault.
+type FaultFunc func(e *Env, m *Message) error
+
+// Apply calls f(e, m).
+func (f FaultFunc) Apply(e *Env, m *Message) error {
+	return f(e, m)
+}
+
+// Env gives the faults access to the simulation.
+type Env struct {
+	s *Simulation
+}
+
+// Now returns the time elapsed since the start of the simulation.
+func (e *Env) Now() time.Duration {
+	return e.s.Now()
+}
+
+// Rand returns the seeded source of randomness of the simulation.
+func (e *Env) Rand() *rand.Rand {
+	return e.s.rand
+}
+
+// Config returns a copy of the DKG config of the node, including its
+// longterm key, so that byzantine faults can forge packets in its name.
+func (e *Env) Config(node uint32) *dkg.Config {
+	c := *e.s.nodes[node].conf
+	return &c
+}
+
+// Sign signs the packet with the longterm key of the node.
+func (e *Env) Sign(node uint32, p dkg.Packet) error {
+	c := e.s.nodes[node].conf
+	sig, err := c.Auth.Sign(c.Longterm, p.Hash())
+	if err != nil {
+		return err
+	}
+	switch b := p.(type) {
+	case *dkg.DealBundle:
+		b.Signature = sig
+	case *dkg.ResponseBundle:
+		b.Signature = sig
+	case *dkg.JustificationBundle:
+		b.Signature = sig
+	case *dkg.ExtractionBundle:
+		b.Signature = sig
+	case *dkg.ReconstructionBundle:
+		b.Signature = sig
+	}
+	return nil
+}
+
+// Send sends an additional message on the network, without applying the
+// faults to it.
+func (e *Env) Send(m *Message) {
+	e.s.post(m)
+}
+
+// Filter selects the messages a fault applies to. A nil filter selects all
+// the messages.
+type Filter func(m *Message) bool
+
+func (f Filter) match(m *Message) bool {
+	return f == nil || f(m)
+}
+
+// From selects the messages sent by the given nodes.
+func From(nodes ...uint32) Filter {
+	return func(m *Message) bool {
+		return contains(nodes, m.From)
+	}
+}
+
+// To selects the messages sent to the given nodes.
+func To(nodes ...uint32) Filter {
+	return func(m *Message) bool {
+		return contains(nodes, m.To)
+	}
+}
+
+// Kinds selects the messages carrying the given kinds of packets.
+func Kinds(kinds ...Kind) Filter {
+	return func(m *Message) bool {
+		k := KindOf(m.Packet)
+		for _, kind := range kinds {
+			if k == kind {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match selects the messages selected by all the filters.
+func Match(filters ...Filter) Filter {
+	return func(m *Message) bool {
+		for _, f := range filters {
+			if !f.match(m) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func contains(list []uint32, i uint32) bool {
+	for _, j := range list {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+// Loss drops the selected messages with the given probability.
+func Loss(rate float64, f Filter) Fault {
+	return FaultFunc(func(e *Env, m *Message) error {
+		if f.match(m) && e.Rand().Float64() < rate {
+			m.Dropped = true
+		}
+		return nil
+	})
+}
+
+// Delay adds a random delay between min and max to the selected messages.
+// Since each message gets its own delay, it also reorders them.
+func Delay(min, max time.Duration, f Filter) Fault {
+	return FaultFunc(func(e *Env, m *Message) error {
+		if !f.match(m) {
+			return nil
+		}
+		m.Delay += min
+		if max > min {
+			m.Delay += time.Duration(e.Rand().Int63n(int64(max - min + 1)))
+		}
+		return nil
+	})
+}
+
+// InvalidShare makes the dealer send invalid encrypted shares to the victims.
+// The deal bundle is signed again by the dealer, and is the same for all the
+// recipients.
+func InvalidShare(dealer uint32, victims ...uint32) Fault {
+	var forged dkg.Packet
+	return FaultFunc(func(e *Env, m *Message) error {
+		if m.From != dealer || KindOf(m.Packet) != DealKind {
+			return nil
+		}
+		if forged == nil {
+			bundle := clonePacket(m.Packet).(*dkg.DealBundle)
+			for i, d := range bundle.Deals {
+				if contains(victims, d.ShareIndex) {
+					share := bundle.Deals[i].EncryptedShare
+					share[len(share)-1] ^= 0xff
+				}
+			}
+			if err := e.Sign(dealer, bundle); err != nil {
+				return err
+			}
+			forged = bundle
+		}
+		m.Packet = clonePacket(forged)
+		return nil
+	})
+}
+
+// Equivocate makes the dealer send a second deal bundle, for a different
+// polynomial, to the selected recipients in addition to its regular one.
+func Equivocate(dealer uint32, f Filter) Fault {
+	var forged dkg.Packet
+	return FaultFunc(func(e *Env, m *Message) error {
+		if m.From != dealer || KindOf(m.Packet) != DealKind || !f.match(m) {
+			return nil
+		}
+		if forged == nil {
+			gen, err := dkg.NewDistKeyHandler(e.Config(dealer))
+			if err != nil {
+				return err
+			}
+			if forged, err = gen.Deals(); err != nil {
+				return err
+			}
+		}
+		e.Send(&Message{
+			From:   m.From,
+			To:     m.To,
+			Packet: clonePacket(forged),
+			Delay:  m.Delay + time.Duration(e.Rand().Int63n(int64(m.Delay)+1)),
+		})
+		return nil
+	})
+}
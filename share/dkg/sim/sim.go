@@ -0,0 +1,382 @@
+// Package sim runs the DKG protocol between simulated nodes over a virtual
+// network, to test its behavior under packet loss, reordering, delays,
+// crashes and byzantine dealers.
+//
+// A Simulation drives one dkg.Protocol per node. The time is virtual and
+// given by a clockwork fake clock: the phases of the nodes and the deliveries
+// of the packets are events scheduled on this clock, and the simulation hands
+// them one by one to the nodes, waiting for each node to have processed an
+// event before moving on to the next one. The faults of the network are
+// scripted with Fault rules, and all their random choices are drawn from a
+// source seeded with Config.Seed, so that a run can be replayed exactly by
+// running the simulation again with the same seed and faults.
+package sim
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/share/dkg"
+	"github.com/drand/kyber/sign/schnorr"
+	clock "github.com/jonboulle/clockwork"
+)
+
+const (
+	// DefaultPeriod is the default duration of a phase.
+	DefaultPeriod = 10 * time.Second
+	// DefaultLatency is the default time a packet spends on the network.
+	DefaultLatency = 10 * time.Millisecond
+)
+
+// Config describes the DKG to simulate.
+type Config struct {
+	// Suite is the suite used by the nodes. It defaults to Ed25519.
+	Suite dkg.Suite
+	// N is the number of nodes, indexed from 0 to N-1.
+	N int
+	// Threshold of the DKG. It defaults to N/2+1.
+	Threshold int
+	// Mode and FastSync are passed to the dkg.Config of the nodes.
+	Mode     dkg.Mode
+	FastSync bool
+	// Seed seeds all the random choices of the simulation: the longterm keys
+	// of the nodes, the nonce of the DKG and the decisions of the faults.
+	Seed int64
+	// Period is the duration of each phase of the protocol.
+	Period time.Duration
+	// Latency is the time a packet spends on the network, before any delay
+	// added by the faults.
+	Latency time.Duration
+	// Faults are applied in order to every packet sent on the network.
+	Faults []Fault
+	// Crashes maps the nodes that crash to the time at which they crash. A
+	// crashed node does not send nor receive any packet anymore.
+	Crashes map[uint32]time.Duration
+	// Log is passed to the dkg.Config of the nodes.
+	Log dkg.Logger
+}
+
+// Report is the outcome of a simulation.
+type Report struct {
+	// Results holds the result of the protocol of each node that did not
+	// crash.
+	Results map[uint32]dkg.OptionResult
+	// Trace is the list of events of the simulation, in order. Two runs with
+	// the same configuration have the same trace.
+	Trace []Event
+}
+
+// Agree returns an error if a node that did not crash failed, or if the
+// nodes ended up with different distributed keys or qualified sets. The
+// ignored nodes, typically the byzantine ones, are not checked.
+func (r *Report) Agree(ignored ...uint32) error {
+	var ref *dkg.Result
+	for i, res := range r.Results {
+		if contains(ignored, i) {
+			continue
+		}
+		if res.Error != nil {
+			return fmt.Errorf("sim: node %d failed: %v", i, res.Error)
+		}
+		if ref == nil {
+			ref = res.Result
+			continue
+		}
+		if !ref.Key.Public().Equal(res.Result.Key.Public()) {
+			return fmt.Errorf("sim: node %d has a different distributed key", i)
+		}
+		if !sameNodes(ref.QUAL, res.Result.QUAL) {
+			return fmt.Errorf("sim: node %d has a different qualified set", i)
+		}
+	}
+	return nil
+}
+
+func sameNodes(a, b []dkg.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		found := false
+		for j := range b {
+			if a[i].Equal(&b[j]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Simulation is a run of the DKG over the virtual network.
+type Simulation struct {
+	c      Config
+	clock  clock.FakeClock
+	start  time.Time
+	rand   *rand.Rand
+	nodes  []*node
+	events eventQueue
+	seq    uint64
+	trace  []Event
+	env    *Env
+	// err is the first error of a fault, which aborts the run
+	err error
+}
+
+type node struct {
+	index    uint32
+	longterm kyber.Scalar
+	conf     *dkg.Config
+	board    *board
+	phaser   *phaser
+	proto    *dkg.Protocol
+	done     chan dkg.OptionResult
+	result   *dkg.OptionResult
+}
+
+// New returns a simulation of the DKG described by the config.
+func New(c Config) (*Simulation, error) {
+	if c.N <= 0 {
+		return nil, errors.New("sim: no nodes")
+	}
+	if c.Suite == nil {
+		c.Suite = edwards25519.NewBlakeSHA256Ed25519()
+	}
+	if c.Threshold == 0 {
+		c.Threshold = c.N/2 + 1
+	}
+	if c.Period == 0 {
+		c.Period = DefaultPeriod
+	}
+	if c.Latency == 0 {
+		c.Latency = DefaultLatency
+	}
+	s := &Simulation{
+		c:     c,
+		clock: clock.NewFakeClock(),
+		rand:  rand.New(rand.NewSource(c.Seed)),
+	}
+	s.start = s.clock.Now()
+	s.env = &Env{s: s}
+
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(c.Seed))
+	stream := c.Suite.XOF(append([]byte("drand/kyber/share/dkg/sim"), seed[:]...))
+	var list []dkg.Node
+	for i := 0; i < c.N; i++ {
+		priv := c.Suite.Scalar().Pick(stream)
+		s.nodes = append(s.nodes, &node{index: uint32(i), longterm: priv})
+		list = append(list, dkg.Node{
+			Index:  uint32(i),
+			Public: c.Suite.Point().Mul(priv, nil),
+		})
+	}
+	nonce := make([]byte, dkg.NonceLength)
+	stream.XORKeyStream(nonce, nonce)
+	for _, n := range s.nodes {
+		n.conf = &dkg.Config{
+			Suite:     c.Suite,
+			Longterm:  n.longterm,
+			NewNodes:  list,
+			Threshold: c.Threshold,
+			Nonce:     nonce,
+			Auth:      schnorr.NewScheme(c.Suite),
+			Mode:      c.Mode,
+			FastSync:  c.FastSync,
+			Log:       c.Log,
+			Clock:     s.clock,
+		}
+		n.board = newBoard(s, n.index)
+		n.phaser = &phaser{out: make(chan dkg.Phase)}
+		n.done = make(chan dkg.OptionResult, 1)
+	}
+	return s, nil
+}
+
+// Clock returns the fake clock of the simulation.
+func (s *Simulation) Clock() clock.FakeClock {
+	return s.clock
+}
+
+// Now returns the time elapsed since the start of the simulation.
+func (s *Simulation) Now() time.Duration {
+	return s.clock.Since(s.start)
+}
+
+// Run runs the protocol until all the nodes finished, or until a fault
+// returns an error. A simulation can only be run once.
+func (s *Simulation) Run() (*Report, error) {
+	for _, n := range s.nodes {
+		proto, err := dkg.NewProtocol(n.conf, n.board, n.phaser, false)
+		if err != nil {
+			return nil, err
+		}
+		n.proto = proto
+		go func(n *node) { n.done <- <-proto.WaitEnd() }(n)
+		s.wait(n)
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	for i, phase := range s.nodes[0].conf.Phases() {
+		for _, n := range s.nodes {
+			s.schedule(time.Duration(i)*s.c.Period, &event{node: n.index, phase: phase})
+		}
+	}
+	for s.events.Len() > 0 && s.err == nil {
+		ev := heap.Pop(&s.events).(*event)
+		if d := ev.at - s.Now(); d > 0 {
+			s.clock.Advance(d)
+		}
+		n := s.nodes[ev.node]
+		if n.result != nil {
+			continue
+		}
+		if ev.msg == nil {
+			s.record(Event{Type: PhaseEvent, From: ev.node, To: ev.node, Phase: ev.phase})
+			n.phaser.out <- ev.phase
+			s.wait(n)
+			continue
+		}
+		if s.crashed(ev.node) {
+			s.record(Event{Type: DropEvent, From: ev.msg.From, To: ev.msg.To, Kind: KindOf(ev.msg.Packet), Note: "recipient crashed"})
+			continue
+		}
+		s.record(Event{Type: DeliverEvent, From: ev.msg.From, To: ev.msg.To, Kind: KindOf(ev.msg.Packet)})
+		n.board.deliver(ev.msg.Packet)
+		s.wait(n)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	report := &Report{
+		Results: make(map[uint32]dkg.OptionResult),
+		Trace:   s.trace,
+	}
+	for _, n := range s.nodes {
+		if n.result == nil {
+			return nil, fmt.Errorf("sim: node %d did not finish", n.index)
+		}
+		if _, crashed := s.c.Crashes[n.index]; !crashed {
+			report.Results[n.index] = *n.result
+		}
+	}
+	return report, nil
+}
+
+// wait waits until the node is idle, i.e. is waiting for the next packet or
+// phase, or has finished.
+func (s *Simulation) wait(n *node) {
+	select {
+	case <-n.board.idle:
+	case res := <-n.done:
+		n.result = &res
+		s.record(Event{Type: FinishEvent, From: n.index, To: n.index, Note: errorNote(res.Error)})
+	}
+}
+
+// send is called by the board of a node pushing a packet: it applies the
+// faults to the packet sent to each node, and schedules its deliveries. The
+// first error of a fault is kept for Run to return, and drops the message.
+func (s *Simulation) send(from uint32, p dkg.Packet) {
+	if s.crashed(from) {
+		s.record(Event{Type: DropEvent, From: from, To: from, Kind: KindOf(p), Note: "sender crashed"})
+		return
+	}
+	for _, n := range s.nodes {
+		m := &Message{
+			From:   from,
+			To:     n.index,
+			Packet: clonePacket(p),
+			Delay:  s.c.Latency,
+		}
+		for _, f := range s.c.Faults {
+			if m.Dropped {
+				break
+			}
+			if err := f.Apply(s.env, m); err != nil {
+				if s.err == nil {
+					s.err = err
+				}
+				m.Dropped = true
+			}
+		}
+		s.post(m)
+	}
+}
+
+func (s *Simulation) post(m *Message) {
+	if m.Dropped {
+		s.record(Event{Type: DropEvent, From: m.From, To: m.To, Kind: KindOf(m.Packet), Note: "lost"})
+		return
+	}
+	s.record(Event{Type: SendEvent, From: m.From, To: m.To, Kind: KindOf(m.Packet), Note: m.Delay.String()})
+	s.schedule(s.Now()+m.Delay, &event{node: m.To, msg: m})
+}
+
+func (s *Simulation) crashed(i uint32) bool {
+	at, ok := s.c.Crashes[i]
+	return ok && s.Now() >= at
+}
+
+func (s *Simulation) schedule(at time.Duration, ev *event) {
+	ev.at = at
+	ev.seq = s.seq
+	s.seq++
+	heap.Push(&s.events, ev)
+}
+
+func (s *Simulation) record(e Event) {
+	e.At = s.Now()
+	s.trace = append(s.trace, e)
+}
+
+func errorNote(err error) string {
+	if err == nil {
+		return "success"
+	}
+	return err.Error()
+}
+
+// event is either a phase signaled to a node or the delivery of a message.
+type event struct {
+	at    time.Duration
+	seq   uint64
+	node  uint32
+	phase dkg.Phase
+	msg   *Message
+}
+
+// eventQueue is a heap of events ordered by time, and then by order of
+// scheduling.
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	ev := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,151 @@
+package sim
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/drand/kyber/share/dkg"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, c Config) *Report {
+	s, err := New(c)
+	require.NoError(t, err)
+	report, err := s.Run()
+	require.NoError(t, err)
+	return report
+}
+
+// requireQUAL checks that all the nodes but the excluded ones agree on a
+// qualified set made of n nodes, none of which is excluded.
+func requireQUAL(t *testing.T, r *Report, n int, excluded ...uint32) {
+	require.NoError(t, r.Agree(excluded...))
+	for i, res := range r.Results {
+		if contains(excluded, i) {
+			continue
+		}
+		require.Len(t, res.Result.QUAL, n)
+		for _, node := range res.Result.QUAL {
+			require.NotContains(t, excluded, node.Index)
+		}
+	}
+}
+
+func TestSimulationHonest(t *testing.T) {
+	for _, mode := range []dkg.Mode{dkg.JointFeldman, dkg.GJKR} {
+		t.Run(mode.String(), func(t *testing.T) {
+			r := run(t, Config{N: 5, Mode: mode})
+			require.Len(t, r.Results, 5)
+			requireQUAL(t, r, 5)
+		})
+	}
+}
+
+func TestSimulationFastSync(t *testing.T) {
+	r := run(t, Config{N: 5, FastSync: true})
+	requireQUAL(t, r, 5)
+	// all the nodes finish as soon as they got all the responses
+	for _, e := range r.Trace {
+		if e.Type == FinishEvent {
+			require.True(t, e.At < DefaultPeriod)
+		}
+	}
+}
+
+func TestSimulationReproducible(t *testing.T) {
+	conf := func(seed int64) Config {
+		return Config{
+			N:    7,
+			Seed: seed,
+			Faults: []Fault{
+				Loss(0.1, Kinds(ResponseKind)),
+				Delay(0, 2*time.Second, nil),
+			},
+		}
+	}
+	trace := func(r *Report) []string {
+		var s []string
+		for _, e := range r.Trace {
+			s = append(s, e.String())
+		}
+		return s
+	}
+	r1 := run(t, conf(42))
+	r2 := run(t, conf(42))
+	require.Equal(t, trace(r1), trace(r2))
+	for i, res := range r1.Results {
+		require.Equal(t, res.Error, r2.Results[i].Error)
+	}
+	r3 := run(t, conf(43))
+	require.NotEqual(t, trace(r1), trace(r3))
+}
+
+func TestSimulationCrash(t *testing.T) {
+	r := run(t, Config{
+		N:       5,
+		Crashes: map[uint32]time.Duration{3: 0},
+	})
+	require.Len(t, r.Results, 4)
+	requireQUAL(t, r, 4, 3)
+}
+
+func TestSimulationLateDeals(t *testing.T) {
+	r := run(t, Config{
+		N: 5,
+		Faults: []Fault{
+			Delay(DefaultPeriod, 2*DefaultPeriod, Match(From(2), Kinds(DealKind))),
+		},
+	})
+	requireQUAL(t, r, 4, 2)
+}
+
+func TestSimulationInvalidShare(t *testing.T) {
+	for _, mode := range []dkg.Mode{dkg.JointFeldman, dkg.GJKR} {
+		t.Run(mode.String(), func(t *testing.T) {
+			// the dealer justifies the shares of the victims and stays
+			// qualified
+			r := run(t, Config{
+				N:      5,
+				Mode:   mode,
+				Faults: []Fault{InvalidShare(1, 2, 3)},
+			})
+			requireQUAL(t, r, 5)
+
+			// the dealer does not justify them and is excluded
+			r = run(t, Config{
+				N:    5,
+				Mode: mode,
+				Faults: []Fault{
+					InvalidShare(1, 2, 3),
+					Loss(1, Match(From(1), Kinds(JustificationKind))),
+				},
+			})
+			requireQUAL(t, r, 4, 1)
+		})
+	}
+}
+
+func TestSimulationEquivocation(t *testing.T) {
+	r := run(t, Config{
+		N:      5,
+		Faults: []Fault{Equivocate(4, nil)},
+	})
+	requireQUAL(t, r, 4, 4)
+}
+
+func TestSimulationFaultError(t *testing.T) {
+	failure := errors.New("fault failure")
+	s, err := New(Config{
+		N: 5,
+		Faults: []Fault{FaultFunc(func(e *Env, m *Message) error {
+			if KindOf(m.Packet) == DealKind {
+				return failure
+			}
+			return nil
+		})},
+	})
+	require.NoError(t, err)
+	_, err = s.Run()
+	require.ErrorIs(t, err, failure)
+}
@@ -2,6 +2,7 @@ package dkg
 
 import (
 	"crypto/sha256"
+	"encoding"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/drand/kyber"
+	"github.com/drand/kyber/proof/dleq"
 	"github.com/drand/kyber/share"
 )
 
@@ -144,6 +146,19 @@ type Response struct {
 	// Index of the Dealer for which this response is for
 	DealerIndex uint32
 	Status      bool
+	// Proof proves a complaint when the verifiable complaints are enabled. It
+	// is nil for successes, and for complaints about a deal the share holder
+	// didn't receive.
+	Proof *ComplaintProof
+}
+
+// ComplaintProof reveals the Diffie-Hellman key between the longterm key of a
+// share holder and the ephemeral key of its encrypted share, with a DLEQ proof
+// that it is the right one. Anyone can decrypt the share with it, and check
+// whether the complaint is legitimate.
+type ComplaintProof struct {
+	DHKey kyber.Point
+	Proof dleq.Proof
 }
 
 var _ Packet = (*ResponseBundle)(nil)
@@ -175,6 +190,14 @@ func (r *ResponseBundle) Hash() []byte {
 		} else {
 			binary.Write(h, binary.BigEndian, byte(0))
 		}
+		if p := resp.Proof; p != nil {
+			for _, m := range []encoding.BinaryMarshaler{p.DHKey, p.Proof.C, p.Proof.R, p.Proof.VG, p.Proof.VH} {
+				if m != nil {
+					buff, _ := m.MarshalBinary()
+					h.Write(buff)
+				}
+			}
+		}
 	}
 	h.Write(r.SessionID)
 	return h.Sum(nil)
@@ -214,6 +237,9 @@ type JustificationBundle struct {
 type Justification struct {
 	ShareIndex uint32
 	Share      kyber.Scalar
+	// Blinding is the blinding share revealed along the share in GJKR mode.
+	// It is nil otherwise.
+	Blinding kyber.Scalar
 }
 
 func (j *JustificationBundle) Hash() []byte {
@@ -227,6 +253,10 @@ func (j *JustificationBundle) Hash() []byte {
 		binary.Write(h, binary.BigEndian, just.ShareIndex)
 		sbuff, _ := just.Share.MarshalBinary()
 		h.Write(sbuff)
+		if just.Blinding != nil {
+			bbuff, _ := just.Blinding.MarshalBinary()
+			h.Write(bbuff)
+		}
 	}
 	h.Write(j.SessionID)
 	return h.Sum(nil)
@@ -240,6 +270,106 @@ func (j *JustificationBundle) Sig() []byte {
 	return j.Signature
 }
 
+var _ Packet = (*ExtractionBundle)(nil)
+
+// ExtractionBundle is the struct sent out by qualified dealers in GJKR mode
+// that reveals the Feldman commitments of their polynomial, along with a proof
+// that they are consistent with the Pedersen commitments sent in the deal
+// phase.
+type ExtractionBundle struct {
+	DealerIndex uint32
+	// Public coefficients of the polynomial used to create the shares
+	Public []kyber.Point
+	// Proof of knowledge of the representation of the Pedersen commitments
+	// with respect to the Feldman commitments
+	Challenge        kyber.Scalar
+	Response         kyber.Scalar
+	BlindingResponse kyber.Scalar
+	// SessionID of the current run
+	SessionID []byte
+	// Signature over the hash of the whole bundle
+	Signature []byte
+}
+
+// Hash hashes the index, public coefficients and proof
+func (e *ExtractionBundle) Hash() []byte {
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, e.DealerIndex)
+	for _, c := range e.Public {
+		cbuff, _ := c.MarshalBinary()
+		h.Write(cbuff)
+	}
+	for _, s := range []kyber.Scalar{e.Challenge, e.Response, e.BlindingResponse} {
+		if s == nil {
+			continue
+		}
+		sbuff, _ := s.MarshalBinary()
+		h.Write(sbuff)
+	}
+	h.Write(e.SessionID)
+	return h.Sum(nil)
+}
+
+func (e *ExtractionBundle) Index() Index {
+	return e.DealerIndex
+}
+
+func (e *ExtractionBundle) Sig() []byte {
+	return e.Signature
+}
+
+var _ Packet = (*ReconstructionBundle)(nil)
+
+// ReconstructionBundle is the struct sent out by share holders in GJKR mode
+// that reveals their shares of the qualified dealers that did not reveal valid
+// Feldman commitments, so that their polynomial can be reconstructed.
+type ReconstructionBundle struct {
+	// Index of the share holder revealing its shares
+	ShareIndex uint32
+	Shares     []ReconstructionShare
+	// SessionID of the current run
+	SessionID []byte
+	// Signature over the hash of the whole bundle
+	Signature []byte
+}
+
+// ReconstructionShare holds the share and blinding share received from a
+// dealer.
+type ReconstructionShare struct {
+	DealerIndex uint32
+	Share       kyber.Scalar
+	Blinding    kyber.Scalar
+}
+
+// Hash hashes the share index and shares
+func (r *ReconstructionBundle) Hash() []byte {
+	sort.SliceStable(r.Shares, func(a, b int) bool {
+		return r.Shares[a].DealerIndex < r.Shares[b].DealerIndex
+	})
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, r.ShareIndex)
+	for _, sh := range r.Shares {
+		binary.Write(h, binary.BigEndian, sh.DealerIndex)
+		for _, s := range []kyber.Scalar{sh.Share, sh.Blinding} {
+			if s == nil {
+				continue
+			}
+			sbuff, _ := s.MarshalBinary()
+			h.Write(sbuff)
+		}
+	}
+	h.Write(r.SessionID)
+	return h.Sum(nil)
+}
+
+func (r *ReconstructionBundle) Index() Index {
+	return r.ShareIndex
+}
+
+func (r *ReconstructionBundle) Sig() []byte {
+	return r.Signature
+}
+
 // Packet is the interface that implements the three messages that this
 // implementation uses during the different phases. This interface allows to
 // verify a DKG packet without knowing its specific type.
@@ -288,6 +418,27 @@ func VerifyPacketSignature(c *Config, p Packet) error {
 			return errors.New("no nodes with this public key")
 		}
 		sig = auth.Signature
+	case *ExtractionBundle:
+		hash = auth.Hash()
+		pub, ok = findIndex(getDealers(), auth.DealerIndex)
+		if !ok {
+			return errors.New("no nodes with this public key")
+		}
+		sig = auth.Signature
+	case *PVSSDealBundle:
+		hash = auth.Hash()
+		pub, ok = findIndex(getDealers(), auth.DealerIndex)
+		if !ok {
+			return errors.New("no nodes with this public key")
+		}
+		sig = auth.Signature
+	case *ReconstructionBundle:
+		hash = auth.Hash()
+		pub, ok = findIndex(c.NewNodes, auth.ShareIndex)
+		if !ok {
+			return errors.New("no nodes with this public key")
+		}
+		sig = auth.Signature
 	default:
 		return errors.New("unknown packet type")
 	}
@@ -0,0 +1,47 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// Counter is a Handler recording metrics about the events: the number of
+// events of each name and the time spent in each phase. It is safe for
+// concurrent use, so a single Counter can be shared by several protocols.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	phases map[string]time.Duration
+}
+
+// NewCounter returns an empty counter.
+func NewCounter() *Counter {
+	return &Counter{
+		counts: make(map[string]int),
+		phases: make(map[string]time.Duration),
+	}
+}
+
+// Handle records the event.
+func (c *Counter) Handle(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[e.Name()]++
+	if t, ok := e.(PhaseTransition); ok {
+		c.phases[t.From] += t.Elapsed
+	}
+}
+
+// Count returns the number of events with the given name.
+func (c *Counter) Count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+// PhaseDuration returns the total time spent in the given phase.
+func (c *Counter) PhaseDuration(phase string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.phases[phase]
+}
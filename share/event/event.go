@@ -0,0 +1,148 @@
+// Package event defines the typed events emitted by the DKG and VSS
+// protocols, so that operators can monitor a run: which deals were received
+// or rejected, which complaints were issued, which nodes were evicted and
+// how long each phase took.
+//
+// The events are delivered synchronously to a Handler, from the goroutine
+// running the protocol, so handlers must not block.
+package event
+
+import (
+	"time"
+)
+
+// Event is an event of the protocol.
+type Event interface {
+	// Name returns the name of the event, e.g. "deal_received".
+	Name() string
+	// Fields returns the attributes of the event as alternating keys and
+	// values.
+	Fields() []interface{}
+}
+
+// Handler receives the events of a protocol.
+type Handler interface {
+	Handle(e Event)
+}
+
+// HandlerFunc is a function implementing Handler.
+type HandlerFunc func(e Event)
+
+// Handle calls f(e).
+func (f HandlerFunc) Handle(e Event) {
+	f(e)
+}
+
+// Emit sends the event to the handler if it is not nil.
+func Emit(h Handler, e Event) {
+	if h != nil {
+		h.Handle(e)
+	}
+}
+
+// DealReceived is emitted when a share holder receives a valid deal.
+type DealReceived struct {
+	Dealer      uint32
+	ShareHolder uint32
+}
+
+func (DealReceived) Name() string { return "deal_received" }
+
+func (e DealReceived) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder}
+}
+
+// DealRejected is emitted when a share holder rejects a deal.
+type DealRejected struct {
+	Dealer      uint32
+	ShareHolder uint32
+	Reason      string
+}
+
+func (DealRejected) Name() string { return "deal_rejected" }
+
+func (e DealRejected) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder, "reason", e.Reason}
+}
+
+// ComplaintIssued is emitted when a share holder complains against a dealer.
+type ComplaintIssued struct {
+	Dealer      uint32
+	ShareHolder uint32
+}
+
+func (ComplaintIssued) Name() string { return "complaint_issued" }
+
+func (e ComplaintIssued) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder}
+}
+
+// ComplaintReceived is emitted when a node receives the complaint of a share
+// holder against a dealer.
+type ComplaintReceived struct {
+	Dealer      uint32
+	ShareHolder uint32
+}
+
+func (ComplaintReceived) Name() string { return "complaint_received" }
+
+func (e ComplaintReceived) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder}
+}
+
+// JustificationAccepted is emitted when a dealer answers a complaint with a
+// valid share.
+type JustificationAccepted struct {
+	Dealer      uint32
+	ShareHolder uint32
+}
+
+func (JustificationAccepted) Name() string { return "justification_accepted" }
+
+func (e JustificationAccepted) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder}
+}
+
+// JustificationRejected is emitted when a dealer answers a complaint with an
+// invalid justification.
+type JustificationRejected struct {
+	Dealer      uint32
+	ShareHolder uint32
+	Reason      string
+}
+
+func (JustificationRejected) Name() string { return "justification_rejected" }
+
+func (e JustificationRejected) Fields() []interface{} {
+	return []interface{}{"dealer", e.Dealer, "share_holder", e.ShareHolder, "reason", e.Reason}
+}
+
+// NodeEvicted is emitted when a node is excluded from the protocol, either
+// as a dealer or as a share holder.
+type NodeEvicted struct {
+	Node uint32
+	// Dealer is true if the node is evicted as a dealer, and false if it is
+	// evicted as a share holder.
+	Dealer bool
+	Reason string
+}
+
+func (NodeEvicted) Name() string { return "node_evicted" }
+
+func (e NodeEvicted) Fields() []interface{} {
+	return []interface{}{"node", e.Node, "dealer", e.Dealer, "reason", e.Reason}
+}
+
+// PhaseTransition is emitted when the protocol moves to a new phase.
+type PhaseTransition struct {
+	From string
+	To   string
+	// Elapsed is the time spent in the previous phase.
+	Elapsed time.Duration
+}
+
+func (PhaseTransition) Name() string { return "phase_transition" }
+
+func (e PhaseTransition) Fields() []interface{} {
+	return []interface{}{"from", e.From, "to", e.To, "elapsed", e.Elapsed}
+}
@@ -0,0 +1,34 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	c.Handle(DealReceived{Dealer: 1, ShareHolder: 2})
+	c.Handle(DealReceived{Dealer: 3, ShareHolder: 2})
+	c.Handle(PhaseTransition{From: "init", To: "deal", Elapsed: time.Second})
+	c.Handle(PhaseTransition{From: "deal", To: "response", Elapsed: 2 * time.Second})
+	c.Handle(PhaseTransition{From: "init", To: "deal", Elapsed: time.Second})
+
+	require.Equal(t, 2, c.Count("deal_received"))
+	require.Equal(t, 3, c.Count("phase_transition"))
+	require.Equal(t, 0, c.Count("node_evicted"))
+	require.Equal(t, 2*time.Second, c.PhaseDuration("init"))
+	require.Equal(t, 2*time.Second, c.PhaseDuration("deal"))
+	require.Equal(t, time.Duration(0), c.PhaseDuration("response"))
+
+	Emit(nil, DealReceived{})
+	Emit(c, DealReceived{})
+	require.Equal(t, 3, c.Count("deal_received"))
+}
+
+func TestFields(t *testing.T) {
+	e := DealRejected{Dealer: 1, ShareHolder: 2, Reason: "bad"}
+	require.Equal(t, []interface{}{"dealer", uint32(1), "share_holder", uint32(2), "reason", "bad"}, e.Fields())
+	require.Equal(t, "deal_rejected", e.Name())
+}
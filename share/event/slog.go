@@ -0,0 +1,23 @@
+//go:build go1.21
+
+package event
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogHandler returns a Handler logging the events with the logger, using
+// the name of the event as message and its fields as attributes. Rejections
+// and evictions are logged at the warning level, the other events at the info
+// level.
+func NewSlogHandler(l *slog.Logger) Handler {
+	return HandlerFunc(func(e Event) {
+		level := slog.LevelInfo
+		switch e.(type) {
+		case DealRejected, JustificationRejected, NodeEvicted:
+			level = slog.LevelWarn
+		}
+		l.Log(context.Background(), level, e.Name(), e.Fields()...)
+	})
+}
@@ -0,0 +1,22 @@
+//go:build go1.21
+
+package event
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSlogHandler(slog.New(slog.NewTextHandler(&buf, nil)))
+	h.Handle(NodeEvicted{Node: 4, Dealer: true, Reason: "too many complaints"})
+	require.Contains(t, buf.String(), `level=WARN msg=node_evicted node=4 dealer=true reason="too many complaints"`)
+
+	buf.Reset()
+	h.Handle(DealReceived{Dealer: 1, ShareHolder: 2})
+	require.Contains(t, buf.String(), "level=INFO msg=deal_received dealer=1 share_holder=2")
+}
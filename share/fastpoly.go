@@ -0,0 +1,465 @@
+package share
+
+import (
+	"github.com/drand/kyber"
+)
+
+// This file implements subquadratic polynomial arithmetic over the scalar
+// field of a group, and over polynomials whose coefficients are points of the
+// group: Karatsuba multiplication, division by a monic polynomial using
+// Newton iteration, and the product and remainder trees used for multipoint
+// evaluation and interpolation. Polynomials are given by their coefficients
+// in increasing degree order. See "Modern Computer Algebra", von zur Gathen
+// and Gerhard, chapters 8 to 10.
+
+// karatsubaThreshold is the size under which polynomials are multiplied with
+// the schoolbook algorithm. Multiplying points by scalars is much more costly
+// than adding them, so Karatsuba's trade of multiplications for additions
+// pays off earlier for point polynomials.
+const karatsubaThreshold = 16
+const pointKaratsubaThreshold = 4
+
+// fastThreshold is the number of points from which multipoint evaluation
+// uses product trees rather than Horner's rule, and pointFastThreshold its
+// counterpart for point polynomials.
+const fastThreshold = 64
+const pointFastThreshold = 256
+
+// leafThreshold is the number of points under which a remainder tree
+// evaluates the remaining polynomial directly, and pointLeafThreshold its
+// counterpart for point polynomials.
+const leafThreshold = 64
+const pointLeafThreshold = 8
+
+// batchInvert returns the inverses of all the given scalars using a single
+// field inversion (Montgomery's trick). All the scalars must be non-zero.
+func batchInvert(g kyber.Group, xs []kyber.Scalar) []kyber.Scalar {
+	if len(xs) == 0 {
+		return nil
+	}
+	prefix := make([]kyber.Scalar, len(xs))
+	acc := g.Scalar().One()
+	for i, x := range xs {
+		prefix[i] = acc.Clone()
+		acc.Mul(acc, x)
+	}
+	acc.Inv(acc)
+	invs := make([]kyber.Scalar, len(xs))
+	for i := len(xs) - 1; i >= 0; i-- {
+		invs[i] = g.Scalar().Mul(acc, prefix[i])
+		acc.Mul(acc, xs[i])
+	}
+	return invs
+}
+
+func scalarPolyAdd(g kyber.Group, a, b []kyber.Scalar) []kyber.Scalar {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	r := make([]kyber.Scalar, len(a))
+	for i := range a {
+		if i < len(b) {
+			r[i] = g.Scalar().Add(a[i], b[i])
+		} else {
+			r[i] = a[i].Clone()
+		}
+	}
+	return r
+}
+
+func pointPolyAdd(g kyber.Group, a, b []kyber.Point) []kyber.Point {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	r := make([]kyber.Point, len(a))
+	for i := range a {
+		if i < len(b) {
+			r[i] = g.Point().Add(a[i], b[i])
+		} else {
+			r[i] = a[i].Clone()
+		}
+	}
+	return r
+}
+
+// scalarPolyMul returns a*b.
+func scalarPolyMul(g kyber.Group, a, b []kyber.Scalar) []kyber.Scalar {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	if len(a) < karatsubaThreshold || len(b) < karatsubaThreshold {
+		r := make([]kyber.Scalar, len(a)+len(b)-1)
+		for i := range r {
+			r[i] = g.Scalar().Zero()
+		}
+		tmp := g.Scalar()
+		for i := range a {
+			for j := range b {
+				r[i+j].Add(r[i+j], tmp.Mul(a[i], b[j]))
+			}
+		}
+		return r
+	}
+
+	m := (maxInt(len(a), len(b)) + 1) / 2
+	r := make([]kyber.Scalar, len(a)+len(b)-1)
+	for i := range r {
+		r[i] = g.Scalar().Zero()
+	}
+	// coefficients of the partial products beyond the degree of a*b are
+	// zero and skipped
+	addAt := func(p []kyber.Scalar, off int) {
+		for i := 0; i < len(p) && off+i < len(r); i++ {
+			r[off+i].Add(r[off+i], p[i])
+		}
+	}
+	if len(a) <= m || len(b) <= m {
+		// one operand is short: split the long one only
+		long, short := a, b
+		if len(long) <= m {
+			long, short = b, a
+		}
+		addAt(scalarPolyMul(g, long[:m], short), 0)
+		addAt(scalarPolyMul(g, long[m:], short), m)
+		return r
+	}
+	a0, a1 := a[:m], a[m:]
+	b0, b1 := b[:m], b[m:]
+	z0 := scalarPolyMul(g, a0, b0)
+	z2 := scalarPolyMul(g, a1, b1)
+	z1 := scalarPolyMul(g, scalarPolyAdd(g, a0, a1), scalarPolyAdd(g, b0, b1))
+	for i := range z1 {
+		if i < len(z0) {
+			z1[i].Sub(z1[i], z0[i])
+		}
+		if i < len(z2) {
+			z1[i].Sub(z1[i], z2[i])
+		}
+	}
+	addAt(z0, 0)
+	addAt(z1, m)
+	addAt(z2, 2*m)
+	return r
+}
+
+// pointPolyMul returns the polynomial a*b where the coefficients of a are
+// points.
+func pointPolyMul(g kyber.Group, a []kyber.Point, b []kyber.Scalar) []kyber.Point {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	if len(a) < pointKaratsubaThreshold || len(b) < pointKaratsubaThreshold {
+		r := make([]kyber.Point, len(a)+len(b)-1)
+		for i := range r {
+			r[i] = g.Point().Null()
+		}
+		tmp := g.Point()
+		for i := range a {
+			for j := range b {
+				r[i+j].Add(r[i+j], tmp.Mul(b[j], a[i]))
+			}
+		}
+		return r
+	}
+
+	m := (maxInt(len(a), len(b)) + 1) / 2
+	r := make([]kyber.Point, len(a)+len(b)-1)
+	for i := range r {
+		r[i] = g.Point().Null()
+	}
+	addAt := func(p []kyber.Point, off int) {
+		for i := 0; i < len(p) && off+i < len(r); i++ {
+			r[off+i].Add(r[off+i], p[i])
+		}
+	}
+	if len(a) <= m {
+		addAt(pointPolyMul(g, a, b[:m]), 0)
+		addAt(pointPolyMul(g, a, b[m:]), m)
+		return r
+	}
+	if len(b) <= m {
+		addAt(pointPolyMul(g, a[:m], b), 0)
+		addAt(pointPolyMul(g, a[m:], b), m)
+		return r
+	}
+	a0, a1 := a[:m], a[m:]
+	b0, b1 := b[:m], b[m:]
+	z0 := pointPolyMul(g, a0, b0)
+	z2 := pointPolyMul(g, a1, b1)
+	z1 := pointPolyMul(g, pointPolyAdd(g, a0, a1), scalarPolyAdd(g, b0, b1))
+	for i := range z1 {
+		if i < len(z0) {
+			z1[i].Sub(z1[i], z0[i])
+		}
+		if i < len(z2) {
+			z1[i].Sub(z1[i], z2[i])
+		}
+	}
+	addAt(z0, 0)
+	addAt(z1, m)
+	addAt(z2, 2*m)
+	return r
+}
+
+// scalarPolyInvTrunc returns h such that f*h = 1 mod x^k using Newton
+// iteration. f[0] must be invertible.
+func scalarPolyInvTrunc(g kyber.Group, f []kyber.Scalar, k int) []kyber.Scalar {
+	h := []kyber.Scalar{g.Scalar().Inv(f[0])}
+	two := g.Scalar().SetInt64(2)
+	for l := 1; l < k; {
+		l = minInt(2*l, k)
+		// h = h * (2 - f*h) mod x^l
+		fh := padScalars(g, scalarPolyMul(g, f[:minInt(len(f), l)], h), l)[:l]
+		for i := range fh {
+			fh[i].Neg(fh[i])
+		}
+		fh[0].Add(fh[0], two)
+		h = scalarPolyMul(g, h, fh)[:l]
+	}
+	return h
+}
+
+func reverseScalars(p []kyber.Scalar) []kyber.Scalar {
+	r := make([]kyber.Scalar, len(p))
+	for i, c := range p {
+		r[len(p)-1-i] = c
+	}
+	return r
+}
+
+func reversePoints(p []kyber.Point) []kyber.Point {
+	r := make([]kyber.Point, len(p))
+	for i, c := range p {
+		r[len(p)-1-i] = c
+	}
+	return r
+}
+
+// scalarPolyRem returns a mod b where b is monic.
+func scalarPolyRem(g kyber.Group, a, b []kyber.Scalar) []kyber.Scalar {
+	db := len(b) - 1
+	if len(a) <= db {
+		return a
+	}
+	k := len(a) - db
+	inv := scalarPolyInvTrunc(g, reverseScalars(b), k)
+	q := reverseScalars(scalarPolyMul(g, reverseScalars(a)[:k], inv)[:k])
+	qb := scalarPolyMul(g, q, b)
+	r := make([]kyber.Scalar, db)
+	for i := range r {
+		r[i] = g.Scalar().Sub(a[i], qb[i])
+	}
+	return r
+}
+
+// pointPolyRem returns a mod b where b is monic.
+func pointPolyRem(g kyber.Group, a []kyber.Point, b []kyber.Scalar) []kyber.Point {
+	db := len(b) - 1
+	if len(a) <= db {
+		return a
+	}
+	k := len(a) - db
+	inv := scalarPolyInvTrunc(g, reverseScalars(b), k)
+	q := reversePoints(pointPolyMul(g, reversePoints(a)[:k], inv)[:k])
+	qb := pointPolyMul(g, q, b)
+	r := make([]kyber.Point, db)
+	for i := range r {
+		r[i] = g.Point().Sub(a[i], qb[i])
+	}
+	return r
+}
+
+// productTree holds the subproducts of prod_i (x - xs[i]). Level 0 holds the
+// linear factors and each node of level l+1 is the product of two
+// consecutive nodes of level l, the last one being carried over when a level
+// has an odd number of nodes. The node j of level l thus covers the points
+// xs[j*2^l : (j+1)*2^l].
+type productTree struct {
+	xs     []kyber.Scalar
+	levels [][][]kyber.Scalar
+}
+
+func newProductTree(g kyber.Group, xs []kyber.Scalar) *productTree {
+	leaves := make([][]kyber.Scalar, len(xs))
+	for i, x := range xs {
+		leaves[i] = []kyber.Scalar{g.Scalar().Neg(x), g.Scalar().One()}
+	}
+	levels := [][][]kyber.Scalar{leaves}
+	for cur := leaves; len(cur) > 1; {
+		next := make([][]kyber.Scalar, (len(cur)+1)/2)
+		for j := range next {
+			if 2*j+1 < len(cur) {
+				next[j] = scalarPolyMul(g, cur[2*j], cur[2*j+1])
+			} else {
+				next[j] = cur[2*j]
+			}
+		}
+		levels = append(levels, next)
+		cur = next
+	}
+	return &productTree{xs: xs, levels: levels}
+}
+
+// root returns prod_i (x - xs[i]).
+func (t *productTree) root() []kyber.Scalar {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// span returns the range of points covered by the node j of level l.
+func (t *productTree) span(l, j int) (int, int) {
+	return j << l, minInt((j+1)<<l, len(t.xs))
+}
+
+// evalScalars evaluates p at all the points of the tree.
+func (t *productTree) evalScalars(g kyber.Group, p []kyber.Scalar) []kyber.Scalar {
+	out := make([]kyber.Scalar, len(t.xs))
+	top := len(t.levels) - 1
+	t.evalScalarsAt(g, scalarPolyRem(g, p, t.levels[top][0]), top, 0, out)
+	return out
+}
+
+func (t *productTree) evalScalarsAt(g kyber.Group, p []kyber.Scalar, l, j int, out []kyber.Scalar) {
+	from, to := t.span(l, j)
+	if to-from <= leafThreshold || l == 0 {
+		for i := from; i < to; i++ {
+			out[i] = hornerScalar(g, p, t.xs[i])
+		}
+		return
+	}
+	left := t.levels[l-1][2*j]
+	t.evalScalarsAt(g, scalarPolyRem(g, p, left), l-1, 2*j, out)
+	if 2*j+1 < len(t.levels[l-1]) {
+		right := t.levels[l-1][2*j+1]
+		t.evalScalarsAt(g, scalarPolyRem(g, p, right), l-1, 2*j+1, out)
+	}
+}
+
+// evalPoints evaluates the polynomial p with point coefficients at all the
+// points of the tree.
+func (t *productTree) evalPoints(g kyber.Group, p []kyber.Point) []kyber.Point {
+	out := make([]kyber.Point, len(t.xs))
+	top := len(t.levels) - 1
+	t.evalPointsAt(g, pointPolyRem(g, p, t.levels[top][0]), top, 0, out)
+	return out
+}
+
+func (t *productTree) evalPointsAt(g kyber.Group, p []kyber.Point, l, j int, out []kyber.Point) {
+	from, to := t.span(l, j)
+	if to-from <= pointLeafThreshold || l == 0 {
+		for i := from; i < to; i++ {
+			out[i] = hornerPoint(g, p, t.xs[i])
+		}
+		return
+	}
+	left := t.levels[l-1][2*j]
+	t.evalPointsAt(g, pointPolyRem(g, p, left), l-1, 2*j, out)
+	if 2*j+1 < len(t.levels[l-1]) {
+		right := t.levels[l-1][2*j+1]
+		t.evalPointsAt(g, pointPolyRem(g, p, right), l-1, 2*j+1, out)
+	}
+}
+
+// weights returns the barycentric weights 1/prod_{j!=i}(xs[i]-xs[j]), i.e.
+// the inverses of the derivative of the root evaluated at each point.
+func (t *productTree) weights(g kyber.Group) []kyber.Scalar {
+	root := t.root()
+	deriv := make([]kyber.Scalar, len(root)-1)
+	for i := range deriv {
+		deriv[i] = g.Scalar().Mul(root[i+1], g.Scalar().SetInt64(int64(i+1)))
+	}
+	return batchInvert(g, t.evalScalars(g, deriv))
+}
+
+// interpolateScalars returns the polynomial of degree less than len(xs)
+// taking the values ys at the points of the tree, given the barycentric
+// weights ws.
+func (t *productTree) interpolateScalars(g kyber.Group, ys, ws []kyber.Scalar) []kyber.Scalar {
+	cur := make([][]kyber.Scalar, len(ys))
+	for i := range ys {
+		cur[i] = []kyber.Scalar{g.Scalar().Mul(ys[i], ws[i])}
+	}
+	for l := 0; len(cur) > 1; l++ {
+		next := make([][]kyber.Scalar, (len(cur)+1)/2)
+		for j := range next {
+			if 2*j+1 < len(cur) {
+				next[j] = scalarPolyAdd(g,
+					scalarPolyMul(g, cur[2*j], t.levels[l][2*j+1]),
+					scalarPolyMul(g, cur[2*j+1], t.levels[l][2*j]))
+			} else {
+				next[j] = cur[2*j]
+			}
+		}
+		cur = next
+	}
+	return cur[0]
+}
+
+// interpolatePoints is the point version of interpolateScalars.
+func (t *productTree) interpolatePoints(g kyber.Group, ys []kyber.Point, ws []kyber.Scalar) []kyber.Point {
+	cur := make([][]kyber.Point, len(ys))
+	for i := range ys {
+		cur[i] = []kyber.Point{g.Point().Mul(ws[i], ys[i])}
+	}
+	for l := 0; len(cur) > 1; l++ {
+		next := make([][]kyber.Point, (len(cur)+1)/2)
+		for j := range next {
+			if 2*j+1 < len(cur) {
+				next[j] = pointPolyAdd(g,
+					pointPolyMul(g, cur[2*j], t.levels[l][2*j+1]),
+					pointPolyMul(g, cur[2*j+1], t.levels[l][2*j]))
+			} else {
+				next[j] = cur[2*j]
+			}
+		}
+		cur = next
+	}
+	return cur[0]
+}
+
+func hornerScalar(g kyber.Group, p []kyber.Scalar, x kyber.Scalar) kyber.Scalar {
+	v := g.Scalar().Zero()
+	for j := len(p) - 1; j >= 0; j-- {
+		v.Mul(v, x)
+		v.Add(v, p[j])
+	}
+	return v
+}
+
+func hornerPoint(g kyber.Group, p []kyber.Point, x kyber.Scalar) kyber.Point {
+	v := g.Point().Null()
+	for j := len(p) - 1; j >= 0; j-- {
+		v.Mul(x, v)
+		v.Add(v, p[j])
+	}
+	return v
+}
+
+// padScalars returns p extended with zero coefficients up to length n.
+func padScalars(g kyber.Group, p []kyber.Scalar, n int) []kyber.Scalar {
+	for len(p) < n {
+		p = append(p, g.Scalar().Zero())
+	}
+	return p
+}
+
+// padPoints returns p extended with null coefficients up to length n.
+func padPoints(g kyber.Group, p []kyber.Point, n int) []kyber.Point {
+	for len(p) < n {
+		p = append(p, g.Point().Null())
+	}
+	return p
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,275 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/drand/kyber"
+)
+
+// Interpolator holds the Lagrange coefficients for a fixed set of share
+// indices, so that secrets, commitments and polynomials shared over the
+// same set of participants can be recovered repeatedly without recomputing
+// them. The coefficients are computed with product trees and batch
+// inversion, in subquadratic time in the number of indices.
+type Interpolator struct {
+	g       kyber.Group
+	indices []int          // Sorted share indices
+	pos     map[int]int    // Position of each share index in indices
+	tree    *productTree   // Product tree over the x-coordinates of the shares
+	weights []kyber.Scalar // Barycentric weights of the x-coordinates
+	lambdas []kyber.Scalar // Lagrange coefficients to evaluate at x = 0
+}
+
+// NewInterpolator returns an Interpolator for the shares with the given
+// indices. The polynomials recovered by the Interpolator have a threshold
+// equal to the number of indices.
+func NewInterpolator(g kyber.Group, indices []int) (*Interpolator, error) {
+	if len(indices) == 0 {
+		return nil, errors.New("share: no index to interpolate from")
+	}
+	sorted := make([]int, len(indices))
+	copy(sorted, indices)
+	sort.Ints(sorted)
+	pos := make(map[int]int, len(sorted))
+	for i, idx := range sorted {
+		if idx < 0 {
+			return nil, fmt.Errorf("share: invalid index %d", idx)
+		}
+		if _, ok := pos[idx]; ok {
+			return nil, fmt.Errorf("share: duplicate index %d", idx)
+		}
+		pos[idx] = i
+	}
+
+	xs := make([]kyber.Scalar, len(sorted))
+	for i, idx := range sorted {
+		xs[i] = g.Scalar().SetInt64(int64(idx + 1))
+	}
+	tree, weights, lambdas := interpolationData(g, xs)
+
+	return &Interpolator{
+		g:       g,
+		indices: sorted,
+		pos:     pos,
+		tree:    tree,
+		weights: weights,
+		lambdas: lambdas,
+	}, nil
+}
+
+// Indices returns the sorted share indices of the Interpolator.
+func (ip *Interpolator) Indices() []int {
+	return ip.indices
+}
+
+// Coefficients returns the Lagrange coefficients to recover p(0), in the
+// order of Indices: p(0) is the sum of the coefficients multiplied by the
+// corresponding shares.
+func (ip *Interpolator) Coefficients() []kyber.Scalar {
+	return ip.lambdas
+}
+
+// RecoverSecret reconstructs the shared secret p(0) from the private shares.
+// A share must be given for each index of the Interpolator, shares with other
+// indices are ignored.
+func (ip *Interpolator) RecoverSecret(shares []*PriShare) (kyber.Scalar, error) {
+	ys, err := ip.priValues(shares)
+	if err != nil {
+		return nil, err
+	}
+	acc := ip.g.Scalar().Zero()
+	tmp := ip.g.Scalar()
+	for i, y := range ys {
+		acc.Add(acc, tmp.Mul(ip.lambdas[i], y))
+	}
+	return acc, nil
+}
+
+// RecoverCommit reconstructs the secret commitment p(0) from the public
+// shares. A share must be given for each index of the Interpolator, shares
+// with other indices are ignored.
+func (ip *Interpolator) RecoverCommit(shares []*PubShare) (kyber.Point, error) {
+	ys, err := ip.pubValues(shares)
+	if err != nil {
+		return nil, err
+	}
+	acc := ip.g.Point().Null()
+	tmp := ip.g.Point()
+	for i, y := range ys {
+		acc.Add(acc, tmp.Mul(ip.lambdas[i], y))
+	}
+	return acc, nil
+}
+
+// RecoverPriPoly reconstructs the full secret sharing polynomial from the
+// private shares. A share must be given for each index of the Interpolator,
+// shares with other indices are ignored.
+func (ip *Interpolator) RecoverPriPoly(shares []*PriShare) (*PriPoly, error) {
+	ys, err := ip.priValues(shares)
+	if err != nil {
+		return nil, err
+	}
+	coeffs := ip.tree.interpolateScalars(ip.g, ys, ip.weights)
+	return &PriPoly{g: ip.g, coeffs: padScalars(ip.g, coeffs, len(ys))}, nil
+}
+
+// RecoverPubPoly reconstructs the full public polynomial, for the standard
+// base point, from the public shares. A share must be given for each index of
+// the Interpolator, shares with other indices are ignored.
+func (ip *Interpolator) RecoverPubPoly(shares []*PubShare) (*PubPoly, error) {
+	ys, err := ip.pubValues(shares)
+	if err != nil {
+		return nil, err
+	}
+	commits := ip.tree.interpolatePoints(ip.g, ys, ip.weights)
+	return &PubPoly{g: ip.g, commits: padPoints(ip.g, commits, len(ys))}, nil
+}
+
+func (ip *Interpolator) priValues(shares []*PriShare) ([]kyber.Scalar, error) {
+	ys := make([]kyber.Scalar, len(ip.indices))
+	for _, s := range shares {
+		if s == nil || s.V == nil {
+			continue
+		}
+		if i, ok := ip.pos[s.I]; ok {
+			ys[i] = s.V
+		}
+	}
+	for i, y := range ys {
+		if y == nil {
+			return nil, fmt.Errorf("share: missing share %d", ip.indices[i])
+		}
+	}
+	return ys, nil
+}
+
+func (ip *Interpolator) pubValues(shares []*PubShare) ([]kyber.Point, error) {
+	ys := make([]kyber.Point, len(ip.indices))
+	for _, s := range shares {
+		if s == nil || s.V == nil {
+			continue
+		}
+		if i, ok := ip.pos[s.I]; ok {
+			ys[i] = s.V
+		}
+	}
+	for i, y := range ys {
+		if y == nil {
+			return nil, fmt.Errorf("share: missing share %d", ip.indices[i])
+		}
+	}
+	return ys, nil
+}
+
+// RecoverSecretXY reconstructs p(0) from the evaluations ys[i] = p(xs[i]) at
+// arbitrary distinct points, of a polynomial p with threshold len(xs).
+func RecoverSecretXY(g kyber.Group, xs, ys []kyber.Scalar) (kyber.Scalar, error) {
+	if err := checkXs(xs, len(ys)); err != nil {
+		return nil, err
+	}
+	_, _, lambdas := interpolationData(g, xs)
+	acc := g.Scalar().Zero()
+	tmp := g.Scalar()
+	for i, y := range ys {
+		acc.Add(acc, tmp.Mul(lambdas[i], y))
+	}
+	return acc, nil
+}
+
+// RecoverCommitXY reconstructs the commitment p(0) from the commitments
+// ys[i] = p(xs[i]) at arbitrary distinct points, of a polynomial p with
+// threshold len(xs).
+func RecoverCommitXY(g kyber.Group, xs []kyber.Scalar, ys []kyber.Point) (kyber.Point, error) {
+	if err := checkXs(xs, len(ys)); err != nil {
+		return nil, err
+	}
+	_, _, lambdas := interpolationData(g, xs)
+	acc := g.Point().Null()
+	tmp := g.Point()
+	for i, y := range ys {
+		acc.Add(acc, tmp.Mul(lambdas[i], y))
+	}
+	return acc, nil
+}
+
+// RecoverPriPolyXY reconstructs the polynomial p with threshold len(xs) from
+// its evaluations ys[i] = p(xs[i]) at arbitrary distinct points.
+func RecoverPriPolyXY(g kyber.Group, xs, ys []kyber.Scalar) (*PriPoly, error) {
+	if err := checkXs(xs, len(ys)); err != nil {
+		return nil, err
+	}
+	tree, weights, _ := interpolationData(g, xs)
+	coeffs := tree.interpolateScalars(g, ys, weights)
+	return &PriPoly{g: g, coeffs: padScalars(g, coeffs, len(ys))}, nil
+}
+
+// RecoverPubPolyXY reconstructs the public polynomial p with threshold
+// len(xs), for the standard base point, from its commitments ys[i] = p(xs[i])
+// at arbitrary distinct points.
+func RecoverPubPolyXY(g kyber.Group, xs []kyber.Scalar, ys []kyber.Point) (*PubPoly, error) {
+	if err := checkXs(xs, len(ys)); err != nil {
+		return nil, err
+	}
+	tree, weights, _ := interpolationData(g, xs)
+	commits := tree.interpolatePoints(g, ys, weights)
+	return &PubPoly{g: g, commits: padPoints(g, commits, len(ys))}, nil
+}
+
+// checkXs checks that the interpolation points xs are distinct, and that
+// there are as many of them as values.
+func checkXs(xs []kyber.Scalar, values int) error {
+	if len(xs) == 0 {
+		return errors.New("share: no point to interpolate from")
+	}
+	if len(xs) != values {
+		return errors.New("share: different number of points and values")
+	}
+	seen := make(map[string]bool, len(xs))
+	for _, x := range xs {
+		if x == nil {
+			return errors.New("share: nil interpolation point")
+		}
+		b, err := x.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if seen[string(b)] {
+			return errors.New("share: duplicate interpolation point")
+		}
+		seen[string(b)] = true
+	}
+	return nil
+}
+
+// interpolationData returns the product tree over the distinct points xs,
+// their barycentric weights, and the Lagrange coefficients to evaluate at 0
+// the polynomial interpolating values at xs.
+func interpolationData(g kyber.Group, xs []kyber.Scalar) (*productTree, []kyber.Scalar, []kyber.Scalar) {
+	tree := newProductTree(g, xs)
+	weights := tree.weights(g)
+
+	lambdas := make([]kyber.Scalar, len(xs))
+	zero := g.Scalar().Zero()
+	for i, x := range xs {
+		if x.Equal(zero) {
+			// p(0) is given directly
+			for j := range lambdas {
+				lambdas[j] = g.Scalar().Zero()
+			}
+			lambdas[i].One()
+			return tree, weights, lambdas
+		}
+	}
+
+	// lambda_i = prod_{j!=i} x_j / (x_j - x_i) = -w_i * M(0) / x_i where M is
+	// the product of all (x - x_j)
+	m0 := g.Scalar().Neg(tree.root()[0])
+	invs := batchInvert(g, xs)
+	for i := range lambdas {
+		lambdas[i] = g.Scalar().Mul(weights[i], invs[i])
+		lambdas[i].Mul(lambdas[i], m0)
+	}
+	return tree, weights, lambdas
+}
@@ -0,0 +1,130 @@
+package share
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastPolyMul(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	for _, sizes := range [][2]int{{1, 1}, {3, 40}, {40, 3}, {17, 20}, {100, 63}} {
+		p := NewPriPoly(g, sizes[0], nil, g.RandomStream())
+		q := NewPriPoly(g, sizes[1], nil, g.RandomStream())
+		expected := p.Mul(q)
+		require.True(test, expected.Equal(&PriPoly{g, scalarPolyMul(g, p.coeffs, q.coeffs)}))
+
+		commits := pointPolyMul(g, p.Commit(nil).commits, q.coeffs)
+		require.True(test, expected.Commit(nil).Equal(&PubPoly{g: g, commits: commits}))
+	}
+}
+
+func TestFastMultipointEval(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	for _, sizes := range [][2]int{{10, 150}, {150, 150}, {300, 140}} {
+		t, n := sizes[0], sizes[1]
+		priPoly := NewPriPoly(g, t, nil, g.RandomStream())
+		priShares := priPoly.Shares(n)
+		for i := 0; i < n; i++ {
+			require.True(test, priShares[i].V.Equal(priPoly.Eval(i).V))
+		}
+	}
+	// the point version is only used for large committees, exercise it with
+	// fewer points directly
+	for _, sizes := range [][2]int{{5, 40}, {30, 40}, {50, 40}} {
+		t, n := sizes[0], sizes[1]
+		pubPoly := NewPriPoly(g, t, nil, g.RandomStream()).Commit(nil)
+		vs := newProductTree(g, shareXs(g, n)).evalPoints(g, pubPoly.commits)
+		for i := 0; i < n; i++ {
+			require.True(test, vs[i].Equal(pubPoly.Eval(i).V))
+		}
+	}
+}
+
+func TestInterpolator(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	for _, t := range []int{1, 5, 40} {
+		n := t + 21
+		priPoly := NewPriPoly(g, t, nil, g.RandomStream())
+		pubPoly := priPoly.Commit(nil)
+		priShares := priPoly.Shares(n)
+		pubShares := pubPoly.Shares(n)
+
+		indices := make([]int, t)
+		for i := range indices {
+			indices[i] = 7 * i % n
+		}
+		ip, err := NewInterpolator(g, indices)
+		require.NoError(test, err)
+
+		secret, err := ip.RecoverSecret(priShares)
+		require.NoError(test, err)
+		require.True(test, secret.Equal(priPoly.Secret()))
+
+		commit, err := ip.RecoverCommit(pubShares)
+		require.NoError(test, err)
+		require.True(test, commit.Equal(pubPoly.Commit()))
+
+		recPri, err := ip.RecoverPriPoly(priShares)
+		require.NoError(test, err)
+		require.True(test, recPri.Equal(priPoly))
+
+		recPub, err := ip.RecoverPubPoly(pubShares)
+		require.NoError(test, err)
+		require.True(test, recPub.Equal(pubPoly))
+
+		priShares[indices[0]] = nil
+		_, err = ip.RecoverSecret(priShares)
+		require.Error(test, err)
+	}
+
+	_, err := NewInterpolator(g, []int{1, 2, 1})
+	require.Error(test, err)
+	_, err = NewInterpolator(g, nil)
+	require.Error(test, err)
+}
+
+func BenchmarkRecoverPriPoly(b *testing.B) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	for _, t := range []int{64, 256, 1024} {
+		priPoly := NewPriPoly(g, t, nil, g.RandomStream())
+		shares := priPoly.Shares(t)
+		b.Run(fmt.Sprintf("t=%d", t), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = RecoverPriPoly(g, shares, t, t)
+			}
+		})
+	}
+}
+
+func BenchmarkPubPolyShares(b *testing.B) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	for _, n := range []int{256, 512} {
+		pubPoly := NewPriPoly(g, n/2+1, nil, g.RandomStream()).Commit(nil)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = pubPoly.Shares(n)
+			}
+		})
+	}
+}
+
+func BenchmarkInterpolatorRecoverCommit(b *testing.B) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	t := 512
+	pubShares := NewPriPoly(g, t, nil, g.RandomStream()).Commit(nil).Shares(t)
+	indices := make([]int, t)
+	for i := range indices {
+		indices[i] = i
+	}
+	ip, _ := NewInterpolator(g, indices)
+	b.ResetTimer()
+	var commit kyber.Point
+	for i := 0; i < b.N; i++ {
+		commit, _ = ip.RecoverCommit(pubShares)
+	}
+	_ = commit
+}
@@ -0,0 +1,97 @@
+package share
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	"github.com/drand/kyber"
+)
+
+// NewPackedPriPoly creates a packed (Franklin-Yung) secret sharing polynomial
+// embedding the k = len(secrets) secrets in a single polynomial: the j-th
+// secret is the evaluation of the polynomial at x = -j, so the first secret
+// is p(0) as with a regular PriPoly, while shares are still evaluated at
+// x = i+1. Any t-1 shares reveal nothing about the secrets, and t+k-1 shares
+// are needed to recover them, which is the Threshold of the returned
+// polynomial. Nil secrets are chosen using the provided randomness stream
+// rand.
+func NewPackedPriPoly(group kyber.Group, t int, secrets []kyber.Scalar, rand cipher.Stream) *PriPoly {
+	if len(secrets) == 0 {
+		secrets = []kyber.Scalar{nil}
+	}
+	k := len(secrets)
+	xs := make([]kyber.Scalar, t+k-1)
+	ys := make([]kyber.Scalar, t+k-1)
+	for j := range xs {
+		xs[j] = packedPoint(group, j)
+		if j < k && secrets[j] != nil {
+			ys[j] = secrets[j]
+		} else {
+			ys[j] = group.Scalar().Pick(rand)
+		}
+	}
+	// the points are distinct so the interpolation cannot fail
+	poly, _ := RecoverPriPolyXY(group, xs, ys)
+	return poly
+}
+
+// PackedSecrets returns the k secrets embedded in the packed secret sharing
+// polynomial p.
+func PackedSecrets(p *PriPoly, k int) []kyber.Scalar {
+	secrets := make([]kyber.Scalar, k)
+	for j := range secrets {
+		secrets[j] = p.EvalAt(packedPoint(p.g, j))
+	}
+	return secrets
+}
+
+// PackedCommits returns the commitments to the k secrets embedded in the
+// packed secret sharing polynomial committed to by p.
+func PackedCommits(p *PubPoly, k int) []kyber.Point {
+	commits := make([]kyber.Point, k)
+	for j := range commits {
+		commits[j] = p.EvalAt(packedPoint(p.g, j))
+	}
+	return commits
+}
+
+// CheckPackedSecret checks that s is the j-th secret embedded in the packed
+// secret sharing polynomial committed to by p.
+func CheckPackedSecret(p *PubPoly, j int, s kyber.Scalar) bool {
+	pv := p.EvalAt(packedPoint(p.g, j))
+	ps := p.g.Point().Mul(s, p.b)
+	return pv.Equal(ps)
+}
+
+// RecoverPackedSecrets reconstructs the k secrets embedded by
+// NewPackedPriPoly with threshold t from a list of private shares. At least
+// t+k-1 shares are needed.
+func RecoverPackedSecrets(g kyber.Group, shares []*PriShare, t, k, n int) ([]kyber.Scalar, error) {
+	if k < 1 {
+		return nil, errors.New("share: packed sharing needs at least one secret")
+	}
+	poly, err := RecoverPriPoly(g, shares, t+k-1, n)
+	if err != nil {
+		return nil, err
+	}
+	return PackedSecrets(poly, k), nil
+}
+
+// RecoverPackedCommits reconstructs the commitments to the k secrets embedded
+// by NewPackedPriPoly with threshold t from a list of public shares. At least
+// t+k-1 shares are needed.
+func RecoverPackedCommits(g kyber.Group, shares []*PubShare, t, k, n int) ([]kyber.Point, error) {
+	if k < 1 {
+		return nil, errors.New("share: packed sharing needs at least one secret")
+	}
+	poly, err := RecoverPubPoly(g, shares, t+k-1, n)
+	if err != nil {
+		return nil, err
+	}
+	return PackedCommits(poly, k), nil
+}
+
+// packedPoint returns the evaluation point -j of the j-th packed secret.
+func packedPoint(g kyber.Group, j int) kyber.Scalar {
+	return g.Scalar().Neg(g.Scalar().SetInt64(int64(j)))
+}
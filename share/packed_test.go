@@ -0,0 +1,66 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackedSecretRecovery(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	n := 10
+	t := 4
+	k := 3
+	secrets := make([]kyber.Scalar, k)
+	for i := range secrets {
+		secrets[i] = g.Scalar().Pick(g.RandomStream())
+	}
+	poly := NewPackedPriPoly(g, t, secrets, g.RandomStream())
+	require.Equal(test, t+k-1, poly.Threshold())
+	require.True(test, poly.Secret().Equal(secrets[0]))
+	for j, s := range PackedSecrets(poly, k) {
+		require.True(test, s.Equal(secrets[j]))
+	}
+
+	shares := poly.Shares(n)
+	shares[1] = nil
+	shares[3] = nil
+	shares[4] = nil
+	recovered, err := RecoverPackedSecrets(g, shares, t, k, n)
+	require.NoError(test, err)
+	for j, s := range recovered {
+		require.True(test, s.Equal(secrets[j]))
+	}
+
+	shares[5] = nil
+	shares[6] = nil
+	_, err = RecoverPackedSecrets(g, shares, t, k, n)
+	require.Error(test, err)
+}
+
+func TestPackedPublicRecovery(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	n := 8
+	t := 3
+	secrets := []kyber.Scalar{g.Scalar().Pick(g.RandomStream()), nil, g.Scalar().Pick(g.RandomStream())}
+	k := len(secrets)
+	poly := NewPackedPriPoly(g, t, secrets, g.RandomStream())
+	pub := poly.Commit(nil)
+
+	for _, s := range poly.Shares(n) {
+		require.True(test, pub.Check(s))
+	}
+	packed := PackedSecrets(poly, k)
+	require.True(test, CheckPackedSecret(pub, 0, secrets[0]))
+	require.True(test, CheckPackedSecret(pub, 1, packed[1]))
+	require.True(test, CheckPackedSecret(pub, 2, secrets[2]))
+	require.False(test, CheckPackedSecret(pub, 1, secrets[2]))
+
+	commits, err := RecoverPackedCommits(g, pub.Shares(n), t, k, n)
+	require.NoError(test, err)
+	for j, c := range commits {
+		require.True(test, c.Equal(g.Point().Mul(packed[j], nil)))
+	}
+}
@@ -83,19 +83,33 @@ func (p *PriPoly) Secret() kyber.Scalar {
 // Eval computes the private share v = p(i).
 func (p *PriPoly) Eval(i int) *PriShare {
 	xi := p.g.Scalar().SetInt64(1 + int64(i))
+	return &PriShare{i, p.EvalAt(xi)}
+}
+
+// EvalAt computes p(x) for an arbitrary x. Note that the private share of
+// index i is p(i+1), not p(i).
+func (p *PriPoly) EvalAt(x kyber.Scalar) kyber.Scalar {
 	v := p.g.Scalar().Zero()
 	for j := p.Threshold() - 1; j >= 0; j-- {
-		v.Mul(v, xi)
+		v.Mul(v, x)
 		v.Add(v, p.coeffs[j])
 	}
-	return &PriShare{i, v}
+	return v
 }
 
-// Shares creates a list of n private shares p(1),...,p(n).
+// Shares creates a list of n private shares p(1),...,p(n). For large n, the
+// shares are computed with a subquadratic multipoint evaluation.
 func (p *PriPoly) Shares(n int) []*PriShare {
 	shares := make([]*PriShare, n)
+	if n <= fastThreshold {
+		for i := range shares {
+			shares[i] = p.Eval(i)
+		}
+		return shares
+	}
+	vs := newProductTree(p.g, shareXs(p.g, n)).evalScalars(p.g, p.coeffs)
 	for i := range shares {
-		shares[i] = p.Eval(i)
+		shares[i] = &PriShare{i, vs[i]}
 	}
 	return shares
 }
@@ -178,31 +192,15 @@ func (p *PriPoly) Coefficients() []kyber.Scalar {
 // RecoverSecret reconstructs the shared secret p(0) from a list of private
 // shares using Lagrange interpolation.
 func RecoverSecret(g kyber.Group, shares []*PriShare, t, n int) (kyber.Scalar, error) {
-	x, y := xyScalar(g, shares, t, n)
+	x, _ := xyScalar(g, shares, t, n)
 	if len(x) < t {
 		return nil, errors.New("share: not enough shares to recover secret")
 	}
-
-	acc := g.Scalar().Zero()
-	num := g.Scalar()
-	den := g.Scalar()
-	tmp := g.Scalar()
-
-	for i, xi := range x {
-		yi := y[i]
-		num.Set(yi)
-		den.One()
-		for j, xj := range x {
-			if i == j {
-				continue
-			}
-			num.Mul(num, xj)
-			den.Mul(den, tmp.Sub(xj, xi))
-		}
-		acc.Add(acc, num.Div(num, den))
+	ip, err := NewInterpolator(g, indicesOf(x))
+	if err != nil {
+		return nil, err
 	}
-
-	return acc, nil
+	return ip.RecoverSecret(shares)
 }
 
 type byIndexScalar []*PriShare
@@ -242,48 +240,31 @@ func xyScalar(g kyber.Group, shares []*PriShare, t, n int) (map[int]kyber.Scalar
 	return x, y
 }
 
-func minusConst(g kyber.Group, c kyber.Scalar) *PriPoly {
-	neg := g.Scalar().Neg(c)
-	return &PriPoly{
-		g:      g,
-		coeffs: []kyber.Scalar{neg, g.Scalar().One()},
-	}
-}
-
 // RecoverPriPoly takes a list of shares and the parameters t and n to
 // reconstruct the secret polynomial completely, i.e., all private
 // coefficients.  It is up to the caller to make sure that there are enough
 // shares to correctly re-construct the polynomial. There must be at least t
 // shares.
 func RecoverPriPoly(g kyber.Group, shares []*PriShare, t, n int) (*PriPoly, error) {
-	x, y := xyScalar(g, shares, t, n)
+	x, _ := xyScalar(g, shares, t, n)
 	if len(x) != t {
 		return nil, errors.New("share: not enough shares to recover private polynomial")
 	}
+	ip, err := NewInterpolator(g, indicesOf(x))
+	if err != nil {
+		return nil, err
+	}
+	return ip.RecoverPriPoly(shares)
+}
 
-	var accPoly *PriPoly
-	var err error
-	//den := g.Scalar()
-	// Notations follow the Wikipedia article on Lagrange interpolation
-	// https://en.wikipedia.org/wiki/Lagrange_polynomial
-	for j := range x {
-		basis := lagrangeBasis(g, j, x)
-		for i := range basis.coeffs {
-			basis.coeffs[i] = basis.coeffs[i].Mul(basis.coeffs[i], y[j])
-		}
-
-		if accPoly == nil {
-			accPoly = basis
-			continue
-		}
-
-		// add all L_j * y_j together
-		accPoly, err = accPoly.Add(basis)
-		if err != nil {
-			return nil, err
-		}
+// indicesOf returns the share indices of the interpolation points xs,
+// computed with xyScalar() or xyCommit().
+func indicesOf(xs map[int]kyber.Scalar) []int {
+	indices := make([]int, 0, len(xs))
+	for i := range xs {
+		indices = append(indices, i)
 	}
-	return accPoly, nil
+	return indices
 }
 
 func (p *PriPoly) String() string {
@@ -338,23 +319,47 @@ func (p *PubPoly) Commit() kyber.Point {
 // Eval computes the public share v = p(i).
 func (p *PubPoly) Eval(i int) *PubShare {
 	xi := p.g.Scalar().SetInt64(1 + int64(i)) // x-coordinate of this share
+	return &PubShare{i, p.EvalAt(xi)}
+}
+
+// EvalAt computes the commitment p(x) for an arbitrary x. Note that the
+// public share of index i is p(i+1), not p(i).
+func (p *PubPoly) EvalAt(x kyber.Scalar) kyber.Point {
 	v := p.g.Point().Null()
 	for j := p.Threshold() - 1; j >= 0; j-- {
-		v.Mul(xi, v)
+		v.Mul(x, v)
 		v.Add(v, p.commits[j])
 	}
-	return &PubShare{i, v}
+	return v
 }
 
-// Shares creates a list of n public commitment shares p(1),...,p(n).
+// Shares creates a list of n public commitment shares p(1),...,p(n). For
+// large n, the shares are computed with a subquadratic multipoint
+// evaluation.
 func (p *PubPoly) Shares(n int) []*PubShare {
 	shares := make([]*PubShare, n)
+	if n <= pointFastThreshold {
+		for i := range shares {
+			shares[i] = p.Eval(i)
+		}
+		return shares
+	}
+	vs := newProductTree(p.g, shareXs(p.g, n)).evalPoints(p.g, p.commits)
 	for i := range shares {
-		shares[i] = p.Eval(i)
+		shares[i] = &PubShare{i, vs[i]}
 	}
 	return shares
 }
 
+// shareXs returns the x-coordinates 1,...,n of the first n shares.
+func shareXs(g kyber.Group, n int) []kyber.Scalar {
+	xs := make([]kyber.Scalar, n)
+	for i := range xs {
+		xs[i] = g.Scalar().SetInt64(int64(i + 1))
+	}
+	return xs
+}
+
 // Add computes the component-wise sum of the polynomials p and q and returns it
 // as a new polynomial. NOTE: If the base points p.b and q.b are different then the
 // base point of the resulting PubPoly cannot be computed without knowing the
@@ -446,90 +451,27 @@ func xyCommit(g kyber.Group, shares []*PubShare, t, n int) (map[int]kyber.Scalar
 // RecoverCommit reconstructs the secret commitment p(0) from a list of public
 // shares using Lagrange interpolation.
 func RecoverCommit(g kyber.Group, shares []*PubShare, t, n int) (kyber.Point, error) {
-	x, y := xyCommit(g, shares, t, n)
+	x, _ := xyCommit(g, shares, t, n)
 	if len(x) < t {
 		return nil, errors.New("share: not enough good public shares to reconstruct secret commitment")
 	}
-
-	num := g.Scalar()
-	den := g.Scalar()
-	tmp := g.Scalar()
-	Acc := g.Point().Null()
-	Tmp := g.Point()
-
-	for i, xi := range x {
-		num.One()
-		den.One()
-		for j, xj := range x {
-			if i == j {
-				continue
-			}
-			num.Mul(num, xj)
-			den.Mul(den, tmp.Sub(xj, xi))
-		}
-		Tmp.Mul(num.Div(num, den), y[i])
-		Acc.Add(Acc, Tmp)
+	ip, err := NewInterpolator(g, indicesOf(x))
+	if err != nil {
+		return nil, err
 	}
-
-	return Acc, nil
+	return ip.RecoverCommit(shares)
 }
 
 // RecoverPubPoly reconstructs the full public polynomial from a set of public
 // shares using Lagrange interpolation.
 func RecoverPubPoly(g kyber.Group, shares []*PubShare, t, n int) (*PubPoly, error) {
-	x, y := xyCommit(g, shares, t, n)
+	x, _ := xyCommit(g, shares, t, n)
 	if len(x) < t {
 		return nil, errors.New("share: not enough good public shares to reconstruct secret commitment")
 	}
-
-	var accPoly *PubPoly
-	var err error
-
-	for j := range x {
-		basis := lagrangeBasis(g, j, x)
-
-		// compute the L_j * y_j polynomial in point space
-		tmp := basis.Commit(y[j])
-		if accPoly == nil {
-			accPoly = tmp
-			continue
-		}
-
-		// add all L_j * y_j together
-		accPoly, err = accPoly.Add(tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return accPoly, nil
-
-}
-
-// lagrangeBasis returns a PriPoly containing the Lagrange coefficients for the
-// i-th position. xs is a mapping between the indices and the values that the
-// interpolation is using, computed with xyScalar().
-func lagrangeBasis(g kyber.Group, i int, xs map[int]kyber.Scalar) *PriPoly {
-	var basis = &PriPoly{
-		g:      g,
-		coeffs: []kyber.Scalar{g.Scalar().One()},
-	}
-	// compute lagrange basis l_j
-	den := g.Scalar().One()
-	var acc = g.Scalar().One()
-	for m, xm := range xs {
-		if i == m {
-			continue
-		}
-		basis = basis.Mul(minusConst(g, xm))
-		den.Sub(xs[i], xm) // den = xi - xm
-		den.Inv(den)       // den = 1 / den
-		acc.Mul(acc, den)  // acc = acc * den
-	}
-
-	// multiply all coefficients by the denominator
-	for i := range basis.coeffs {
-		basis.coeffs[i] = basis.coeffs[i].Mul(basis.coeffs[i], acc)
+	ip, err := NewInterpolator(g, indicesOf(x))
+	if err != nil {
+		return nil, err
 	}
-	return basis
+	return ip.RecoverPubPoly(shares)
 }
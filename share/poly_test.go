@@ -546,3 +546,62 @@ func TestRefreshDKG(test *testing.T) {
 	// Check that the secret and the corresponding (old) public commit match
 	require.True(test, g.Point().Mul(refreshedPriPoly.Secret(), nil).Equal(dkgCommits[0]))
 }
+
+func TestEvalAt(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	priPoly := NewPriPoly(g, 4, nil, g.RandomStream())
+	pubPoly := priPoly.Commit(nil)
+
+	require.True(test, priPoly.EvalAt(g.Scalar().Zero()).Equal(priPoly.Secret()))
+	require.True(test, pubPoly.EvalAt(g.Scalar().Zero()).Equal(pubPoly.Commit()))
+	for i := 0; i < 5; i++ {
+		x := g.Scalar().SetInt64(int64(i + 1))
+		require.True(test, priPoly.EvalAt(x).Equal(priPoly.Eval(i).V))
+		require.True(test, pubPoly.EvalAt(x).Equal(pubPoly.Eval(i).V))
+	}
+	x := g.Scalar().Pick(g.RandomStream())
+	require.True(test, pubPoly.EvalAt(x).Equal(g.Point().Mul(priPoly.EvalAt(x), nil)))
+}
+
+func TestRecoverXY(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	t := 5
+	priPoly := NewPriPoly(g, t, nil, g.RandomStream())
+	pubPoly := priPoly.Commit(nil)
+
+	xs := make([]kyber.Scalar, t)
+	ys := make([]kyber.Scalar, t)
+	commits := make([]kyber.Point, t)
+	for i := range xs {
+		xs[i] = g.Scalar().Pick(g.RandomStream())
+		ys[i] = priPoly.EvalAt(xs[i])
+		commits[i] = pubPoly.EvalAt(xs[i])
+	}
+
+	secret, err := RecoverSecretXY(g, xs, ys)
+	require.NoError(test, err)
+	require.True(test, secret.Equal(priPoly.Secret()))
+	recPri, err := RecoverPriPolyXY(g, xs, ys)
+	require.NoError(test, err)
+	require.True(test, recPri.Equal(priPoly))
+
+	commit, err := RecoverCommitXY(g, xs, commits)
+	require.NoError(test, err)
+	require.True(test, commit.Equal(pubPoly.Commit()))
+	recPub, err := RecoverPubPolyXY(g, xs, commits)
+	require.NoError(test, err)
+	require.True(test, recPub.Equal(pubPoly))
+
+	// p(0) given as one of the points
+	xs[2] = g.Scalar().Zero()
+	ys[2] = priPoly.Secret()
+	secret, err = RecoverSecretXY(g, xs, ys)
+	require.NoError(test, err)
+	require.True(test, secret.Equal(priPoly.Secret()))
+
+	xs[1] = xs[0]
+	_, err = RecoverSecretXY(g, xs, ys)
+	require.Error(test, err)
+	_, err = RecoverCommitXY(g, xs[2:], commits)
+	require.Error(test, err)
+}
@@ -0,0 +1,221 @@
+package pvss
+
+import (
+	"errors"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/proof/dleq"
+	"github.com/drand/kyber/share"
+)
+
+// This file implements the SCRAPE variant of PVSS introduced in "SCRAPE:
+// Scalable Randomness Attested by Public Entities" by Ignacio Cascudo and
+// Bernardo David. Instead of committing to the coefficients of the secret
+// sharing polynomial, the dealer commits to each share as sH = p(i+1)H. All
+// the commitments are checked at once to lie on a polynomial of degree less
+// than t by computing their inner product with a random codeword of the dual
+// of the corresponding Reed-Solomon code, so that verifying a whole deal
+// costs O(n) exponentiations rather than O(n*t). Each encrypted share is then
+// checked against its commitment, either with a DLEQ proof in DDH groups or
+// with a pairing. Since the commitments are the sH values expected by
+// VerifyEncShare and DecShare, the regular decryption and recovery flow
+// applies unchanged to the DDH variant.
+
+var errorCommitVerification = errors.New("commitments do not lie on a polynomial of the expected degree")
+
+// EncSharesScrape creates a list of encrypted publicly verifiable PVSS shares
+// for the given secret and the list of public keys X using the sharing
+// threshold t and the base point H. The function returns the list of shares
+// and the list of commitments sH[i] = p(i+1)H to each share.
+func EncSharesScrape(suite Suite, H kyber.Point, X []kyber.Point, secret kyber.Scalar, t int) (shares []*PubVerShare, sH []kyber.Point, err error) {
+	n := len(X)
+	priPoly := share.NewPriPoly(suite, t, secret, suite.RandomStream())
+	priShares := priPoly.Shares(n)
+
+	values := make([]kyber.Scalar, n)
+	HS := make([]kyber.Point, n)
+	for i := 0; i < n; i++ {
+		values[i] = priShares[i].V
+		HS[i] = H
+	}
+
+	proofs, sH, sX, err := dleq.NewDLEQProofBatch(suite, HS, X, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encShares := make([]*PubVerShare, n)
+	for i := 0; i < n; i++ {
+		encShares[i] = &PubVerShare{share.PubShare{I: priShares[i].I, V: sX[i]}, *proofs[i]}
+	}
+	return encShares, sH, nil
+}
+
+// VerifyEncSharesScrape checks a whole deal created by EncSharesScrape: the
+// commitments sH must lie on a polynomial of threshold t, and each encrypted
+// share must be consistent with its commitment. The encrypted shares and
+// commitments must be given in the order of the public keys X.
+func VerifyEncSharesScrape(suite Suite, H kyber.Point, X []kyber.Point, sH []kyber.Point, encShares []*PubVerShare, t int) error {
+	if len(X) != len(sH) || len(sH) != len(encShares) {
+		return errorDifferentLengths
+	}
+	if err := CheckCommitsScrape(suite, sH, t); err != nil {
+		return err
+	}
+	for i := range encShares {
+		if encShares[i] == nil || encShares[i].S.I != i {
+			return errorEncVerification
+		}
+		if err := VerifyEncShare(suite, H, X[i], sH[i], encShares[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckCommitsScrape checks that the commitments sH[i] to the shares p(i+1)
+// lie on a polynomial of threshold t, using a single inner product with a
+// codeword of the dual code derived from the commitments themselves.
+func CheckCommitsScrape(suite Suite, sH []kyber.Point, t int) error {
+	if !checkDualCode(suite, suite, sH, t) {
+		return errorCommitVerification
+	}
+	return nil
+}
+
+// checkDualCode returns true if the points v[i] are the evaluations at i+1,
+// in the exponent, of a polynomial of threshold t. A codeword c of the dual
+// code is derived from a random polynomial m of degree n-t-1 as
+// c_i = m(i+1) / prod_{j!=i} (i-j), and the inner product of c and v is null
+// for every valid v, while it is null with negligible probability otherwise.
+// The randomness is derived from the points so that the check is
+// deterministic.
+func checkDualCode(g kyber.Group, xof kyber.XOFFactory, v []kyber.Point, t int) bool {
+	n := len(v)
+	if t < 1 || n < t {
+		return false
+	}
+	if n == t {
+		// every vector is a codeword
+		return true
+	}
+
+	var seed []byte
+	for _, p := range v {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return false
+		}
+		seed = append(seed, b...)
+	}
+	m := share.NewPriPoly(g, n-t, nil, xof.XOF(seed))
+	ms := m.Shares(n)
+
+	// prod_{j!=i} (i-j) = (-1)^(n-1-i) * i! * (n-1-i)! for i, j in [0, n)
+	fact := make([]kyber.Scalar, n)
+	fact[0] = g.Scalar().One()
+	for i := 1; i < n; i++ {
+		fact[i] = g.Scalar().Mul(fact[i-1], g.Scalar().SetInt64(int64(i)))
+	}
+	acc := g.Point().Null()
+	tmp := g.Point()
+	c := g.Scalar()
+	for i := 0; i < n; i++ {
+		c.Mul(fact[i], fact[n-1-i])
+		if (n-1-i)%2 == 1 {
+			c.Neg(c)
+		}
+		c.Div(ms[i].V, c)
+		acc.Add(acc, tmp.Mul(c, v[i]))
+	}
+	return acc.Equal(g.Point().Null())
+}
+
+// EncSharesScrapePairing is the pairing-based variant of EncSharesScrape. The
+// public keys X are in G1 and the commitments sH[i] = p(i+1)G2 are made with
+// respect to the base point of G2, so that no proof is needed: anyone can
+// check e(sX[i], G2) == e(X[i], sH[i]). The secret is p(0)G1.
+func EncSharesScrapePairing(suite pairing.Suite, X []kyber.Point, secret kyber.Scalar, t int) (encShares []*share.PubShare, sH []kyber.Point, err error) {
+	n := len(X)
+	g1, g2 := suite.G1(), suite.G2()
+	priPoly := share.NewPriPoly(g2, t, secret, suite.RandomStream())
+	priShares := priPoly.Shares(n)
+
+	encShares = make([]*share.PubShare, n)
+	sH = make([]kyber.Point, n)
+	for i := 0; i < n; i++ {
+		encShares[i] = &share.PubShare{I: priShares[i].I, V: g1.Point().Mul(priShares[i].V, X[i])}
+		sH[i] = g2.Point().Mul(priShares[i].V, nil)
+	}
+	return encShares, sH, nil
+}
+
+// VerifyEncSharesScrapePairing checks a whole deal created by
+// EncSharesScrapePairing, see VerifyEncSharesScrape.
+func VerifyEncSharesScrapePairing(suite pairing.Suite, X []kyber.Point, sH []kyber.Point, encShares []*share.PubShare, t int) error {
+	if len(X) != len(sH) || len(sH) != len(encShares) {
+		return errorDifferentLengths
+	}
+	if !checkDualCode(suite.G2(), suite, sH, t) {
+		return errorCommitVerification
+	}
+	for i := range encShares {
+		if encShares[i] == nil || encShares[i].I != i {
+			return errorEncVerification
+		}
+		if err := VerifyEncSharePairing(suite, X[i], sH[i], encShares[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyEncSharePairing checks that the encrypted share sX is consistent with
+// the commitment sH, i.e. e(sX, G2) == e(X, sH).
+func VerifyEncSharePairing(suite pairing.Suite, X kyber.Point, sH kyber.Point, encShare *share.PubShare) error {
+	if !suite.ValidatePairing(encShare.V, suite.G2().Point().Base(), X, sH) {
+		return errorEncVerification
+	}
+	return nil
+}
+
+// DecSharePairing first verifies the encrypted share against its commitment
+// and, if valid, decrypts it with the private key x. No proof is needed for
+// the decrypted share, see VerifyDecSharePairing.
+func DecSharePairing(suite pairing.Suite, X kyber.Point, sH kyber.Point, x kyber.Scalar, encShare *share.PubShare) (*share.PubShare, error) {
+	if err := VerifyEncSharePairing(suite, X, sH, encShare); err != nil {
+		return nil, err
+	}
+	g1 := suite.G1()
+	V := g1.Point().Mul(g1.Scalar().Inv(x), encShare.V) // decryption: x^{-1} * (xS)
+	return &share.PubShare{I: encShare.I, V: V}, nil
+}
+
+// VerifyDecSharePairing checks that the decrypted share sG1 is consistent with
+// the commitment sH, i.e. e(sG1, G2) == e(G1, sH).
+func VerifyDecSharePairing(suite pairing.Suite, sH kyber.Point, decShare *share.PubShare) error {
+	if !suite.ValidatePairing(decShare.V, suite.G2().Point().Base(), suite.G1().Point().Base(), sH) {
+		return errorDecVerification
+	}
+	return nil
+}
+
+// RecoverSecretPairing first verifies the given decrypted shares against the
+// commitments sH, given in the order of the share indices, and then tries to
+// recover the shared secret p(0)G1.
+func RecoverSecretPairing(suite pairing.Suite, sH []kyber.Point, decShares []*share.PubShare, t int, n int) (kyber.Point, error) {
+	var shares []*share.PubShare
+	for _, s := range decShares {
+		if s == nil || s.I < 0 || s.I >= len(sH) {
+			continue
+		}
+		if err := VerifyDecSharePairing(suite, sH[s.I], s); err == nil {
+			shares = append(shares, s)
+		}
+	}
+	if len(shares) < t {
+		return nil, errorTooFewShares
+	}
+	return share.RecoverCommit(suite.G1(), shares, t, n)
+}
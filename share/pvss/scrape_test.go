@@ -0,0 +1,105 @@
+package pvss
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/share"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPVSSScrape(test *testing.T) {
+	suite := edwards25519.NewBlakeSHA256Ed25519()
+	G := suite.Point().Base()
+	H := suite.Point().Pick(suite.XOF([]byte("H")))
+	n := 10
+	t := 2*n/3 + 1
+	x := make([]kyber.Scalar, n) // trustee private keys
+	X := make([]kyber.Point, n)  // trustee public keys
+	for i := 0; i < n; i++ {
+		x[i] = suite.Scalar().Pick(suite.RandomStream())
+		X[i] = suite.Point().Mul(x[i], nil)
+	}
+	secret := suite.Scalar().Pick(suite.RandomStream())
+
+	// (1) Share distribution (dealer)
+	encShares, sH, err := EncSharesScrape(suite, H, X, secret, t)
+	require.NoError(test, err)
+	require.NoError(test, VerifyEncSharesScrape(suite, H, X, sH, encShares, t))
+
+	// (2) Share decryption (trustees), as with EncShares
+	var K []kyber.Point  // good public keys
+	var E []*PubVerShare // good encrypted shares
+	var D []*PubVerShare // good decrypted shares
+	for i := 0; i < n; i++ {
+		ds, err := DecShare(suite, H, X[i], sH[i], x[i], encShares[i])
+		require.NoError(test, err)
+		K = append(K, X[i])
+		E = append(E, encShares[i])
+		D = append(D, ds)
+	}
+
+	// (3) Recover the secret (dealer/3rd party)
+	recovered, err := RecoverSecret(suite, G, K, E, D, t, n)
+	require.NoError(test, err)
+	require.True(test, suite.Point().Mul(secret, nil).Equal(recovered))
+
+	// commitments not lying on a polynomial of threshold t
+	bad := append([]kyber.Point{}, sH...)
+	bad[3] = suite.Point().Pick(suite.RandomStream())
+	require.Error(test, CheckCommitsScrape(suite, bad, t))
+	require.Error(test, VerifyEncSharesScrape(suite, H, X, bad, encShares, t))
+
+	// commitments of a polynomial of a higher degree
+	_, sH2, err := EncSharesScrape(suite, H, X, secret, t+1)
+	require.NoError(test, err)
+	require.Error(test, CheckCommitsScrape(suite, sH2, t))
+	require.NoError(test, CheckCommitsScrape(suite, sH2, t+1))
+
+	// encrypted share not matching its commitment
+	encShares[2], encShares[4] = encShares[4], encShares[2]
+	require.Error(test, VerifyEncSharesScrape(suite, H, X, sH, encShares, t))
+}
+
+func TestPVSSScrapePairing(test *testing.T) {
+	suite := bn256.NewSuite()
+	g1 := suite.G1()
+	n := 7
+	t := 4
+	x := make([]kyber.Scalar, n)
+	X := make([]kyber.Point, n)
+	for i := 0; i < n; i++ {
+		x[i] = g1.Scalar().Pick(suite.RandomStream())
+		X[i] = g1.Point().Mul(x[i], nil)
+	}
+	secret := g1.Scalar().Pick(suite.RandomStream())
+
+	encShares, sH, err := EncSharesScrapePairing(suite, X, secret, t)
+	require.NoError(test, err)
+	require.NoError(test, VerifyEncSharesScrapePairing(suite, X, sH, encShares, t))
+
+	decShares := make([]*share.PubShare, n)
+	for i := 0; i < n; i++ {
+		decShares[i], err = DecSharePairing(suite, X[i], sH[i], x[i], encShares[i])
+		require.NoError(test, err)
+	}
+	// a wrong decrypted share is discarded
+	decShares[1] = &share.PubShare{I: 1, V: g1.Point().Pick(suite.RandomStream())}
+	require.Error(test, VerifyDecSharePairing(suite, sH[1], decShares[1]))
+
+	recovered, err := RecoverSecretPairing(suite, sH, decShares, t, n)
+	require.NoError(test, err)
+	require.True(test, g1.Point().Mul(secret, nil).Equal(recovered))
+
+	_, err = RecoverSecretPairing(suite, sH, decShares[:t], t, n)
+	require.Error(test, err)
+
+	_, err = DecSharePairing(suite, X[0], sH[1], x[0], encShares[0])
+	require.Error(test, err)
+
+	bad := append([]kyber.Point{}, sH...)
+	bad[0] = suite.G2().Point().Pick(suite.RandomStream())
+	require.Error(test, VerifyEncSharesScrapePairing(suite, X, bad, encShares, t))
+}
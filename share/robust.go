@@ -0,0 +1,360 @@
+package share
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/drand/kyber"
+)
+
+var errorTooManyFaults = errors.New("share: too many faulty shares to decode")
+
+var errorInvalidThreshold = errors.New("share: invalid threshold")
+
+// RobustRecoverSecret reconstructs the shared secret p(0) from a list of
+// private shares of which some may be corrupted. Given m valid shares of a
+// polynomial of threshold t, up to (m-t)/2 faulty shares are detected and
+// corrected using Berlekamp-Welch decoding. It returns the secret along with
+// the indices of the shares that do not lie on the recovered polynomial.
+// Supplying more faulty shares than can be corrected either returns an
+// error or, if the faulty shares happen to be consistent with another
+// polynomial, a wrong result: decoding is unique only within the bound.
+func RobustRecoverSecret(g kyber.Group, shares []*PriShare, t, n int) (kyber.Scalar, []int, error) {
+	poly, faulty, err := RobustRecoverPriPoly(g, shares, t, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return poly.Secret(), faulty, nil
+}
+
+// RobustRecoverPriPoly is like RecoverPriPoly but tolerates up to (m-t)/2
+// faulty shares out of the m valid shares given, see RobustRecoverSecret. It
+// returns the recovered polynomial along with the indices of the faulty
+// shares.
+func RobustRecoverPriPoly(g kyber.Group, shares []*PriShare, t, n int) (*PriPoly, []int, error) {
+	if t <= 0 || t > n {
+		return nil, nil, errorInvalidThreshold
+	}
+	sorted, err := sortedPriShares(shares, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sorted) < t {
+		return nil, nil, errors.New("share: not enough shares to recover secret")
+	}
+
+	xs := make([]kyber.Scalar, len(sorted))
+	ys := make([]kyber.Scalar, len(sorted))
+	for i, s := range sorted {
+		xs[i] = g.Scalar().SetInt64(int64(s.I + 1))
+		ys[i] = s.V
+	}
+
+	coeffs, err := berlekampWelch(g, xs, ys, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	poly := &PriPoly{g: g, coeffs: coeffs}
+
+	var faulty []int
+	for _, s := range sorted {
+		if !poly.Eval(s.I).V.Equal(s.V) {
+			faulty = append(faulty, s.I)
+		}
+	}
+	return poly, faulty, nil
+}
+
+// RobustRecoverCommit reconstructs the secret commitment p(0) from a list of
+// public shares of which up to (m-t)/2 out of the m valid shares given may be
+// faulty. It returns the commitment along with the indices of the faulty
+// shares. Since the shares live in a group where Berlekamp-Welch decoding is
+// not possible, the faulty shares are located by searching for the largest
+// subset of shares lying on a single polynomial; the cost of this search
+// grows combinatorially with the number of faulty shares. Callers able to
+// verify each share individually, e.g. against a PubPoly, should do so
+// and use RecoverCommit instead.
+func RobustRecoverCommit(g kyber.Group, shares []*PubShare, t, n int) (kyber.Point, []int, error) {
+	if t <= 0 || t > n {
+		return nil, nil, errorInvalidThreshold
+	}
+	sorted, err := sortedPubShares(shares, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := len(sorted)
+	if m < t {
+		return nil, nil, errors.New("share: not enough good public shares to reconstruct secret commitment")
+	}
+
+	xs := make([]kyber.Scalar, m)
+	for i, s := range sorted {
+		xs[i] = g.Scalar().SetInt64(int64(s.I + 1))
+	}
+
+	maxFaults := (m - t) / 2
+	for e := 0; e <= maxFaults; e++ {
+		removed := make([]int, e)
+		for i := range removed {
+			removed[i] = i
+		}
+		for {
+			kept := complementIndices(m, removed)
+			if consistentCommits(g, xs, sorted, kept, t) {
+				good := make([]*PubShare, len(kept))
+				for i, k := range kept {
+					good[i] = sorted[k]
+				}
+				commit, err := RecoverCommit(g, good, t, n)
+				if err != nil {
+					return nil, nil, err
+				}
+				faulty := make([]int, len(removed))
+				for i, r := range removed {
+					faulty[i] = sorted[r].I
+				}
+				return commit, faulty, nil
+			}
+			if !nextCombination(removed, m) {
+				break
+			}
+		}
+	}
+	return nil, nil, errorTooManyFaults
+}
+
+// sortedPriShares returns the valid shares sorted by index, rejecting
+// duplicated indices.
+func sortedPriShares(shares []*PriShare, n int) ([]*PriShare, error) {
+	sorted := make([]*PriShare, 0, n)
+	for _, s := range shares {
+		if s == nil || s.V == nil || s.I < 0 {
+			continue
+		}
+		sorted = append(sorted, s)
+	}
+	sort.Sort(byIndexScalar(sorted))
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].I == sorted[i-1].I {
+			return nil, errors.New("share: duplicate share index")
+		}
+	}
+	return sorted, nil
+}
+
+// sortedPubShares is the public version of sortedPriShares.
+func sortedPubShares(shares []*PubShare, n int) ([]*PubShare, error) {
+	sorted := make([]*PubShare, 0, n)
+	for _, s := range shares {
+		if s == nil || s.V == nil || s.I < 0 {
+			continue
+		}
+		sorted = append(sorted, s)
+	}
+	sort.Sort(byIndexPub(sorted))
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].I == sorted[i-1].I {
+			return nil, errors.New("share: duplicate share index")
+		}
+	}
+	return sorted, nil
+}
+
+// berlekampWelch returns the coefficients of the unique polynomial P of
+// degree less than t agreeing with all but at most (m-t)/2 of the m points
+// (xs[i], ys[i]). It finds an error locator E, monic of degree e, and Q of
+// degree less than e+t such that Q(x_i) = y_i * E(x_i) for every i, and
+// returns P = Q / E.
+func berlekampWelch(g kyber.Group, xs, ys []kyber.Scalar, t int) ([]kyber.Scalar, error) {
+	m := len(xs)
+	e := (m - t) / 2
+	nq := e + t
+	cols := nq + e
+
+	// Each row encodes
+	// sum_j q_j x_i^j - y_i * sum_{k<e} E_k x_i^k = y_i * x_i^e
+	rows := make([][]kyber.Scalar, m)
+	for i := range rows {
+		row := make([]kyber.Scalar, cols+1)
+		pow := g.Scalar().One()
+		for j := 0; j < nq; j++ {
+			row[j] = pow.Clone()
+			if j < e {
+				row[nq+j] = g.Scalar().Neg(g.Scalar().Mul(ys[i], pow))
+			}
+			if j == e {
+				row[cols] = g.Scalar().Mul(ys[i], pow)
+			}
+			pow.Mul(pow, xs[i])
+		}
+		rows[i] = row
+	}
+
+	sol, ok := solveLinear(g, rows, cols)
+	if !ok {
+		return nil, errorTooManyFaults
+	}
+
+	q := sol[:nq]
+	locator := make([]kyber.Scalar, e+1)
+	copy(locator, sol[nq:])
+	locator[e] = g.Scalar().One()
+
+	quo, rem := polyDivMonic(g, q, locator)
+	zero := g.Scalar().Zero()
+	for _, r := range rem {
+		if !r.Equal(zero) {
+			return nil, errorTooManyFaults
+		}
+	}
+	coeffs := make([]kyber.Scalar, t)
+	for i := range coeffs {
+		if i < len(quo) {
+			coeffs[i] = quo[i]
+		} else {
+			coeffs[i] = g.Scalar().Zero()
+		}
+	}
+	return coeffs, nil
+}
+
+// solveLinear solves the linear system given as an augmented matrix with the
+// given number of unknowns using Gaussian elimination. Free variables are set
+// to zero. It returns false if the system is inconsistent. The rows are
+// modified in place.
+func solveLinear(g kyber.Group, rows [][]kyber.Scalar, cols int) ([]kyber.Scalar, bool) {
+	zero := g.Scalar().Zero()
+	tmp := g.Scalar()
+	pivots := make([]int, 0, cols)
+	r := 0
+	for c := 0; c < cols && r < len(rows); c++ {
+		p := -1
+		for i := r; i < len(rows); i++ {
+			if !rows[i][c].Equal(zero) {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		rows[r], rows[p] = rows[p], rows[r]
+		inv := g.Scalar().Inv(rows[r][c])
+		for j := c; j <= cols; j++ {
+			rows[r][j].Mul(rows[r][j], inv)
+		}
+		for i := range rows {
+			if i == r || rows[i][c].Equal(zero) {
+				continue
+			}
+			f := rows[i][c].Clone()
+			for j := c; j <= cols; j++ {
+				rows[i][j].Sub(rows[i][j], tmp.Mul(f, rows[r][j]))
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+	for i := r; i < len(rows); i++ {
+		if !rows[i][cols].Equal(zero) {
+			return nil, false
+		}
+	}
+	sol := make([]kyber.Scalar, cols)
+	for i := range sol {
+		sol[i] = g.Scalar().Zero()
+	}
+	for i, c := range pivots {
+		sol[c] = rows[i][cols].Clone()
+	}
+	return sol, true
+}
+
+// polyDivMonic divides the polynomial a by the monic polynomial b, both given
+// by their coefficients in increasing degree order, and returns the quotient
+// and the remainder.
+func polyDivMonic(g kyber.Group, a, b []kyber.Scalar) (quo, rem []kyber.Scalar) {
+	rem = make([]kyber.Scalar, len(a))
+	for i := range a {
+		rem[i] = a[i].Clone()
+	}
+	db := len(b) - 1
+	if len(a) <= db {
+		return nil, rem
+	}
+	quo = make([]kyber.Scalar, len(a)-db)
+	tmp := g.Scalar()
+	for i := len(a) - 1; i >= db; i-- {
+		c := rem[i].Clone()
+		quo[i-db] = c
+		for j := 0; j <= db; j++ {
+			rem[i-db+j].Sub(rem[i-db+j], tmp.Mul(c, b[j]))
+		}
+	}
+	return quo, rem[:db]
+}
+
+// consistentCommits returns true if the public shares at the kept positions
+// all lie on the same polynomial of threshold t, by interpolating the first t
+// of them and checking the remaining ones.
+func consistentCommits(g kyber.Group, xs []kyber.Scalar, shares []*PubShare, kept []int, t int) bool {
+	basis := kept[:t]
+	num := g.Scalar()
+	den := g.Scalar()
+	tmp := g.Scalar()
+	acc := g.Point()
+	term := g.Point()
+	for _, k := range kept[t:] {
+		acc.Null()
+		for _, i := range basis {
+			num.One()
+			den.One()
+			for _, j := range basis {
+				if i == j {
+					continue
+				}
+				num.Mul(num, tmp.Sub(xs[k], xs[j]))
+				den.Mul(den, tmp.Sub(xs[i], xs[j]))
+			}
+			term.Mul(num.Div(num, den), shares[i].V)
+			acc.Add(acc, term)
+		}
+		if !acc.Equal(shares[k].V) {
+			return false
+		}
+	}
+	return true
+}
+
+// complementIndices returns the positions in [0, m) not listed in removed,
+// which must be sorted.
+func complementIndices(m int, removed []int) []int {
+	kept := make([]int, 0, m-len(removed))
+	r := 0
+	for i := 0; i < m; i++ {
+		if r < len(removed) && removed[r] == i {
+			r++
+			continue
+		}
+		kept = append(kept, i)
+	}
+	return kept
+}
+
+// nextCombination advances comb to the next k-combination of [0, m) in
+// lexicographic order and returns false once all combinations are exhausted.
+func nextCombination(comb []int, m int) bool {
+	k := len(comb)
+	i := k - 1
+	for i >= 0 && comb[i] == m-k+i {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	comb[i]++
+	for j := i + 1; j < k; j++ {
+		comb[j] = comb[j-1] + 1
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/drand/kyber/group/edwards25519"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobustSecretRecovery(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	n := 10
+	t := 4
+	poly := NewPriPoly(g, t, nil, g.RandomStream())
+	shares := poly.Shares(n)
+
+	// no faulty share
+	secret, faulty, err := RobustRecoverSecret(g, shares, t, n)
+	require.NoError(test, err)
+	require.Empty(test, faulty)
+	require.True(test, secret.Equal(poly.Secret()))
+
+	// (n-t)/2 = 3 faulty shares and a missing one
+	shares[1] = &PriShare{I: 1, V: g.Scalar().Pick(g.RandomStream())}
+	shares[4] = &PriShare{I: 4, V: g.Scalar().Pick(g.RandomStream())}
+	shares[8] = &PriShare{I: 8, V: g.Scalar().Pick(g.RandomStream())}
+	shares[6] = nil
+	// with 9 shares, only up to (9-4)/2 = 2 faults can be corrected
+	_, _, err = RobustRecoverSecret(g, shares, t, n)
+	require.Error(test, err)
+
+	shares[6] = poly.Eval(6)
+	recovered, faulty, err := RobustRecoverPriPoly(g, shares, t, n)
+	require.NoError(test, err)
+	require.Equal(test, []int{1, 4, 8}, faulty)
+	require.True(test, recovered.Equal(poly))
+
+	// the regular recovery is fooled by the faulty shares
+	wrong, err := RecoverSecret(g, shares, t, n)
+	require.NoError(test, err)
+	require.False(test, wrong.Equal(poly.Secret()))
+}
+
+func TestRobustSecretRecoveryNotEnough(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	n := 5
+	t := 5
+	poly := NewPriPoly(g, t, nil, g.RandomStream())
+	shares := poly.Shares(n)
+	shares[0] = nil
+	_, _, err := RobustRecoverSecret(g, shares, t, n)
+	require.Error(test, err)
+
+	shares = poly.Shares(n)
+	shares[0] = shares[1]
+	_, _, err = RobustRecoverSecret(g, shares, t, n)
+	require.Error(test, err)
+
+	shares = poly.Shares(n)
+	for _, th := range []int{0, -1, n + 1} {
+		_, _, err = RobustRecoverSecret(g, shares, th, n)
+		require.Error(test, err)
+		_, _, err = RobustRecoverCommit(g, poly.Commit(nil).Shares(n), th, n)
+		require.Error(test, err)
+	}
+}
+
+func TestRobustPublicRecovery(test *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	n := 9
+	t := 4
+	priPoly := NewPriPoly(g, t, nil, g.RandomStream())
+	pubPoly := priPoly.Commit(nil)
+	shares := pubPoly.Shares(n)
+
+	shares[0] = &PubShare{I: 0, V: g.Point().Pick(g.RandomStream())}
+	shares[5] = &PubShare{I: 5, V: g.Point().Pick(g.RandomStream())}
+
+	commit, faulty, err := RobustRecoverCommit(g, shares, t, n)
+	require.NoError(test, err)
+	require.Equal(test, []int{0, 5}, faulty)
+	require.True(test, commit.Equal(pubPoly.Commit()))
+
+	shares[7] = &PubShare{I: 7, V: g.Point().Pick(g.RandomStream())}
+	_, _, err = RobustRecoverCommit(g, shares, t, n)
+	require.Error(test, err)
+}
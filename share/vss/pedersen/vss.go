@@ -14,6 +14,7 @@ import (
 
 	"github.com/drand/kyber"
 	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/share/event"
 	"github.com/drand/kyber/sign/schnorr"
 	"go.dedis.ch/protobuf"
 )
@@ -355,10 +356,13 @@ func NewVerifier(suite Suite, longterm kyber.Scalar, dealerKey kyber.Point,
 func (v *Verifier) ProcessEncryptedDeal(e *EncryptedDeal) (*Response, error) {
 	d, err := v.decryptDeal(e)
 	if err != nil {
+		v.emit(event.DealRejected{Dealer: v.dealerIndex, ShareHolder: uint32(v.index), Reason: err.Error()})
 		return nil, err
 	}
 	if d.SecShare.I != v.index {
-		return nil, errors.New("vss: verifier got wrong index from deal")
+		err = errors.New("vss: verifier got wrong index from deal")
+		v.emit(event.DealRejected{Dealer: v.dealerIndex, ShareHolder: uint32(v.index), Reason: err.Error()})
+		return nil, err
 	}
 
 	t := int(d.T)
@@ -380,6 +384,12 @@ func (v *Verifier) ProcessEncryptedDeal(e *EncryptedDeal) (*Response, error) {
 	if err == errDealAlreadyProcessed {
 		return nil, err
 	}
+	if err != nil {
+		v.emit(event.DealRejected{Dealer: v.dealerIndex, ShareHolder: uint32(v.index), Reason: err.Error()})
+		v.emit(event.ComplaintIssued{Dealer: v.dealerIndex, ShareHolder: uint32(v.index)})
+	} else {
+		v.emit(event.DealReceived{Dealer: v.dealerIndex, ShareHolder: uint32(v.index)})
+	}
 
 	if r.Signature, err = schnorr.Sign(v.suite, v.longterm, r.Hash(v.suite)); err != nil {
 		return nil, err
@@ -524,6 +534,9 @@ type Aggregator struct {
 	t         int
 	badDealer bool
 	timeout   bool
+
+	events      event.Handler
+	dealerIndex uint32
 }
 
 func newAggregator(suite Suite, dealer kyber.Point, verifiers, commitments []kyber.Point, t int, sid []byte) *Aggregator {
@@ -549,6 +562,19 @@ func NewEmptyAggregator(suite Suite, verifiers []kyber.Point) *Aggregator {
 	}
 }
 
+// SetEventHandler sets the handler receiving the events of the protocol, such
+// as the deals rejected or the complaints received. Since the aggregator only
+// knows the dealer by its public key, the events report the given index as
+// the index of the dealer.
+func (a *Aggregator) SetEventHandler(h event.Handler, dealerIndex uint32) {
+	a.events = h
+	a.dealerIndex = dealerIndex
+}
+
+func (a *Aggregator) emit(e event.Event) {
+	event.Emit(a.events, e)
+}
+
 var errDealAlreadyProcessed = errors.New("vss: verifier already received a deal")
 
 // VerifyDeal analyzes the deal and returns an error if it's incorrect. If
@@ -624,7 +650,13 @@ func (a *Aggregator) verifyResponse(r *Response) error {
 		return err
 	}
 
-	return a.addResponse(r)
+	if err := a.addResponse(r); err != nil {
+		return err
+	}
+	if r.Status == StatusComplaint {
+		a.emit(event.ComplaintReceived{Dealer: a.dealerIndex, ShareHolder: r.Index})
+	}
+	return nil
 }
 
 func (a *Aggregator) verifyJustification(j *Justification) error {
@@ -642,9 +674,12 @@ func (a *Aggregator) verifyJustification(j *Justification) error {
 	if err := a.VerifyDeal(j.Deal, false); err != nil {
 		// if one justification is bad, then flag the dealer as malicious
 		a.badDealer = true
+		a.emit(event.JustificationRejected{Dealer: a.dealerIndex, ShareHolder: j.Index, Reason: err.Error()})
+		a.emit(event.NodeEvicted{Node: a.dealerIndex, Dealer: true, Reason: "invalid justification"})
 		return err
 	}
 	r.Status = StatusApproval
+	a.emit(event.JustificationAccepted{Dealer: a.dealerIndex, ShareHolder: j.Index})
 	return nil
 }
 
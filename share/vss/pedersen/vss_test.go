@@ -7,6 +7,7 @@ import (
 
 	"github.com/drand/kyber"
 	"github.com/drand/kyber/group/edwards25519"
+	"github.com/drand/kyber/share/event"
 	"github.com/drand/kyber/sign/schnorr"
 	"github.com/drand/kyber/xof/blake2xb"
 	"github.com/stretchr/testify/assert"
@@ -351,6 +352,44 @@ func TestVSSAggregatorVerifyJustification(t *testing.T) {
 
 }
 
+func TestVSSEvents(t *testing.T) {
+	dealer, verifiers := genAll()
+	v := verifiers[0]
+	var events []event.Event
+	record := event.HandlerFunc(func(e event.Event) { events = append(events, e) })
+	v.SetEventHandler(record, 3)
+	dealer.SetEventHandler(record, 3)
+
+	// valid deal
+	verifiers[1].SetEventHandler(record, 3)
+	encD, _ := dealer.EncryptedDeal(1)
+	_, err := verifiers[1].ProcessEncryptedDeal(encD)
+	require.NoError(t, err)
+	require.Equal(t, []event.Event{event.DealReceived{Dealer: 3, ShareHolder: 1}}, events)
+	events = nil
+
+	// invalid share: complaint and justification
+	d := dealer.deals[0]
+	goodV := d.SecShare.V
+	d.SecShare.V = suite.Scalar().Pick(rng)
+	encD, _ = dealer.EncryptedDeal(0)
+	d.SecShare.V = goodV
+	resp, err := v.ProcessEncryptedDeal(encD)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.IsType(t, event.DealRejected{}, events[0])
+	require.Equal(t, event.ComplaintIssued{Dealer: 3, ShareHolder: 0}, events[1])
+	events = nil
+
+	j, err := dealer.ProcessResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, []event.Event{event.ComplaintReceived{Dealer: 3, ShareHolder: 0}}, events)
+	events = nil
+
+	require.NoError(t, v.ProcessJustification(j))
+	require.Equal(t, []event.Event{event.JustificationAccepted{Dealer: 3, ShareHolder: 0}}, events)
+}
+
 func TestVSSAggregatorVerifyResponseDuplicate(t *testing.T) {
 	dealer, verifiers := genAll()
 	v1 := verifiers[0]
@@ -11,6 +11,7 @@ func init() {
 	// Those are variable time suites that shouldn't be used
 	// in production environment when possible
 	register(nist.NewBlakeSHA256P256())
+	register(nist.NewBlakeSHA384P384())
 	register(nist.NewBlakeSHA256QR512())
 	register(bn256.NewSuiteG1())
 	register(bn256.NewSuiteG2())
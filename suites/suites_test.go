@@ -13,6 +13,7 @@ func TestSuites_Find(t *testing.T) {
 		"bn256.G2",
 		"bn256.GT",
 		"P256",
+		"P384",
 		"Residue512",
 	}
 